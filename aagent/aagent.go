@@ -30,6 +30,13 @@ type AAgent struct {
 
 	source string
 
+	// set when managing machines, used to start reloaded machines
+	ctx context.Context
+	wg  *sync.WaitGroup
+
+	// held while loading machines from source
+	loadMu sync.Mutex
+
 	sync.Mutex
 }
 
@@ -59,6 +66,11 @@ func New(dir string, fw model.ChoriaProvider) (aa *AAgent, err error) {
 
 // ManageMachines start observing the source directories starting and stopping machines based on changes on disk
 func (a *AAgent) ManageMachines(ctx context.Context, wg *sync.WaitGroup) error {
+	a.Lock()
+	a.ctx = ctx
+	a.wg = wg
+	a.Unlock()
+
 	wg.Add(1)
 	go a.watchSource(ctx, wg)
 
@@ -72,6 +84,10 @@ func (a *AAgent) Transition(name string, version string, path string, id string,
 		return fmt.Errorf("could not find machine matching criteria name='%s', version='%s', path='%s', id='%s'", name, version, path, id)
 	}
 
+	if m.machine.IsPaused() {
+		return fmt.Errorf("machine %s is paused", m.machine.Name())
+	}
+
 	if !m.machine.Can(transition) {
 		return fmt.Errorf("transition %s is not valid while in %v state", transition, m.machine.State())
 	}
@@ -84,6 +100,82 @@ func (a *AAgent) Transition(name string, version string, path string, id string,
 	return nil
 }
 
+// Pause pauses a running machine, paused machines do not run their watchers and ignore transitions
+func (a *AAgent) Pause(name string, version string, path string, id string) error {
+	m := a.findMachine(name, version, path, id)
+	if m == nil {
+		return fmt.Errorf("could not find machine matching criteria name='%s', version='%s', path='%s', id='%s'", name, version, path, id)
+	}
+
+	m.machine.Pause()
+
+	return nil
+}
+
+// Resume resumes a paused machine
+func (a *AAgent) Resume(name string, version string, path string, id string) error {
+	m := a.findMachine(name, version, path, id)
+	if m == nil {
+		return fmt.Errorf("could not find machine matching criteria name='%s', version='%s', path='%s', id='%s'", name, version, path, id)
+	}
+
+	m.machine.Resume()
+
+	return nil
+}
+
+// DataPut stores a data item in a running machine
+func (a *AAgent) DataPut(name string, version string, path string, id string, key string, value any) error {
+	m := a.findMachine(name, version, path, id)
+	if m == nil {
+		return fmt.Errorf("could not find machine matching criteria name='%s', version='%s', path='%s', id='%s'", name, version, path, id)
+	}
+
+	return m.machine.DataPut(key, value)
+}
+
+// Reload stops a running machine and loads it again from its source directory
+func (a *AAgent) Reload(name string, version string, path string, id string) error {
+	m := a.findMachine(name, version, path, id)
+	if m == nil {
+		return fmt.Errorf("could not find machine matching criteria name='%s', version='%s', path='%s', id='%s'", name, version, path, id)
+	}
+
+	if m.plugin || m.machine.IsEmbedded() {
+		return fmt.Errorf("cannot reload compiled in machine %s", m.machine.Name())
+	}
+
+	a.Lock()
+	ctx := a.ctx
+	wg := a.wg
+	a.Unlock()
+
+	if ctx == nil {
+		return fmt.Errorf("machines are not being managed")
+	}
+
+	a.loadMu.Lock()
+	defer a.loadMu.Unlock()
+
+	a.logger.Infof("Reloading machine %s from %s", m.machine.Name(), m.path)
+
+	m.machine.Stop()
+	err := a.deleteByPath(m.path)
+	if err != nil {
+		return err
+	}
+
+	// like when the manifest changes on disk give the old machine a chance to exit
+	util.InterruptibleSleep(ctx, time.Second)
+
+	err = a.loadMachine(ctx, m.path)
+	if err != nil {
+		return err
+	}
+
+	return a.startMachines(ctx, wg)
+}
+
 func (a *AAgent) configureMachine(aa *machine.Machine) {
 	aa.SetFactSource(a.fw.Facts)
 	aa.SetIdentity(a.fw.Identity())
@@ -243,6 +335,9 @@ func (a *AAgent) watchSource(ctx context.Context, wg *sync.WaitGroup) {
 			return
 		}
 
+		a.loadMu.Lock()
+		defer a.loadMu.Unlock()
+
 		err := a.loadFromSource(ctx)
 		if err != nil {
 			a.logger.Errorf("Could not load Autonomous Agents from %s: %s", a.source, err)
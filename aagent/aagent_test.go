@@ -0,0 +1,163 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package aagent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	notifier "github.com/choria-io/go-choria/aagent/notifiers/choria"
+	imock "github.com/choria-io/go-choria/inter/imocks"
+	"github.com/golang/mock/gomock"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestAAgent(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent")
+}
+
+var _ = Describe("AAgent", func() {
+	var (
+		mockctl *gomock.Controller
+		fw      *model.MockChoriaProvider
+		aa      *AAgent
+		td      string
+		mpath   string
+		ctx     context.Context
+		cancel  context.CancelFunc
+		wg      *sync.WaitGroup
+		err     error
+	)
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		ctx, cancel = context.WithCancel(context.Background())
+		wg = &sync.WaitGroup{}
+
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+
+		fw = model.NewMockChoriaProvider(mockctl)
+		fw.EXPECT().Logger(gomock.Any()).Return(logrus.NewEntry(logger)).AnyTimes()
+		fw.EXPECT().Facts().Return(nil).AnyTimes()
+		fw.EXPECT().PublishRaw(gomock.Any(), gomock.Any()).AnyTimes()
+		fw.EXPECT().Identity().Return("ginkgo.example.net").AnyTimes()
+		fw.EXPECT().MainCollective().Return("choria").AnyTimes()
+		fw.EXPECT().PrometheusTextFileDir().Return("").AnyTimes()
+		fw.EXPECT().ScoutOverridesPath().Return("").AnyTimes()
+		fw.EXPECT().ServerStatusFile().Return("", 0).AnyTimes()
+		conn := imock.NewMockConnector(mockctl)
+		conn.EXPECT().Nats().Return(&nats.Conn{}).AnyTimes()
+		fw.EXPECT().Connector().Return(conn).AnyTimes()
+
+		td, err = os.MkdirTemp("", "")
+		Expect(err).ToNot(HaveOccurred())
+
+		mpath = filepath.Join(td, "ginkgo")
+		Expect(os.Mkdir(mpath, 0700)).To(Succeed())
+		myaml, err := os.ReadFile("testdata/ginkgo/machine.yaml")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(os.WriteFile(filepath.Join(mpath, "machine.yaml"), myaml, 0600)).To(Succeed())
+
+		n, err := notifier.New(fw)
+		Expect(err).ToNot(HaveOccurred())
+
+		aa = &AAgent{
+			fw:       fw,
+			logger:   fw.Logger("aagent"),
+			source:   td,
+			machines: []*managedMachine{},
+			notifier: n,
+		}
+
+		Expect(aa.loadMachine(ctx, mpath)).To(Succeed())
+		Expect(aa.machines).To(HaveLen(1))
+	})
+
+	AfterEach(func() {
+		cancel()
+		os.RemoveAll(td)
+		mockctl.Finish()
+	})
+
+	Describe("Pause and Resume", func() {
+		It("Should require a known machine", func() {
+			Expect(aa.Pause("other", "", "", "")).To(MatchError("could not find machine matching criteria name='other', version='', path='', id=''"))
+			Expect(aa.Resume("other", "", "", "")).To(MatchError("could not find machine matching criteria name='other', version='', path='', id=''"))
+		})
+
+		It("Should pause and resume the machine", func() {
+			m := aa.machines[0].machine
+
+			Expect(aa.Pause("ginkgo", "1.0.0", "", "")).To(Succeed())
+			Expect(m.IsPaused()).To(BeTrue())
+			Expect(aa.Transition("ginkgo", "", "", "", "fire_1")).To(MatchError("machine ginkgo is paused"))
+
+			Expect(aa.Resume("", "", mpath, "")).To(Succeed())
+			Expect(m.IsPaused()).To(BeFalse())
+			Expect(aa.Transition("ginkgo", "", "", "", "fire_1")).To(Succeed())
+			Expect(m.State()).To(Equal("one"))
+		})
+	})
+
+	Describe("DataPut", func() {
+		It("Should require a known machine", func() {
+			Expect(aa.DataPut("", "", "", "x", "key", "value")).To(MatchError("could not find machine matching criteria name='', version='', path='', id='x'"))
+		})
+
+		It("Should store the data", func() {
+			m := aa.machines[0].machine
+
+			Expect(aa.DataPut("", "", "", m.InstanceID(), "key", "value")).To(Succeed())
+			v, ok := m.DataGet("key")
+			Expect(ok).To(BeTrue())
+			Expect(v).To(Equal("value"))
+		})
+	})
+
+	Describe("Reload", func() {
+		It("Should require a known machine", func() {
+			Expect(aa.Reload("other", "", "", "")).To(MatchError("could not find machine matching criteria name='other', version='', path='', id=''"))
+		})
+
+		It("Should not reload compiled in machines", func() {
+			aa.machines[0].plugin = true
+			Expect(aa.Reload("ginkgo", "", "", "")).To(MatchError("cannot reload compiled in machine ginkgo"))
+		})
+
+		It("Should require machines to be managed", func() {
+			Expect(aa.Reload("ginkgo", "", "", "")).To(MatchError("machines are not being managed"))
+		})
+
+		It("Should reload the machine from disk", func() {
+			aa.ctx = ctx
+			aa.wg = wg
+			old := aa.machines[0].machine
+
+			myaml, err := os.ReadFile(filepath.Join(mpath, "machine.yaml"))
+			Expect(err).ToNot(HaveOccurred())
+			myaml = []byte(strings.Replace(string(myaml), "version: 1.0.0", "version: 2.0.0", 1))
+			Expect(os.WriteFile(filepath.Join(mpath, "machine.yaml"), myaml, 0600)).To(Succeed())
+
+			Expect(aa.Reload("ginkgo", "1.0.0", "", "")).To(Succeed())
+			Expect(aa.machines).To(HaveLen(1))
+
+			m := aa.machines[0].machine
+			Expect(m).ToNot(BeIdenticalTo(old))
+			Expect(m.Version()).To(Equal("2.0.0"))
+			Expect(old.IsStarted()).To(BeFalse())
+		})
+	})
+})
@@ -44,6 +44,7 @@ func (s *MachineData) Run(_ context.Context, q data.Query, si agents.ServerInfoS
 				"start_time":            m.StartTimeUTC,
 				"available_transitions": m.AvailableTransitions,
 				"scout":                 m.Scout,
+				"paused":                m.Paused,
 			}
 
 			if m.Scout {
@@ -128,6 +129,12 @@ func (s *MachineData) DLL() (*ddl.DDL, error) {
 				Type:        common.OutputTypeBoolean,
 			},
 
+			"paused": {
+				Description: "Indicates if this machine is paused",
+				DisplayAs:   "Paused",
+				Type:        common.OutputTypeBoolean,
+			},
+
 			"current_state": {
 				Description: "For Scout checks, this is the extended Scout state",
 				DisplayAs:   "Scout State",
@@ -18,7 +18,9 @@ type MachineState struct {
 	StartTimeUTC         int64    `json:"start_time" yaml:"start_time"`
 	AvailableTransitions []string `json:"available_transitions" yaml:"available_transitions"`
 	Scout                bool     `json:"scout" yaml:"scout"`
+	Paused               bool     `json:"paused" yaml:"paused"`
 	ScoutState           any      `json:"current_state,omitempty" yaml:"current_state,omitempty"`
+	ScoutHistory         any      `json:"-" yaml:"-"`
 }
 
 // AllMachineStates retrieves a list of machines and their states
@@ -30,14 +32,16 @@ func (a *AAgent) AllMachineStates() (states []MachineState, err error) {
 
 	for _, m := range a.machines {
 		var (
-			cstate any
-			scout  = false
+			cstate   any
+			chistory any
+			scout    = false
 		)
 
 		for _, w := range m.machine.WatcherDefs {
 			if w.Type == "nagios" {
 				scout = true
 				cstate, _ = m.machine.WatcherState(w.Name)
+				chistory, _ = m.machine.WatcherHistory(w.Name)
 			}
 		}
 
@@ -50,7 +54,9 @@ func (a *AAgent) AllMachineStates() (states []MachineState, err error) {
 			StartTimeUTC:         m.machine.StartTime().Unix(),
 			AvailableTransitions: m.machine.AvailableTransitions(),
 			Scout:                scout,
+			Paused:               m.machine.IsPaused(),
 			ScoutState:           cstate,
+			ScoutHistory:         chistory,
 		}
 
 		states = append(states, state)
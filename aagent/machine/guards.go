@@ -0,0 +1,332 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"github.com/looplab/fsm"
+
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+// StateTimeout fires a transition when the machine stays in a state for too long
+type StateTimeout struct {
+	// State is the state this timeout applies to
+	State string `json:"state" yaml:"state"`
+
+	// Timeout is how long the machine can stay in State before Transition fires
+	Timeout string `json:"timeout" yaml:"timeout"`
+
+	// Transition is the event to fire once the timeout is reached
+	Transition string `json:"transition" yaml:"transition"`
+
+	// Description is a human friendly description of the purpose of this timeout
+	Description string `json:"description" yaml:"description"`
+
+	duration time.Duration
+}
+
+// Duration is the parsed timeout, only valid after the machine was validated
+func (t *StateTimeout) Duration() time.Duration {
+	return t.duration
+}
+
+func (m *Machine) validateGuards() error {
+	m.guards = make(map[*Transition]*vm.Program)
+
+	for _, t := range m.Transitions {
+		if t.Guard == "" {
+			continue
+		}
+
+		prog, err := expr.Compile(t.Guard, expr.Env(guardEnv(nil, nil, "", "")), expr.AsBool(), expr.AllowUndefinedVariables())
+		if err != nil {
+			return fmt.Errorf("invalid guard for transition %s: %s", t.Name, err)
+		}
+
+		m.guards[t] = prog
+	}
+
+	return nil
+}
+
+func (m *Machine) validateStateTimeouts() error {
+	seen := map[string]bool{}
+
+	for _, st := range m.StateTimeouts {
+		var err error
+
+		if st.State == "" {
+			return fmt.Errorf("state timeouts require a state")
+		}
+
+		if seen[st.State] {
+			return fmt.Errorf("multiple timeouts defined for state %s", st.State)
+		}
+		seen[st.State] = true
+
+		st.duration, err = util.ParseDuration(st.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout for state %s: %s", st.State, err)
+		}
+
+		if st.duration <= 0 {
+			return fmt.Errorf("timeout for state %s must be positive", st.State)
+		}
+
+		valid := false
+		for _, t := range m.Transitions {
+			if t.Name != st.Transition {
+				continue
+			}
+
+			for _, from := range t.From {
+				if from == st.State {
+					valid = true
+				}
+			}
+		}
+
+		if !valid {
+			return fmt.Errorf("timeout transition %s is not valid from state %s", st.Transition, st.State)
+		}
+	}
+
+	return nil
+}
+
+func guardEnv(data map[string]any, facts map[string]any, identity string, state string) map[string]any {
+	return map[string]any{
+		"data":     data,
+		"facts":    facts,
+		"identity": identity,
+		"state":    state,
+	}
+}
+
+// checkGuard is called by the fsm before any event, cancelling events when their guard does not pass
+//
+// lock should be held by caller
+func (m *Machine) checkGuard(e *fsm.Event) {
+	var transition *Transition
+	for _, t := range m.Transitions {
+		if t.Name != e.Event {
+			continue
+		}
+
+		for _, from := range t.From {
+			if from == e.Src {
+				transition = t
+			}
+		}
+	}
+
+	if transition == nil {
+		return
+	}
+
+	prog, ok := m.guards[transition]
+	if !ok {
+		return
+	}
+
+	facts := map[string]any{}
+	if m.facts != nil {
+		err := json.Unmarshal(m.facts(), &facts)
+		if err != nil {
+			m.Warnf("machine", "Could not parse facts for guard on %s: %s", e.Event, err)
+		}
+	}
+
+	res, err := expr.Run(prog, guardEnv(m.Data(), facts, m.Identity(), e.Src))
+	if err != nil {
+		e.Cancel(fmt.Errorf("guard evaluation failed: %s", err))
+		return
+	}
+
+	pass, ok := res.(bool)
+	if !ok {
+		e.Cancel(fmt.Errorf("guard returned non boolean"))
+		return
+	}
+
+	if !pass {
+		e.Cancel(fmt.Errorf("guard %q did not pass", transition.Guard))
+	}
+}
+
+func (m *Machine) stateTimeout(state string) *StateTimeout {
+	for _, st := range m.StateTimeouts {
+		if st.State == state {
+			return st
+		}
+	}
+
+	return nil
+}
+
+// resetStateTimeout stops any running state timeout and starts a new one if state has a timeout,
+// elapsed is how long the machine has already been in the state
+//
+// lock should be held by caller
+func (m *Machine) resetStateTimeout(state string, elapsed time.Duration) {
+	if m.stateTimer != nil {
+		m.stateTimer.Stop()
+		m.stateTimer = nil
+	}
+
+	// timeouts only apply to running machines
+	if m.ctx == nil || m.ctx.Err() != nil {
+		return
+	}
+
+	st := m.stateTimeout(state)
+	if st == nil {
+		return
+	}
+
+	remaining := st.duration - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	m.stateTimer = time.AfterFunc(remaining, func() {
+		if m.State() != st.State {
+			return
+		}
+
+		m.Infof("machine", "Firing %s after being in state %s for %v", st.Transition, st.State, st.duration)
+
+		err := m.Transition(st.Transition)
+		if err != nil {
+			m.Errorf("machine", "Could not fire timeout transition %s: %s", st.Transition, err)
+		}
+	})
+}
+
+// lock should be held by caller
+func (m *Machine) stopStateTimeout() {
+	if m.stateTimer != nil {
+		m.stateTimer.Stop()
+		m.stateTimer = nil
+	}
+}
+
+// startStateTimeout starts the timeout for the current state when the machine starts, a machine
+// resumed from persisted state only waits for the remainder of the timeout while one restored as
+// paused starts its timeout when resumed
+func (m *Machine) startStateTimeout() {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.paused {
+		return
+	}
+
+	current := m.fsm.Current()
+	elapsed := time.Duration(0)
+
+	if m.resumed && len(m.history) > 0 {
+		last := m.history[len(m.history)-1]
+		if last.ToState == current {
+			elapsed = time.Since(last.Timestamp)
+		}
+	}
+
+	m.resetStateTimeout(current, elapsed)
+}
+
+// Graph produce a dot graph of the fsm, transitions with guards and state timeouts are annotated
+func (m *Machine) Graph() string {
+	m.Lock()
+	defer m.Unlock()
+
+	type edge struct {
+		src   string
+		dst   string
+		label string
+		style string
+	}
+
+	var edges []edge
+	states := map[string]bool{}
+
+	for _, t := range m.Transitions {
+		label := t.Name
+		if t.Guard != "" {
+			label = fmt.Sprintf("%s\\n[%s]", t.Name, t.Guard)
+		}
+
+		for _, from := range t.From {
+			edges = append(edges, edge{src: from, dst: t.Destination, label: label})
+			states[from] = true
+			states[t.Destination] = true
+		}
+	}
+
+	for _, st := range m.StateTimeouts {
+		for _, t := range m.Transitions {
+			if t.Name != st.Transition {
+				continue
+			}
+
+			for _, from := range t.From {
+				if from == st.State {
+					edges = append(edges, edge{src: st.State, dst: t.Destination, label: fmt.Sprintf("%s\\n(after %s)", t.Name, st.Timeout), style: "dashed"})
+				}
+			}
+		}
+	}
+
+	// the current state is at the top like fsm.Visualize does
+	current := m.fsm.Current()
+	sort.SliceStable(edges, func(i, j int) bool {
+		if (edges[i].src == current) != (edges[j].src == current) {
+			return edges[i].src == current
+		}
+		if edges[i].src != edges[j].src {
+			return edges[i].src < edges[j].src
+		}
+
+		return edges[i].label < edges[j].label
+	})
+
+	var buf bytes.Buffer
+	buf.WriteString("digraph fsm {\n")
+	for _, e := range edges {
+		style := ""
+		if e.style != "" {
+			style = fmt.Sprintf(", style = %s", e.style)
+		}
+
+		fmt.Fprintf(&buf, "    %q -> %q [ label = \"%s\"%s ];\n", e.src, e.dst, graphEscape(e.label), style)
+	}
+	buf.WriteString("\n")
+
+	var names []string
+	for s := range states {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	for _, s := range names {
+		fmt.Fprintf(&buf, "    %q;\n", s)
+	}
+	buf.WriteString("}\n")
+
+	return buf.String()
+}
+
+func graphEscape(s string) string {
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
@@ -21,6 +21,11 @@ func (m *Machine) WatcherState(watcher string) (any, bool) {
 	return m.manager.WatcherState(watcher)
 }
 
+// WatcherHistory is the history kept by a given watcher, boolean result is false for unknown watchers or those without history
+func (m *Machine) WatcherHistory(watcher string) (any, bool) {
+	return m.manager.WatcherHistory(watcher)
+}
+
 // InstanceID is a unique id for the instance of a machine
 func (m *Machine) InstanceID() string {
 	return m.instanceID
@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/antonmedv/expr/vm"
 	"github.com/choria-io/go-choria/aagent/model"
 	"github.com/choria-io/go-choria/backoff"
 	"github.com/choria-io/go-choria/inter"
@@ -55,6 +56,15 @@ type Machine struct {
 	// SplayStart causes a random sleep of maximum this many seconds before the machine starts
 	SplayStart int `json:"splay_start" yaml:"splay_start"`
 
+	// StateTimeouts fire transitions when the machine stays in a state for too long
+	StateTimeouts []*StateTimeout `json:"state_timeouts" yaml:"state_timeouts"`
+
+	// PersistState saves the current state, transition history and counters and resumes from them when the machine loads
+	PersistState bool `json:"persist_state" yaml:"persist_state"`
+
+	// PersistMaxAge is the maximum age of a persisted state that will be resumed, unlimited when not set
+	PersistMaxAge string `json:"persist_max_age" yaml:"persist_max_age"`
+
 	// ActivationCheck when set this can be called to avoid activating a plugin
 	// typically this would be used when compiling machines into the binary
 	ActivationCheck ActivationChecker `json:"-" yaml:"-"`
@@ -69,6 +79,7 @@ type Machine struct {
 	mainCollective   string
 	choriaStatusFreq int
 	startTime        time.Time
+	persistMaxAge    time.Duration
 
 	embedded    bool
 	data        map[string]any
@@ -80,6 +91,15 @@ type Machine struct {
 	notifiers   []NotificationService
 	knownStates map[string]bool
 
+	history          []*TransitionRecord
+	transitionCounts map[string]int
+	watcherStates    map[string]*PersistedWatcherState
+	watcherMu        sync.Mutex
+	resumed          bool
+	paused           bool
+	guards           map[*Transition]*vm.Program
+	stateTimer       *time.Timer
+
 	// we use a 5 second backoff to limit fast transitions
 	// this when this timer fires it will reset the try counter
 	// to 0, but we reset this timer on every transition meaning
@@ -91,6 +111,7 @@ type Machine struct {
 	// once there have not been a storm of transitions for a while
 	backoffTimer      *time.Timer
 	transitionCounter int
+	backoffDisabled   bool
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -111,6 +132,9 @@ type Transition struct {
 
 	// Description is a human friendly description of the purpose of this transition
 	Description string `json:"description" yaml:"description"`
+
+	// Guard is an optional expression that must be true for the transition to be taken
+	Guard string `json:"guard" yaml:"guard"`
 }
 
 // WatcherManager manages watchers
@@ -119,6 +143,7 @@ type WatcherManager interface {
 	NotifyStateChance()
 	SetMachine(any) error
 	WatcherState(watcher string) (any, bool)
+	WatcherHistory(watcher string) (any, bool)
 	Delete()
 }
 
@@ -181,12 +206,7 @@ func initializeMachine(m *Machine, dir string, afile string, manager WatcherMana
 		return fmt.Errorf("could not register with manager: %s", err)
 	}
 
-	err = m.Setup()
-	if err != nil {
-		return err
-	}
-
-	return nil
+	return m.Setup()
 }
 
 // FromYAML loads a machine from a YAML definition
@@ -423,11 +443,6 @@ func (m *Machine) Watchers() []*watchers.WatcherDef {
 	return m.WatcherDefs
 }
 
-// Graph produce a dot graph of the fsm
-func (m *Machine) Graph() string {
-	return fsm.Visualize(m.fsm)
-}
-
 func (m *Machine) backoffFunc() {
 	m.Lock()
 	defer m.Unlock()
@@ -457,7 +472,13 @@ func (m *Machine) buildFSM() error {
 	}
 
 	f := fsm.NewFSM(m.InitialState, events, fsm.Callbacks{
+		"before_event": func(e *fsm.Event) {
+			m.checkGuard(e)
+		},
 		"enter_state": func(e *fsm.Event) {
+			m.recordTransition(e.Event, e.Src, e.Dst)
+			m.resetStateTimeout(e.Dst, 0)
+
 			for i, notifier := range m.notifiers {
 				if i == 0 {
 					m.manager.NotifyStateChance()
@@ -509,6 +530,23 @@ func (m *Machine) Validate() error {
 		return fmt.Errorf("no watchers defined")
 	}
 
+	err := m.validateGuards()
+	if err != nil {
+		return err
+	}
+
+	err = m.validateStateTimeouts()
+	if err != nil {
+		return err
+	}
+
+	if m.PersistMaxAge != "" {
+		m.persistMaxAge, err = util.ParseDuration(m.PersistMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid persist_max_age: %s", err)
+		}
+	}
+
 	for _, w := range m.Watchers() {
 		err := w.ParseAnnounceInterval()
 		if err != nil {
@@ -543,6 +581,15 @@ func (m *Machine) Setup() error {
 func (m *Machine) Start(ctx context.Context, wg *sync.WaitGroup) (started chan struct{}) {
 	m.ctx, m.cancel = context.WithCancel(ctx)
 
+	// restored here rather than on load so that notifiers registered after loading receive the logs
+	if m.PersistState && m.directory != "" {
+		err := m.restoreState()
+		if err != nil {
+			// like with data we do not want a bad state file to prevent the machine from starting
+			m.Warnf("machine", "Could not resume from persisted state, starting in %s: %s", m.InitialState, err)
+		}
+	}
+
 	started = make(chan struct{})
 
 	runf := func() {
@@ -569,6 +616,7 @@ func (m *Machine) Start(ctx context.Context, wg *sync.WaitGroup) (started chan s
 			m.Errorf(m.MachineName, "Could not start manager: %s", err)
 		} else {
 			m.startTime = time.Now().UTC()
+			m.startStateTimeout()
 		}
 
 		started <- struct{}{}
@@ -599,7 +647,17 @@ func (m *Machine) Delete() {
 		m.backoffTimer.Stop()
 	}
 
+	m.stopStateTimeout()
+
 	if m.cancel != nil {
+		// only machines that were started restored their state, saving others would discard it
+		if m.PersistState && m.directory != "" {
+			err := m.saveState(m.fsm.Current())
+			if err != nil {
+				m.Errorf("machine", "Could not save state to %s: %s", stateFileName, err)
+			}
+		}
+
 		m.Infof("runner", "Stopping")
 		m.cancel()
 	}
@@ -616,7 +674,17 @@ func (m *Machine) Stop() {
 		m.backoffTimer.Stop()
 	}
 
+	m.stopStateTimeout()
+
 	if m.cancel != nil {
+		// only machines that were started restored their state, saving others would discard it
+		if m.PersistState && m.directory != "" {
+			err := m.saveState(m.fsm.Current())
+			if err != nil {
+				m.Errorf("machine", "Could not save state to %s: %s", stateFileName, err)
+			}
+		}
+
 		m.Infof("runner", "Stopping")
 		m.cancel()
 	}
@@ -624,7 +692,19 @@ func (m *Machine) Stop() {
 	m.startTime = time.Time{}
 }
 
+// DisableTransitionBackoff disables the rate limiting of fast transitions, typically only used when testing machines
+func (m *Machine) DisableTransitionBackoff() {
+	m.Lock()
+	defer m.Unlock()
+
+	m.backoffDisabled = true
+}
+
 func (m *Machine) backoffTransition(t string) error {
+	if m.backoffDisabled {
+		return nil
+	}
+
 	if m.backoffTimer == nil {
 		m.backoffTimer = time.AfterFunc(time.Minute, m.backoffFunc)
 	}
@@ -653,13 +733,21 @@ func (m *Machine) Transition(t string, args ...any) error {
 		return nil
 	}
 
+	if m.paused {
+		m.Infof("machine", "Ignoring '%s' event while paused", t)
+		return nil
+	}
+
 	if m.Can(t) {
 		err := m.backoffTransition(t)
 		if err != nil {
 			return err
 		}
 
-		m.fsm.Event(t, args...)
+		err = m.fsm.Event(t, args...)
+		if cerr, ok := err.(fsm.CanceledError); ok {
+			m.Infof("machine", "Could not fire '%s' event while in %s: %v", t, m.fsm.Current(), cerr.Err)
+		}
 	} else {
 		m.Warnf("machine", "Could not fire '%s' event while in %s", t, m.fsm.Current())
 	}
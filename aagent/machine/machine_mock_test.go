@@ -81,6 +81,19 @@ func (mr *MockWatcherManagerMockRecorder) WatcherState(watcher any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatcherState", reflect.TypeOf((*MockWatcherManager)(nil).WatcherState), watcher)
 }
 
+// WatcherHistory mocks base method
+func (m *MockWatcherManager) WatcherHistory(watcher string) (any, bool) {
+	ret := m.ctrl.Call(m, "WatcherHistory", watcher)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// WatcherHistory indicates an expected call of WatcherHistory
+func (mr *MockWatcherManagerMockRecorder) WatcherHistory(watcher any) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatcherHistory", reflect.TypeOf((*MockWatcherManager)(nil).WatcherHistory), watcher)
+}
+
 // Delete mocks base method
 func (m *MockWatcherManager) Delete() {
 	m.ctrl.Call(m, "Delete")
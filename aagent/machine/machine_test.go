@@ -6,14 +6,18 @@ package machine
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/choria-io/go-choria/aagent/plugin"
 	"github.com/choria-io/go-choria/aagent/watchers"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/ghodss/yaml"
 	"github.com/sirupsen/logrus"
 
@@ -22,6 +26,34 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type testWatcherState struct {
+	Result string `json:"result"`
+}
+
+func (s *testWatcherState) JSON() ([]byte, error)         { return json.Marshal(s) }
+func (s *testWatcherState) CloudEvent() cloudevents.Event { return cloudevents.NewEvent("1.0") }
+func (s *testWatcherState) String() string                { return s.Result }
+func (s *testWatcherState) WatcherType() string           { return "test" }
+func (s *testWatcherState) SenderID() string              { return "ginkgo" }
+
+type logRecorder struct {
+	messages []string
+	mu       sync.Mutex
+}
+
+func (l *logRecorder) NotifyPostTransition(_ *TransitionNotification) error { return nil }
+func (l *logRecorder) NotifyWatcherState(_ string, _ WatcherStateNotification) error {
+	return nil
+}
+func (l *logRecorder) Debugf(_ InfoSource, _ string, format string, args ...any) {}
+func (l *logRecorder) Infof(_ InfoSource, _ string, format string, args ...any) {
+	l.mu.Lock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+	l.mu.Unlock()
+}
+func (l *logRecorder) Warnf(_ InfoSource, _ string, format string, args ...any)  {}
+func (l *logRecorder) Errorf(_ InfoSource, _ string, format string, args ...any) {}
+
 func TestMachine(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Aagent/Machine")
@@ -203,6 +235,276 @@ var _ = Describe("Aagent/Machine", func() {
 		})
 	})
 
+	Describe("Persistence", func() {
+		var td string
+
+		BeforeEach(func() {
+			td, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			myaml, err := os.ReadFile("testdata/machine.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			myaml = append(myaml, []byte("\npersist_state: true\npersist_max_age: 1h\n")...)
+			Expect(os.WriteFile(filepath.Join(td, "machine.yaml"), myaml, 0600)).ToNot(HaveOccurred())
+
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{})).AnyTimes()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(td)
+		})
+
+		It("Should persist and resume the state", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.PersistState).To(BeTrue())
+			Expect(machine.persistMaxAge).To(Equal(time.Hour))
+			machine.DisableTransitionBackoff()
+
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(machine.Transition("fire_2")).ToNot(HaveOccurred())
+			Expect(filepath.Join(td, stateFileName)).To(BeARegularFile())
+
+			resumed, err := FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed.IsResumed()).To(BeFalse())
+			Expect(resumed.State()).To(Equal("unknown"))
+
+			Expect(resumed.restoreState()).To(Succeed())
+			Expect(resumed.IsResumed()).To(BeTrue())
+			Expect(resumed.State()).To(Equal("two"))
+			Expect(resumed.TransitionCounts()).To(Equal(map[string]int{"fire_1": 1, "fire_2": 1}))
+
+			history := resumed.TransitionHistory()
+			Expect(history).To(HaveLen(2))
+			Expect(history[1].Transition).To(Equal("fire_2"))
+			Expect(history[1].FromState).To(Equal("one"))
+			Expect(history[1].ToState).To(Equal("two"))
+		})
+
+		It("Should not resume stale states", func() {
+			state := PersistedState{State: "two", Timestamp: time.Now().Add(-2 * time.Hour)}
+			j, err := json.Marshal(state)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(td, stateFileName), j, 0600)).ToNot(HaveOccurred())
+
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.restoreState()).To(Succeed())
+			Expect(machine.IsResumed()).To(BeFalse())
+			Expect(machine.State()).To(Equal("unknown"))
+		})
+
+		It("Should not resume unknown states", func() {
+			state := PersistedState{State: "other", Timestamp: time.Now()}
+			j, err := json.Marshal(state)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.WriteFile(filepath.Join(td, stateFileName), j, 0600)).ToNot(HaveOccurred())
+
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.restoreState()).To(MatchError("persisted state other is not a known state"))
+			Expect(machine.IsResumed()).To(BeFalse())
+			Expect(machine.State()).To(Equal("unknown"))
+		})
+
+		It("Should persist watcher states", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+
+			machine.NotifyWatcherState("check", &testWatcherState{Result: "ok"})
+			machine.NotifyWatcherState("check", &testWatcherState{Result: "error"})
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+
+			resumed, err := FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed.restoreState()).To(Succeed())
+
+			states := resumed.WatcherStates()
+			Expect(states).To(HaveKey("check"))
+			Expect(states["check"].Notifications).To(Equal(2))
+			Expect(states["check"].State).To(MatchJSON(`{"result":"error"}`))
+
+			resumed.NotifyWatcherState("check", &testWatcherState{Result: "ok"})
+			Expect(resumed.WatcherStates()["check"].Notifications).To(Equal(3))
+		})
+
+		It("Should resume when started with notifiers registered", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+
+			resumed, err := FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+
+			notifier := &logRecorder{}
+			resumed.RegisterNotifier(notifier)
+			resumed.SplayStart = 0
+
+			started := make(chan struct{})
+			manager.EXPECT().Run(gomock.Any(), gomock.Any()).Do(func(_ context.Context, _ *sync.WaitGroup) {
+				Expect(resumed.State()).To(Equal("one"))
+				close(started)
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			resumed.Start(ctx, &sync.WaitGroup{})
+			Eventually(started).Should(BeClosed())
+			Expect(resumed.IsResumed()).To(BeTrue())
+			Expect(notifier.messages).To(ContainElement(HavePrefix("Resuming in state one persisted")))
+		})
+
+		It("Should not persist when disabled", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.PersistState = false
+
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(filepath.Join(td, stateFileName)).ToNot(BeAnExistingFile())
+			Expect(machine.TransitionCounts()).To(Equal(map[string]int{"fire_1": 1}))
+		})
+	})
+
+	Describe("Guards and Timeouts", func() {
+		var td string
+
+		BeforeEach(func() {
+			td, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			myaml, err := os.ReadFile("testdata/machine.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			myaml = []byte(strings.Replace(string(myaml), "destination: two", "destination: two\n    guard: data.allowed == true", 1))
+			myaml = append(myaml, []byte("\nstate_timeouts:\n  - state: one\n    timeout: 100ms\n    transition: fire_2\n")...)
+			Expect(os.WriteFile(filepath.Join(td, "machine.yaml"), myaml, 0600)).ToNot(HaveOccurred())
+
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{})).AnyTimes()
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(td)
+		})
+
+		It("Should validate guards and timeouts", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(machine.StateTimeouts[0].Duration()).To(Equal(100 * time.Millisecond))
+
+			machine.Transitions[1].Guard = "data.allowed =="
+			Expect(machine.Validate()).To(MatchError(ContainSubstring("invalid guard for transition fire_2")))
+			machine.Transitions[1].Guard = ""
+
+			machine.StateTimeouts[0].Transition = "fire_1"
+			Expect(machine.Validate()).To(MatchError("timeout transition fire_1 is not valid from state one"))
+
+			machine.StateTimeouts[0].Transition = "fire_2"
+			machine.StateTimeouts[0].Timeout = "0s"
+			Expect(machine.Validate()).To(MatchError("timeout for state one must be positive"))
+
+			machine.StateTimeouts[0].Timeout = "1m"
+			machine.StateTimeouts = append(machine.StateTimeouts, machine.StateTimeouts[0])
+			Expect(machine.Validate()).To(MatchError("multiple timeouts defined for state one"))
+		})
+
+		It("Should only transition when the guard passes", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(machine.Transition("fire_2")).ToNot(HaveOccurred())
+			Expect(machine.State()).To(Equal("one"))
+
+			Expect(machine.DataPut("allowed", true)).ToNot(HaveOccurred())
+			Expect(machine.Transition("fire_2")).ToNot(HaveOccurred())
+			Expect(machine.State()).To(Equal("two"))
+		})
+
+		It("Should fire the timeout transition", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+			machine.ctx, machine.cancel = context.WithCancel(context.Background())
+			defer machine.Stop()
+
+			Expect(machine.DataPut("allowed", true)).ToNot(HaveOccurred())
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(machine.State()).To(Equal("one"))
+			Eventually(machine.State, time.Second).Should(Equal("two"))
+		})
+
+		It("Should not start the timeout for paused machines", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+
+			Expect(machine.DataPut("allowed", true)).ToNot(HaveOccurred())
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+
+			machine.ctx, machine.cancel = context.WithCancel(context.Background())
+			defer machine.Stop()
+
+			machine.paused = true
+			machine.startStateTimeout()
+			Expect(machine.stateTimer).To(BeNil())
+			Consistently(machine.State, 300*time.Millisecond).Should(Equal("one"))
+		})
+
+		It("Should annotate the graph", func() {
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+
+			graph := machine.Graph()
+			Expect(graph).To(ContainSubstring(`"unknown" -> "one" [ label = "fire_1" ];`))
+			Expect(graph).To(ContainSubstring(`"one" -> "two" [ label = "fire_2\n[data.allowed == true]" ];`))
+			Expect(graph).To(ContainSubstring(`"one" -> "two" [ label = "fire_2\n(after 100ms)", style = dashed ];`))
+		})
+	})
+
+	Describe("Pause", func() {
+		It("Should ignore transitions while paused", func() {
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{}))
+			machine, err = FromYAML("testdata/machine.yaml", manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.DisableTransitionBackoff()
+
+			machine.Pause()
+			Expect(machine.IsPaused()).To(BeTrue())
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(machine.State()).To(Equal("unknown"))
+
+			manager.EXPECT().NotifyStateChance()
+			machine.Resume()
+			Expect(machine.IsPaused()).To(BeFalse())
+			Expect(machine.Transition("fire_1")).ToNot(HaveOccurred())
+			Expect(machine.State()).To(Equal("one"))
+		})
+
+		It("Should persist the paused state", func() {
+			td, err := os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(td)
+
+			myaml, err := os.ReadFile("testdata/machine.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			myaml = append(myaml, []byte("\npersist_state: true\n")...)
+			Expect(os.WriteFile(filepath.Join(td, "machine.yaml"), myaml, 0600)).ToNot(HaveOccurred())
+
+			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{})).Times(2)
+			machine, err = FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			machine.Pause()
+
+			resumed, err := FromDir(td, manager)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resumed.restoreState()).To(Succeed())
+			Expect(resumed.IsPaused()).To(BeTrue())
+		})
+	})
+
 	Describe("Transition", func() {
 		It("Should initiate the event", func() {
 			manager.EXPECT().SetMachine(gomock.AssignableToTypeOf(&Machine{}))
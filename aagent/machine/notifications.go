@@ -132,6 +132,10 @@ func (m *Machine) NotifyWatcherState(watcher string, state any) {
 		return
 	}
 
+	if m.PersistState {
+		m.recordWatcherState(watcher, notification)
+	}
+
 	for _, n := range m.notifiers {
 		err := n.NotifyWatcherState(watcher, notification)
 		if err != nil {
@@ -0,0 +1,62 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+// Pause pauses the machine, while paused watchers do not perform their checks and transitions are ignored
+func (m *Machine) Pause() {
+	m.Lock()
+	defer m.Unlock()
+
+	if m.paused {
+		return
+	}
+
+	m.paused = true
+	m.stopStateTimeout()
+
+	m.Infof("machine", "Pausing machine in state %s", m.fsm.Current())
+
+	m.persistPauseState()
+}
+
+// Resume resumes a paused machine, watchers are notified so they can evaluate the current state
+func (m *Machine) Resume() {
+	m.Lock()
+
+	if !m.paused {
+		m.Unlock()
+		return
+	}
+
+	m.paused = false
+	m.resetStateTimeout(m.fsm.Current(), 0)
+
+	m.Infof("machine", "Resuming machine in state %s", m.fsm.Current())
+
+	m.persistPauseState()
+	m.Unlock()
+
+	m.manager.NotifyStateChance()
+}
+
+// IsPaused determines if the machine is paused
+func (m *Machine) IsPaused() bool {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.paused
+}
+
+// lock should be held by caller
+func (m *Machine) persistPauseState() {
+	if !m.PersistState || m.directory == "" {
+		return
+	}
+
+	err := m.saveState(m.fsm.Current())
+	if err != nil {
+		m.Errorf("machine", "Could not save state to %s: %s", stateFileName, err)
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+const (
+	stateFileName = "machine_state.json"
+
+	// maxStateHistory is the number of transitions kept in the persisted history
+	maxStateHistory = 50
+)
+
+// PersistedState is the state of a machine saved to disk when PersistState is enabled
+type PersistedState struct {
+	// Machine is the name of the machine this state belongs to
+	Machine string `json:"machine"`
+
+	// Version is the version of the machine that saved the state
+	Version string `json:"version"`
+
+	// State is the state the machine was in
+	State string `json:"state"`
+
+	// Timestamp is when the state was saved
+	Timestamp time.Time `json:"timestamp"`
+
+	// History is the most recent transitions, oldest first
+	History []*TransitionRecord `json:"history"`
+
+	// TransitionCounts is how many times each transition event fired
+	TransitionCounts map[string]int `json:"transition_counts"`
+
+	// Paused indicates the machine was paused
+	Paused bool `json:"paused,omitempty"`
+
+	// Watchers are the counters and most recent state of each watcher
+	Watchers map[string]*PersistedWatcherState `json:"watchers,omitempty"`
+}
+
+// PersistedWatcherState is the saved state of a single watcher
+type PersistedWatcherState struct {
+	// Notifications is how many times the watcher reported its state
+	Notifications int `json:"notifications"`
+
+	// State is the most recent state reported by the watcher
+	State json.RawMessage `json:"state,omitempty"`
+
+	// Timestamp is when the watcher last reported its state
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TransitionRecord records a single transition in the machine history
+type TransitionRecord struct {
+	Transition string    `json:"transition"`
+	FromState  string    `json:"from_state"`
+	ToState    string    `json:"to_state"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// TransitionHistory retrieves the recent transitions of the machine, oldest first
+func (m *Machine) TransitionHistory() []TransitionRecord {
+	m.Lock()
+	defer m.Unlock()
+
+	res := make([]TransitionRecord, len(m.history))
+	for i, r := range m.history {
+		res[i] = *r
+	}
+
+	return res
+}
+
+// TransitionCounts retrieves how many times each transition event fired
+func (m *Machine) TransitionCounts() map[string]int {
+	m.Lock()
+	defer m.Unlock()
+
+	res := make(map[string]int, len(m.transitionCounts))
+	for k, v := range m.transitionCounts {
+		res[k] = v
+	}
+
+	return res
+}
+
+// WatcherStates retrieves the counters and most recent state of each watcher
+func (m *Machine) WatcherStates() map[string]PersistedWatcherState {
+	m.watcherMu.Lock()
+	defer m.watcherMu.Unlock()
+
+	res := make(map[string]PersistedWatcherState, len(m.watcherStates))
+	for k, v := range m.watcherStates {
+		res[k] = *v
+	}
+
+	return res
+}
+
+func (m *Machine) recordWatcherState(watcher string, state WatcherStateNotification) {
+	j, err := state.JSON()
+	if err != nil {
+		j = nil
+	}
+
+	m.watcherMu.Lock()
+	defer m.watcherMu.Unlock()
+
+	if m.watcherStates == nil {
+		m.watcherStates = make(map[string]*PersistedWatcherState)
+	}
+
+	ws, ok := m.watcherStates[watcher]
+	if !ok {
+		ws = &PersistedWatcherState{}
+		m.watcherStates[watcher] = ws
+	}
+
+	ws.Notifications++
+	ws.State = j
+	ws.Timestamp = time.Now().UTC()
+}
+
+// lock should be held by caller
+func (m *Machine) recordTransition(event string, from string, to string) {
+	if m.transitionCounts == nil {
+		m.transitionCounts = make(map[string]int)
+	}
+
+	m.transitionCounts[event]++
+	m.history = append(m.history, &TransitionRecord{
+		Transition: event,
+		FromState:  from,
+		ToState:    to,
+		Timestamp:  time.Now().UTC(),
+	})
+
+	if len(m.history) > maxStateHistory {
+		m.history = m.history[len(m.history)-maxStateHistory:]
+	}
+
+	if !m.PersistState || m.directory == "" {
+		return
+	}
+
+	err := m.saveState(to)
+	if err != nil {
+		m.Errorf("machine", "Could not save state to %s: %s", stateFileName, err)
+	}
+}
+
+// lock should be held by caller
+func (m *Machine) saveState(current string) error {
+	state := &PersistedState{
+		Machine:          m.MachineName,
+		Version:          m.MachineVersion,
+		State:            current,
+		Timestamp:        time.Now().UTC(),
+		History:          m.history,
+		TransitionCounts: m.transitionCounts,
+		Paused:           m.paused,
+		Watchers:         m.watcherStates,
+	}
+
+	m.watcherMu.Lock()
+	j, err := json.Marshal(state)
+	m.watcherMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(m.directory, "")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = tf.Write(j)
+	tf.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tf.Name(), filepath.Join(m.directory, stateFileName))
+}
+
+// restoreState resumes the machine from a previously persisted state, stale or invalid states are discarded
+func (m *Machine) restoreState() error {
+	path := filepath.Join(m.directory, stateFileName)
+	if !util.FileExist(path) {
+		return nil
+	}
+
+	j, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var state PersistedState
+	err = json.Unmarshal(j, &state)
+	if err != nil {
+		return err
+	}
+
+	age := time.Since(state.Timestamp)
+	if m.persistMaxAge > 0 && age > m.persistMaxAge {
+		m.Infof("machine", "Not resuming from persisted state %s saved %v ago, older than %v", state.State, age.Round(time.Second), m.persistMaxAge)
+		return nil
+	}
+
+	known := false
+	for _, s := range m.KnownStates() {
+		if s == state.State {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return fmt.Errorf("persisted state %s is not a known state", state.State)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.fsm.SetState(state.State)
+	m.history = state.History
+	m.transitionCounts = state.TransitionCounts
+	m.paused = state.Paused
+	m.resumed = true
+
+	m.watcherMu.Lock()
+	m.watcherStates = state.Watchers
+	m.watcherMu.Unlock()
+
+	m.Infof("machine", "Resuming in state %s persisted %v ago by version %s", state.State, age.Round(time.Second), state.Version)
+
+	return nil
+}
+
+// IsResumed indicates if the machine resumed from a persisted state rather than its initial state
+func (m *Machine) IsResumed() bool {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.resumed
+}
@@ -21,6 +21,7 @@ type Machine interface {
 	State() string
 	Transition(t string, args ...any) error
 	NotifyWatcherState(string, any)
+	WatcherState(watcher string) (any, bool)
 	Name() string
 	Directory() string
 	TextFileDirectory() string
@@ -38,6 +39,7 @@ type Machine interface {
 	DataPut(key string, val any) error
 	DataGet(key string) (any, bool)
 	DataDelete(key string) error
+	IsPaused() bool
 	Debugf(name string, format string, args ...any)
 	Infof(name string, format string, args ...any)
 	Warnf(name string, format string, args ...any)
@@ -17,10 +17,17 @@ type Watcher interface {
 	Run(context.Context, *sync.WaitGroup)
 	NotifyStateChance()
 	CurrentState() any
+	History() any
 	AnnounceInterval() time.Duration
 	Delete()
 }
 
+// LeaderGatedWatcher is a watcher that can be gated on a leader election, leadership is tracked by the watcher manager
+type LeaderGatedWatcher interface {
+	Watcher
+	SetLeaderElection(election string, isLeader func() bool)
+}
+
 // WatcherConstructor creates a new watcher plugin
 type WatcherConstructor interface {
 	New(machine Machine, name string, states []string, failEvent string, successEvent string, interval string, ai time.Duration, properties map[string]any) (any, error)
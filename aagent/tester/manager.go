@@ -0,0 +1,471 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tester
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/google/go-cmp/cmp"
+	"github.com/robfig/cron"
+)
+
+// maxSettleIterations limits how many transitions a single step can trigger, protecting against loops
+const maxSettleIterations = 1000
+
+// manager implements machine.WatcherManager using scripted watchers and a virtual clock
+type manager struct {
+	machine   *machine.Machine
+	watchers  []*fakeWatcher
+	byName    map[string]*fakeWatcher
+	startTime time.Time
+	now       time.Time
+	changed   bool
+
+	mu sync.Mutex
+}
+
+// fakeWatcher stands in for a real watcher, reacting only to scripted steps and the virtual clock
+type fakeWatcher struct {
+	def   *watchers.WatcherDef
+	mgr   *manager
+	state string
+
+	// timer watchers
+	timer    time.Duration
+	deadline time.Time
+
+	// schedule watchers
+	schedules     []cron.Schedule
+	duration      time.Duration
+	schedState    string
+	schedPrevious string
+
+	// kv watchers
+	kvKey             string
+	kvOnMatchedUpdate bool
+}
+
+func newManager(now time.Time) *manager {
+	return &manager{
+		byName:    make(map[string]*fakeWatcher),
+		startTime: now,
+		now:       now,
+	}
+}
+
+// Run implements machine.WatcherManager, the tester drives watchers directly so this does nothing
+func (m *manager) Run(_ context.Context, _ *sync.WaitGroup) error {
+	return nil
+}
+
+// NotifyStateChance implements machine.WatcherManager
+func (m *manager) NotifyStateChance() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.changed = true
+}
+
+// Delete implements machine.WatcherManager
+func (m *manager) Delete() {}
+
+// SetMachine implements machine.WatcherManager
+func (m *manager) SetMachine(t any) error {
+	mach, ok := t.(*machine.Machine)
+	if !ok {
+		return fmt.Errorf("supplied machine is not a *machine.Machine")
+	}
+
+	m.machine = mach
+
+	for _, def := range mach.Watchers() {
+		w, err := newFakeWatcher(def, m)
+		if err != nil {
+			return fmt.Errorf("could not create %s watcher %s: %s", def.Type, def.Name, err)
+		}
+
+		m.watchers = append(m.watchers, w)
+		m.byName[def.Name] = w
+	}
+
+	// state timeouts behave like timer watchers active only in their state, they cannot be scripted by name
+	for _, st := range mach.StateTimeouts {
+		// the machine is not validated yet when it registers with the manager
+		timeout, err := iu.ParseDuration(st.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout for state %s: %s", st.State, err)
+		}
+
+		w := &fakeWatcher{
+			def: &watchers.WatcherDef{
+				Name:           fmt.Sprintf("%s timeout", st.State),
+				Type:           "timer",
+				StateMatch:     []string{st.State},
+				FailTransition: st.Transition,
+			},
+			mgr:   m,
+			state: "unknown",
+			timer: timeout,
+		}
+
+		m.watchers = append(m.watchers, w)
+	}
+
+	return nil
+}
+
+// WatcherState implements machine.WatcherManager
+func (m *manager) WatcherState(watcher string) (any, bool) {
+	w, ok := m.byName[watcher]
+	if !ok {
+		return nil, false
+	}
+
+	return map[string]any{
+		"name":  w.def.Name,
+		"type":  w.def.Type,
+		"state": w.state,
+	}, true
+}
+
+// WatcherHistory implements machine.WatcherManager, fake watchers keep no history
+func (m *manager) WatcherHistory(_ string) (any, bool) {
+	return nil, false
+}
+
+func (m *manager) watcher(name string) (*fakeWatcher, error) {
+	w, ok := m.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown watcher %s", name)
+	}
+
+	return w, nil
+}
+
+// start notifies all watchers of the initial state as happens when a machine starts
+func (m *manager) start() error {
+	m.NotifyStateChance()
+
+	return m.settle()
+}
+
+// settle lets watchers react to state changes until the machine stops transitioning
+func (m *manager) settle() error {
+	for i := 0; i < maxSettleIterations; i++ {
+		m.mu.Lock()
+		changed := m.changed
+		m.changed = false
+		m.mu.Unlock()
+
+		if !changed {
+			return nil
+		}
+
+		for _, w := range m.watchers {
+			err := w.stateChanged()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return fmt.Errorf("machine did not settle after %d iterations, possible transition loop", maxSettleIterations)
+}
+
+// advance moves the virtual clock forward by d, firing time based watchers in order
+func (m *manager) advance(d time.Duration) error {
+	end := m.now.Add(d)
+
+	for i := 0; ; i++ {
+		if i == maxSettleIterations*100 {
+			return fmt.Errorf("too many events while advancing time by %v", d)
+		}
+
+		var next time.Time
+		for _, w := range m.watchers {
+			t, ok := w.nextEvent()
+			if ok && (next.IsZero() || t.Before(next)) {
+				next = t
+			}
+		}
+
+		if next.IsZero() || next.After(end) {
+			m.now = end
+			return nil
+		}
+
+		m.now = next
+		for _, w := range m.watchers {
+			err := w.tick()
+			if err != nil {
+				return err
+			}
+		}
+
+		err := m.settle()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newFakeWatcher(def *watchers.WatcherDef, mgr *manager) (*fakeWatcher, error) {
+	w := &fakeWatcher{
+		def:           def,
+		mgr:           mgr,
+		state:         "unknown",
+		schedState:    "unknown",
+		schedPrevious: "unknown",
+	}
+
+	switch def.Type {
+	case "timer":
+		props := struct{ Timer time.Duration }{}
+		err := util.ParseMapStructure(def.Properties, &props)
+		if err != nil {
+			return nil, err
+		}
+
+		w.timer = props.Timer
+		if w.timer < time.Second {
+			w.timer = time.Second
+		}
+
+	case "schedule":
+		props := struct {
+			Duration  time.Duration
+			Schedules []string
+		}{}
+		err := util.ParseMapStructure(def.Properties, &props)
+		if err != nil {
+			return nil, err
+		}
+
+		w.duration = props.Duration
+		if w.duration < time.Second {
+			w.duration = time.Minute
+		}
+
+		parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+		for _, spec := range props.Schedules {
+			sched, err := parser.Parse(spec)
+			if err != nil {
+				return nil, fmt.Errorf("could not parse '%s': %s", spec, err)
+			}
+			w.schedules = append(w.schedules, sched)
+		}
+
+	case "kv":
+		props := struct {
+			Bucket       string
+			Key          string
+			BucketPrefix bool `mapstructure:"bucket_prefix"`
+			OnMatch      bool `mapstructure:"on_matching_update"`
+		}{BucketPrefix: true}
+		err := util.ParseMapStructure(def.Properties, &props)
+		if err != nil {
+			return nil, err
+		}
+
+		w.kvKey = props.Key
+		if props.BucketPrefix {
+			w.kvKey = fmt.Sprintf("%s_%s", props.Bucket, props.Key)
+		}
+		w.kvOnMatchedUpdate = props.OnMatch
+	}
+
+	return w, nil
+}
+
+func (w *fakeWatcher) shouldWatch() bool {
+	if len(w.def.StateMatch) == 0 {
+		return true
+	}
+
+	current := w.mgr.machine.State()
+	for _, s := range w.def.StateMatch {
+		if s == current {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *fakeWatcher) success() error {
+	w.state = "success"
+	return w.mgr.machine.Transition(w.def.SuccessTransition)
+}
+
+func (w *fakeWatcher) fail() error {
+	w.state = "fail"
+	return w.mgr.machine.Transition(w.def.FailTransition)
+}
+
+// stateChanged mimics the real watchers handling of machine state changes
+func (w *fakeWatcher) stateChanged() error {
+	switch w.def.Type {
+	case "timer":
+		if w.shouldWatch() {
+			w.deadline = w.mgr.now.Add(w.timer)
+			w.state = "running"
+		} else {
+			w.deadline = time.Time{}
+			w.state = "stopped"
+		}
+
+	case "schedule":
+		return w.evaluateSchedule()
+	}
+
+	return nil
+}
+
+// tick is called whenever the virtual clock moves
+func (w *fakeWatcher) tick() error {
+	switch w.def.Type {
+	case "timer":
+		if w.deadline.IsZero() || w.mgr.now.Before(w.deadline) {
+			return nil
+		}
+
+		w.deadline = time.Time{}
+		w.state = "stopped"
+
+		return w.mgr.machine.Transition(w.def.FailTransition)
+
+	case "schedule":
+		return w.evaluateSchedule()
+	}
+
+	return nil
+}
+
+// nextEvent is the next time on the virtual clock that this watcher might change
+func (w *fakeWatcher) nextEvent() (time.Time, bool) {
+	now := w.mgr.now
+
+	switch w.def.Type {
+	case "timer":
+		if !w.deadline.IsZero() && w.deadline.After(now) {
+			return w.deadline, true
+		}
+
+	case "schedule":
+		var next time.Time
+		for _, sched := range w.schedules {
+			candidates := []time.Time{sched.Next(now)}
+			for _, start := range w.activeStarts(sched) {
+				candidates = append(candidates, start.Add(w.duration))
+			}
+
+			for _, c := range candidates {
+				if c.After(now) && (next.IsZero() || c.Before(next)) {
+					next = c
+				}
+			}
+		}
+
+		return next, !next.IsZero()
+	}
+
+	return time.Time{}, false
+}
+
+// activeStarts finds the start times of all windows of sched that are active now
+func (w *fakeWatcher) activeStarts(sched cron.Schedule) []time.Time {
+	var starts []time.Time
+
+	now := w.mgr.now
+	from := now.Add(-w.duration)
+	// windows that opened before the machine started are never seen by the real watcher
+	if from.Before(w.mgr.startTime) {
+		from = w.mgr.startTime
+	}
+
+	for start := sched.Next(from.Add(-time.Second)); !start.After(now); start = sched.Next(start) {
+		if now.Before(start.Add(w.duration)) {
+			starts = append(starts, start)
+		}
+	}
+
+	return starts
+}
+
+func (w *fakeWatcher) evaluateSchedule() error {
+	on := false
+	for _, sched := range w.schedules {
+		if len(w.activeStarts(sched)) > 0 {
+			on = true
+			break
+		}
+	}
+
+	// like the real watcher the state is unknown until the first window opens
+	switch {
+	case on:
+		w.schedState = "on"
+	case w.schedState != "unknown":
+		w.schedState = "off"
+	}
+
+	if !w.shouldWatch() {
+		w.schedPrevious = "skipped"
+		return nil
+	}
+
+	if w.schedState == w.schedPrevious {
+		return nil
+	}
+
+	w.schedPrevious = w.schedState
+	w.state = w.schedState
+
+	if w.schedState == "on" {
+		return w.mgr.machine.Transition(w.def.SuccessTransition)
+	}
+
+	return w.mgr.machine.Transition(w.def.FailTransition)
+}
+
+// kvUpdate simulates a kv watcher poll seeing a new value
+func (w *fakeWatcher) kvUpdate(u *KVUpdate) error {
+	prev, found := w.mgr.machine.DataGet(w.kvKey)
+
+	if u.Delete {
+		if !found {
+			w.state = "unchanged"
+			return nil
+		}
+
+		err := w.mgr.machine.DataDelete(w.kvKey)
+		if err != nil {
+			return err
+		}
+
+		w.state = "changed"
+		return w.mgr.machine.Transition(w.def.SuccessTransition)
+	}
+
+	if found && cmp.Equal(prev, u.Value) && !w.kvOnMatchedUpdate {
+		w.state = "unchanged"
+		return nil
+	}
+
+	err := w.mgr.machine.DataPut(w.kvKey, u.Value)
+	if err != nil {
+		return err
+	}
+
+	w.state = "changed"
+	return w.mgr.machine.Transition(w.def.SuccessTransition)
+}
@@ -0,0 +1,343 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+// Runner runs a test suite against a machine
+type Runner struct {
+	dir   string
+	suite *Suite
+	log   *logrus.Entry
+}
+
+// Result is the outcome of a single test
+type Result struct {
+	// Name is the name of the test
+	Name string `json:"name"`
+
+	// Transitions are the transition events that fired during the test
+	Transitions []string `json:"transitions"`
+
+	// States are the states the machine moved through, excluding its initial state
+	States []string `json:"states"`
+
+	// FinalState is the state the machine was in at the end of the test
+	FinalState string `json:"final_state"`
+
+	// Failures are descriptions of unmet expectations, empty when the test passed
+	Failures []string `json:"failures,omitempty"`
+}
+
+// Passed indicates if the test passed all expectations
+func (r *Result) Passed() bool {
+	return len(r.Failures) == 0
+}
+
+func (r *Result) failf(format string, a ...any) {
+	r.Failures = append(r.Failures, fmt.Sprintf(format, a...))
+}
+
+// recorder is a machine.NotificationService that records transitions
+type recorder struct {
+	log         *logrus.Entry
+	transitions []string
+	states      []string
+	mu          sync.Mutex
+}
+
+// New creates a runner for the machine found in dir
+func New(dir string, suite *Suite, log *logrus.Entry) (*Runner, error) {
+	if !iu.FileExist(filepath.Join(dir, "machine.yaml")) {
+		return nil, fmt.Errorf("cannot find machine.yaml in %s", dir)
+	}
+
+	err := suite.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Runner{dir: dir, suite: suite, log: log}, nil
+}
+
+// Run runs all the tests in the suite, errors are only returned when tests could not be run
+func (r *Runner) Run() ([]*Result, error) {
+	var results []*Result
+
+	for _, test := range r.suite.Tests {
+		res, err := r.runTest(test)
+		if err != nil {
+			return results, fmt.Errorf("could not run test %q: %s", test.Name, err)
+		}
+
+		results = append(results, res)
+	}
+
+	return results, nil
+}
+
+// loadMachine loads a copy of the machine in a temporary directory so that its data is never written to the source
+func (r *Runner) loadMachine(mgr *manager) (*machine.Machine, string, error) {
+	td, err := os.MkdirTemp("", "machine-test")
+	if err != nil {
+		return nil, "", err
+	}
+
+	manifest, err := os.ReadFile(filepath.Join(r.dir, "machine.yaml"))
+	if err != nil {
+		os.RemoveAll(td)
+		return nil, "", err
+	}
+
+	err = os.WriteFile(filepath.Join(td, "machine.yaml"), manifest, 0600)
+	if err != nil {
+		os.RemoveAll(td)
+		return nil, "", err
+	}
+
+	m, err := machine.FromDir(td, mgr)
+	if err != nil {
+		os.RemoveAll(td)
+		return nil, "", err
+	}
+
+	return m, td, nil
+}
+
+func (r *Runner) runTest(test *Test) (*Result, error) {
+	start := r.suite.StartTime
+	if start.IsZero() {
+		start = time.Now()
+	}
+
+	mgr := newManager(start)
+	m, td, err := r.loadMachine(mgr)
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(td)
+
+	identity := r.suite.Identity
+	if identity == "" {
+		identity = "tester"
+	}
+
+	facts, err := json.Marshal(mergeMaps(r.suite.Facts, test.Facts))
+	if err != nil {
+		return nil, err
+	}
+
+	rec := &recorder{log: r.log.WithField("test", test.Name)}
+	m.RegisterNotifier(rec)
+	m.DisableTransitionBackoff()
+	m.SetIdentity(identity)
+	m.SetFactSource(func() json.RawMessage { return facts })
+
+	for k, v := range mergeMaps(r.suite.Data, test.Data) {
+		err = m.DataPut(k, v)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := &Result{Name: test.Name}
+
+	err = mgr.start()
+	if err != nil {
+		res.failf("machine start failed: %s", err)
+	}
+
+	if res.Passed() {
+		for i, step := range test.Steps {
+			err = r.performStep(mgr, step)
+			if err != nil {
+				res.failf("step %d (%s) failed: %s", i+1, step, err)
+				break
+			}
+
+			if step.ExpectState != "" && m.State() != step.ExpectState {
+				res.failf("step %d (%s) expected state %s but machine is in %s", i+1, step, step.ExpectState, m.State())
+				break
+			}
+		}
+	}
+
+	rec.mu.Lock()
+	res.Transitions = rec.transitions
+	res.States = rec.states
+	rec.mu.Unlock()
+	res.FinalState = m.State()
+
+	r.checkExpectations(m, test.Expect, res)
+
+	return res, nil
+}
+
+func (r *Runner) performStep(mgr *manager, step *Step) error {
+	var w *fakeWatcher
+	var err error
+
+	if step.Watcher != "" {
+		w, err = mgr.watcher(step.Watcher)
+		if err != nil {
+			return err
+		}
+
+		// real watchers do not act outside their active states so the scripted event can never happen
+		if !w.shouldWatch() {
+			return fmt.Errorf("watcher %s is not active in state %s", step.Watcher, mgr.machine.State())
+		}
+	}
+
+	switch {
+	case step.Outcome == "success":
+		err = w.success()
+
+	case step.Outcome == "fail":
+		err = w.fail()
+
+	case step.ExitCode != nil:
+		if *step.ExitCode == 0 {
+			err = w.success()
+		} else {
+			err = w.fail()
+		}
+
+	case step.FileChanged != nil:
+		if *step.FileChanged {
+			err = w.success()
+		} else {
+			err = w.fail()
+		}
+
+	case step.KV != nil:
+		err = w.kvUpdate(step.KV)
+
+	case step.Transition != "":
+		if !mgr.machine.Can(step.Transition) {
+			return fmt.Errorf("cannot transition using %s while in %s", step.Transition, mgr.machine.State())
+		}
+
+		err = mgr.machine.Transition(step.Transition)
+
+	case len(step.Data) > 0:
+		for k, v := range step.Data {
+			err = mgr.machine.DataPut(k, v)
+			if err != nil {
+				return err
+			}
+		}
+
+	case step.Advance != "":
+		var d time.Duration
+		d, err = iu.ParseDuration(step.Advance)
+		if err != nil {
+			return err
+		}
+
+		return mgr.advance(d)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return mgr.settle()
+}
+
+func (r *Runner) checkExpectations(m *machine.Machine, expect *Expectation, res *Result) {
+	if expect == nil {
+		return
+	}
+
+	if expect.Transitions != nil && !equalStrings(expect.Transitions, res.Transitions) {
+		res.failf("expected transitions %s but got %s", strings.Join(expect.Transitions, ", "), strings.Join(res.Transitions, ", "))
+	}
+
+	if expect.States != nil && !equalStrings(expect.States, res.States) {
+		res.failf("expected states %s but got %s", strings.Join(expect.States, ", "), strings.Join(res.States, ", "))
+	}
+
+	if expect.FinalState != "" && expect.FinalState != res.FinalState {
+		res.failf("expected final state %s but got %s", expect.FinalState, res.FinalState)
+	}
+
+	for k, v := range expect.Data {
+		actual, ok := m.DataGet(k)
+		if !ok {
+			res.failf("expected data item %s was not set", k)
+			continue
+		}
+
+		// compare using json to avoid differences in number types between yaml and watchers
+		ej, _ := json.Marshal(v)
+		aj, _ := json.Marshal(actual)
+		if string(ej) != string(aj) {
+			res.failf("expected data item %s to be %s but got %s", k, ej, aj)
+		}
+	}
+}
+
+func equalStrings(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NotifyPostTransition implements machine.NotificationService
+func (r *recorder) NotifyPostTransition(t *machine.TransitionNotification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.transitions = append(r.transitions, t.Transition)
+	r.states = append(r.states, t.ToState)
+
+	return nil
+}
+
+// NotifyWatcherState implements machine.NotificationService
+func (r *recorder) NotifyWatcherState(_ string, _ machine.WatcherStateNotification) error {
+	return nil
+}
+
+// Debugf implements machine.NotificationService
+func (r *recorder) Debugf(m machine.InfoSource, name string, format string, args ...any) {
+	r.log.Debugf("%s#%s: %s", m.Name(), name, fmt.Sprintf(format, args...))
+}
+
+// Infof implements machine.NotificationService
+func (r *recorder) Infof(m machine.InfoSource, name string, format string, args ...any) {
+	r.log.Debugf("%s#%s: %s", m.Name(), name, fmt.Sprintf(format, args...))
+}
+
+// Warnf implements machine.NotificationService
+func (r *recorder) Warnf(m machine.InfoSource, name string, format string, args ...any) {
+	r.log.Warnf("%s#%s: %s", m.Name(), name, fmt.Sprintf(format, args...))
+}
+
+// Errorf implements machine.NotificationService
+func (r *recorder) Errorf(m machine.InfoSource, name string, format string, args ...any) {
+	r.log.Errorf("%s#%s: %s", m.Name(), name, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package tester runs autonomous agent machines against scripted watcher
+// outcomes and virtual time, asserting the resulting transitions without
+// any real side effects
+package tester
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ghodss/yaml"
+)
+
+// Suite is a collection of tests for a single machine
+type Suite struct {
+	// Name is a descriptive name for the suite
+	Name string `json:"name"`
+
+	// Identity is the identity the machine will run as, defaults to tester
+	Identity string `json:"identity"`
+
+	// StartTime is the virtual time the tests will start at, defaults to the current time
+	StartTime time.Time `json:"start_time"`
+
+	// Facts are the facts supplied to every test
+	Facts map[string]any `json:"facts"`
+
+	// Data is the initial machine data supplied to every test
+	Data map[string]any `json:"data"`
+
+	// Tests are the individual tests to run
+	Tests []*Test `json:"tests"`
+}
+
+// Test is a single test of a machine
+type Test struct {
+	// Name is a descriptive name for the test
+	Name string `json:"name"`
+
+	// Facts are facts for this test, merged over those of the suite
+	Facts map[string]any `json:"facts"`
+
+	// Data is the initial data for this test, merged over that of the suite
+	Data map[string]any `json:"data"`
+
+	// Steps are the steps taken in order
+	Steps []*Step `json:"steps"`
+
+	// Expect is the expected outcome once all steps are completed
+	Expect *Expectation `json:"expect"`
+}
+
+// Step is a single scripted action taken against the machine
+type Step struct {
+	// Description is an optional description shown in failures
+	Description string `json:"description,omitempty"`
+
+	// Watcher is the name of the watcher the outcome, exit code, file or kv settings apply to
+	Watcher string `json:"watcher,omitempty"`
+
+	// Outcome is either success or fail and triggers the matching watcher event
+	Outcome string `json:"outcome,omitempty"`
+
+	// ExitCode simulates a command completing with the given code, 0 being success
+	ExitCode *int `json:"exit_code,omitempty"`
+
+	// FileChanged simulates a file watcher detecting a change when true and a missing file when false
+	FileChanged *bool `json:"file_changed,omitempty"`
+
+	// KV simulates an update to the key being watched by a kv watcher
+	KV *KVUpdate `json:"kv,omitempty"`
+
+	// Transition fires a transition directly as if done using choria_util#machine_transition
+	Transition string `json:"transition,omitempty"`
+
+	// Data stores data items in the machine without triggering any events
+	Data map[string]any `json:"data,omitempty"`
+
+	// Advance moves the virtual clock forward, firing timer and schedule watchers as needed
+	Advance string `json:"advance,omitempty"`
+
+	// ExpectState asserts the machine is in this state after the step completes
+	ExpectState string `json:"expect_state,omitempty"`
+}
+
+// KVUpdate is a simulated change to a Key-Value entry
+type KVUpdate struct {
+	// Value is the new value for the key
+	Value any `json:"value"`
+
+	// Delete simulates the key being deleted
+	Delete bool `json:"delete"`
+}
+
+// Expectation describes the expected outcome of a test
+type Expectation struct {
+	// Transitions is the exact ordered list of transition events expected to fire
+	Transitions []string `json:"transitions"`
+
+	// States is the exact ordered list of states the machine is expected to move through, excluding its initial state
+	States []string `json:"states"`
+
+	// FinalState is the state the machine should be in once all steps completed
+	FinalState string `json:"final_state"`
+
+	// Data are data items that should be present with the given values
+	Data map[string]any `json:"data"`
+}
+
+// Load reads a suite from a YAML or JSON file
+func Load(file string) (*Suite, error) {
+	f, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	suite := &Suite{}
+	err = yaml.Unmarshal(f, suite)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse %s: %s", file, err)
+	}
+
+	err = suite.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return suite, nil
+}
+
+// Validate checks the suite for basic validity
+func (s *Suite) Validate() error {
+	if len(s.Tests) == 0 {
+		return fmt.Errorf("no tests defined")
+	}
+
+	for i, t := range s.Tests {
+		if t.Name == "" {
+			return fmt.Errorf("test %d has no name", i+1)
+		}
+
+		for si, step := range t.Steps {
+			err := step.validate()
+			if err != nil {
+				return fmt.Errorf("test %q step %d is invalid: %s", t.Name, si+1, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Step) validate() error {
+	actions := 0
+	for _, set := range []bool{s.Outcome != "", s.ExitCode != nil, s.FileChanged != nil, s.KV != nil, s.Transition != "", len(s.Data) > 0, s.Advance != ""} {
+		if set {
+			actions++
+		}
+	}
+
+	if actions == 0 && s.ExpectState == "" {
+		return fmt.Errorf("no action specified")
+	}
+
+	if actions > 1 {
+		return fmt.Errorf("only one action can be specified per step")
+	}
+
+	watcherAction := s.Outcome != "" || s.ExitCode != nil || s.FileChanged != nil || s.KV != nil
+	if watcherAction && s.Watcher == "" {
+		return fmt.Errorf("watcher is required")
+	}
+
+	if s.Outcome != "" && s.Outcome != "success" && s.Outcome != "fail" {
+		return fmt.Errorf("outcome should be success or fail")
+	}
+
+	return nil
+}
+
+func (s *Step) String() string {
+	if s.Description != "" {
+		return s.Description
+	}
+
+	j, _ := json.Marshal(s)
+
+	return string(j)
+}
+
+func mergeMaps(maps ...map[string]any) map[string]any {
+	res := map[string]any{}
+	for _, m := range maps {
+		for k, v := range m {
+			res[k] = v
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package tester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestTester(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Tester")
+}
+
+var _ = Describe("AAgent/Tester", func() {
+	var log *logrus.Entry
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+	})
+
+	Describe("Load", func() {
+		It("Should load and validate suites", func() {
+			suite, err := Load("testdata/maintenance/tests.yaml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(suite.Name).To(Equal("maintenance machine"))
+			Expect(suite.Tests).To(HaveLen(4))
+			Expect(*suite.Tests[0].Steps[0].ExitCode).To(Equal(0))
+		})
+
+		It("Should detect invalid steps", func() {
+			suite := &Suite{Tests: []*Test{{Name: "x", Steps: []*Step{{Outcome: "success"}}}}}
+			Expect(suite.Validate()).To(MatchError("test \"x\" step 1 is invalid: watcher is required"))
+
+			suite.Tests[0].Steps[0] = &Step{Transition: "x", Advance: "1m"}
+			Expect(suite.Validate()).To(MatchError("test \"x\" step 1 is invalid: only one action can be specified per step"))
+		})
+	})
+
+	Describe("Run", func() {
+		It("Should run the suite", func() {
+			suite, err := Load("testdata/maintenance/tests.yaml")
+			Expect(err).ToNot(HaveOccurred())
+
+			runner, err := New("testdata/maintenance", suite, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			results, err := runner.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results).To(HaveLen(4))
+			for _, res := range results {
+				Expect(res.Failures).To(BeEmpty(), res.Name)
+			}
+
+			Expect(results[1].States).To(Equal([]string{"failed", "unknown", "ok"}))
+		})
+
+		It("Should report failed expectations", func() {
+			suite := &Suite{
+				Tests: []*Test{
+					{
+						Name: "failing",
+						Steps: []*Step{
+							{Watcher: "check", Outcome: "fail"},
+						},
+						Expect: &Expectation{
+							Transitions: []string{"healthy"},
+							FinalState:  "ok",
+							Data:        map[string]any{"x": 1},
+						},
+					},
+				},
+			}
+
+			runner, err := New("testdata/maintenance", suite, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			results, err := runner.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results[0].Passed()).To(BeFalse())
+			Expect(results[0].Failures).To(Equal([]string{
+				"expected transitions healthy but got unhealthy",
+				"expected final state ok but got failed",
+				"expected data item x was not set",
+			}))
+		})
+
+		It("Should fail steps for watchers not active in the current state", func() {
+			suite := &Suite{
+				Tests: []*Test{
+					{
+						Name: "inactive",
+						Steps: []*Step{
+							{Watcher: "config", KV: &KVUpdate{Value: 1}},
+						},
+					},
+				},
+			}
+
+			runner, err := New("testdata/maintenance", suite, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			results, err := runner.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results[0].Failures).To(Equal([]string{"step 1 ({\"watcher\":\"config\",\"kv\":{\"value\":1,\"delete\":false}}) failed: watcher config is not active in state unknown"}))
+		})
+
+		It("Should detect invalid transitions", func() {
+			suite := &Suite{
+				StartTime: time.Date(2022, 8, 1, 1, 0, 0, 0, time.UTC),
+				Tests: []*Test{
+					{
+						Name: "loop",
+						Steps: []*Step{
+							{Transition: "missing"},
+						},
+					},
+				},
+			}
+
+			runner, err := New("testdata/maintenance", suite, log)
+			Expect(err).ToNot(HaveOccurred())
+
+			results, err := runner.Run()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(results[0].Failures).To(Equal([]string{"step 1 ({\"transition\":\"missing\"}) failed: cannot transition using missing while in unknown"}))
+		})
+	})
+})
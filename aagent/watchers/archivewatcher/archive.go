@@ -65,6 +65,10 @@ type Properties struct {
 	Governor string
 	// GovernorTimeout is how long we'll try to access the governor
 	GovernorTimeout time.Duration `mapstructure:"governor_timeout"`
+	// GovernorWeight is how many slots in the governor a lease consumes, defaults to 1
+	GovernorWeight int `mapstructure:"governor_weight"`
+	// GovernorPriority is the priority class used when obtaining a lease, one of low, normal, high or urgent
+	GovernorPriority string `mapstructure:"governor_priority"`
 	// Insecure skips TLS verification on https downloads (not implemented)
 	Insecure bool
 	// Password for accessing the source, required when a username is set
@@ -212,7 +216,12 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 	}
 
 	if w.properties.Governor != "" {
-		fin, err := w.EnterGovernor(ctx, w.properties.Governor, w.properties.GovernorTimeout)
+		opts, err := watcher.GovernorOptions(w.properties.GovernorWeight, w.properties.GovernorPriority)
+		if err != nil {
+			return Error, err
+		}
+
+		fin, err := w.EnterGovernor(ctx, w.properties.Governor, w.properties.GovernorTimeout, opts...)
 		if err != nil {
 			w.Errorf("Cannot enter Governor %s: %s", w.properties.Governor, err)
 			return Error, err
@@ -610,6 +619,13 @@ func (w *Watcher) validate() error {
 		w.properties.GovernorTimeout = 5 * time.Minute
 	}
 
+	if w.properties.Governor != "" {
+		_, err := watcher.GovernorOptions(w.properties.GovernorWeight, w.properties.GovernorPriority)
+		if err != nil {
+			return err
+		}
+	}
+
 	if w.properties.Timeout < 5*time.Second {
 		w.Infof("Setting timeout to minimum 5 seconds")
 		w.properties.Timeout = 5 * time.Second
@@ -0,0 +1,156 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package watchers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/backoff"
+	election "github.com/choria-io/go-choria/providers/election/streams"
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// leaderElectionBucket is the Choria Streams bucket used for elections gating watchers
+	leaderElectionBucket = "CHORIA_LEADER_ELECTION"
+
+	// leaderElectionsDataKey is the machine data key showing leadership of the elections watchers are gated on,
+	// it is for display only, leadership is tracked by the manager
+	leaderElectionsDataKey = "leader_elections"
+)
+
+// leaderElection is an election one or more watchers are gated on
+type leaderElection struct {
+	name   string
+	gained []string
+	lost   []string
+}
+
+// addLeaderElection gates watcher on the election named in its definition
+func (m *Manager) addLeaderElection(def *WatcherDef, w model.Watcher) error {
+	gated, ok := w.(model.LeaderGatedWatcher)
+	if !ok {
+		return fmt.Errorf("%s watcher %s does not support leader elections", def.Type, def.Name)
+	}
+
+	name := def.LeaderElection
+	gated.SetLeaderElection(name, func() bool { return m.isLeader(name) })
+
+	m.Lock()
+	defer m.Unlock()
+
+	e, ok := m.elections[def.LeaderElection]
+	if !ok {
+		e = &leaderElection{name: def.LeaderElection}
+		m.elections[def.LeaderElection] = e
+	}
+
+	if def.LeaderGainedTransition != "" {
+		e.gained = append(e.gained, def.LeaderGainedTransition)
+	}
+	if def.LeaderLostTransition != "" {
+		e.lost = append(e.lost, def.LeaderLostTransition)
+	}
+
+	m.leaders[e.name] = false
+
+	return m.machine.DataPut(leaderElectionsDataKey, m.leadersCopy())
+}
+
+// isLeader determines if this machine is the leader of a named election
+func (m *Manager) isLeader(name string) bool {
+	m.Lock()
+	defer m.Unlock()
+
+	return m.leaders[name]
+}
+
+// leadersCopy must be called with the lock held
+func (m *Manager) leadersCopy() map[string]any {
+	leaders := make(map[string]any, len(m.leaders))
+	for k, v := range m.leaders {
+		leaders[k] = v
+	}
+
+	return leaders
+}
+
+func (m *Manager) setLeader(e *leaderElection, leader bool) {
+	m.Lock()
+	m.leaders[e.name] = leader
+	err := m.machine.DataPut(leaderElectionsDataKey, m.leadersCopy())
+	m.Unlock()
+
+	if err != nil {
+		m.machine.Errorf("manager", "Could not record leadership of election %s: %s", e.name, err)
+	}
+
+	transitions := e.lost
+	if leader {
+		m.machine.Infof("manager", "Became leader of election %s", e.name)
+		transitions = e.gained
+	} else {
+		m.machine.Infof("manager", "Lost leadership of election %s", e.name)
+	}
+
+	for _, t := range transitions {
+		err = m.machine.Transition(t)
+		if err != nil {
+			m.machine.Errorf("manager", "Could not fire %s transition for election %s: %s", t, e.name, err)
+		}
+	}
+
+	m.NotifyStateChance()
+}
+
+func (m *Manager) runLeaderElection(ctx context.Context, wg *sync.WaitGroup, e *leaderElection) {
+	defer wg.Done()
+
+	var kv nats.KeyValue
+
+	err := backoff.FiveSec.For(ctx, func(try int) error {
+		mgr, err := m.machine.JetStreamConnection()
+		if err != nil {
+			m.machine.Errorf("manager", "Could not connect to Choria Streams for election %s: %s", e.name, err)
+			return err
+		}
+
+		js, err := mgr.NatsConn().JetStream()
+		if err != nil {
+			m.machine.Errorf("manager", "Could not connect to Choria Streams for election %s: %s", e.name, err)
+			return err
+		}
+
+		kv, err = js.KeyValue(leaderElectionBucket)
+		if err != nil {
+			m.machine.Errorf("manager", "Could not access KV bucket %s for election %s: %s", leaderElectionBucket, e.name, err)
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	el, err := election.NewElection(m.machine.Identity(), e.name, kv,
+		election.OnWon(func() { m.setLeader(e, true) }),
+		election.OnLost(func() { m.setLeader(e, false) }),
+		election.WithDebug(func(format string, a ...any) { m.machine.Debugf("manager", format, a...) }))
+	if err != nil {
+		m.machine.Errorf("manager", "Could not start election %s: %s", e.name, err)
+		return
+	}
+
+	m.machine.Infof("manager", "Campaigning in election %s", e.name)
+
+	err = el.Start(ctx)
+	if err != nil {
+		m.machine.Errorf("manager", "Election %s failed: %s", e.name, err)
+	}
+}
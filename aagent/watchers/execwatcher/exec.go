@@ -46,6 +46,8 @@ type Properties struct {
 	Environment             []string
 	Governor                string
 	GovernorTimeout         time.Duration `mapstructure:"governor_timeout"`
+	GovernorWeight          int           `mapstructure:"governor_weight"`
+	GovernorPriority        string        `mapstructure:"governor_priority"`
 	OutputAsData            bool          `mapstructure:"parse_as_data"`
 	SuppressSuccessAnnounce bool          `mapstructure:"suppress_success_announce"`
 	GatherInitialState      bool          `mapstructure:"gather_initial_state"`
@@ -119,6 +121,13 @@ func (w *Watcher) validate() error {
 		w.properties.GovernorTimeout = 5 * time.Minute
 	}
 
+	if w.properties.Governor != "" {
+		_, err := watcher.GovernorOptions(w.properties.GovernorWeight, w.properties.GovernorPriority)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -246,7 +255,12 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 	}
 
 	if w.properties.Governor != "" {
-		fin, err := w.EnterGovernor(ctx, w.properties.Governor, w.properties.GovernorTimeout)
+		opts, err := watcher.GovernorOptions(w.properties.GovernorWeight, w.properties.GovernorPriority)
+		if err != nil {
+			return Error, err
+		}
+
+		fin, err := w.EnterGovernor(ctx, w.properties.Governor, w.properties.GovernorTimeout, opts...)
 		if err != nil {
 			w.Errorf("Cannot enter Governor %s: %s", w.properties.Governor, err)
 			return Error, err
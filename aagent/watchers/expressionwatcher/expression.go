@@ -0,0 +1,344 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package expressionwatcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/antonmedv/expr"
+	"github.com/antonmedv/expr/vm"
+	"github.com/google/go-cmp/cmp"
+	"github.com/tidwall/gjson"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/choria-io/go-choria/aagent/util"
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/watcher"
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+type State int
+
+const (
+	Unknown State = iota
+	Skipped
+	Error
+	True
+	False
+	Computed
+
+	wtype   = "expression"
+	version = "v1"
+
+	defaultInterval = 10 * time.Second
+)
+
+var stateNames = map[State]string{
+	Unknown:  "unknown",
+	Skipped:  "skipped",
+	Error:    "error",
+	True:     "true",
+	False:    "false",
+	Computed: "computed",
+}
+
+type properties struct {
+	// Expression is the expr expression to evaluate
+	Expression string
+	// DataItem is the machine data key the result will be stored in, not stored when empty
+	DataItem string `mapstructure:"data_item"`
+}
+
+type Watcher struct {
+	*watcher.Watcher
+	properties *properties
+
+	name     string
+	machine  model.Machine
+	interval time.Duration
+	program  *vm.Program
+
+	previous       State
+	previousResult any
+
+	wmu *sync.Mutex
+	mu  *sync.Mutex
+}
+
+func New(machine model.Machine, name string, states []string, failEvent string, successEvent string, interval string, ai time.Duration, properties map[string]any) (any, error) {
+	var err error
+
+	tw := &Watcher{
+		name:     name,
+		machine:  machine,
+		interval: defaultInterval,
+		wmu:      &sync.Mutex{},
+		mu:       &sync.Mutex{},
+	}
+
+	tw.Watcher, err = watcher.NewWatcher(name, wtype, ai, states, machine, failEvent, successEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.setProperties(properties)
+	if err != nil {
+		return nil, fmt.Errorf("could not set properties: %s", err)
+	}
+
+	if interval != "" {
+		tw.interval, err = iu.ParseDuration(interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %v", err)
+		}
+
+		if tw.interval < time.Second {
+			return nil, fmt.Errorf("interval %v is too small", tw.interval)
+		}
+	}
+
+	return tw, nil
+}
+
+func (w *Watcher) setProperties(props map[string]any) error {
+	if w.properties == nil {
+		w.properties = &properties{}
+	}
+
+	err := util.ParseMapStructure(props, w.properties)
+	if err != nil {
+		return err
+	}
+
+	return w.validate()
+}
+
+func (w *Watcher) validate() error {
+	if w.properties.Expression == "" {
+		return fmt.Errorf("expression is required")
+	}
+
+	var err error
+	w.program, err = expr.Compile(w.properties.Expression, expr.Env(w.emptyEnv()), expr.AllowUndefinedVariables())
+	if err != nil {
+		return fmt.Errorf("invalid expression: %s", err)
+	}
+
+	return nil
+}
+
+func (w *Watcher) Run(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	w.Infof("Expression watcher starting with %v interval", w.interval)
+
+	tick := time.NewTicker(w.interval)
+	defer tick.Stop()
+
+	// handle initial state
+	w.performWatch(false)
+
+	for {
+		select {
+		case <-tick.C:
+			w.performWatch(false)
+
+		case <-w.StateChangeC():
+			w.performWatch(true)
+
+		case <-ctx.Done():
+			w.Infof("Stopping on context interrupt")
+			return
+		}
+	}
+}
+
+func (w *Watcher) performWatch(force bool) {
+	w.wmu.Lock()
+	defer w.wmu.Unlock()
+
+	state, changed, err := w.watch()
+	err = w.handleCheck(state, changed || force, err)
+	if err != nil {
+		w.Errorf("could not handle watcher event: %s", err)
+	}
+}
+
+func (w *Watcher) handleCheck(s State, changed bool, err error) error {
+	w.Debugf("handling check for %s: %s: %v", w.properties.Expression, stateNames[s], err)
+
+	w.mu.Lock()
+	changed = changed || w.previous != s
+	w.previous = s
+	w.mu.Unlock()
+
+	switch s {
+	case Error:
+		if err != nil {
+			w.Errorf("Expression evaluation failed: %s", err)
+		}
+
+		if changed {
+			w.NotifyWatcherState(w.CurrentState())
+		}
+
+	case True:
+		if changed {
+			w.NotifyWatcherState(w.CurrentState())
+			return w.SuccessTransition()
+		}
+
+	case False:
+		if changed {
+			w.NotifyWatcherState(w.CurrentState())
+			return w.FailureTransition()
+		}
+
+	case Computed:
+		if changed {
+			w.NotifyWatcherState(w.CurrentState())
+		}
+	}
+
+	return nil
+}
+
+// watch evaluates the expression, stores the result and reports if the result changed since the previous evaluation
+func (w *Watcher) watch() (State, bool, error) {
+	if !w.ShouldWatch() {
+		return Skipped, false, nil
+	}
+
+	env, err := w.env()
+	if err != nil {
+		return Error, false, err
+	}
+
+	res, err := expr.Run(w.program, env)
+	if err != nil {
+		return Error, false, err
+	}
+
+	w.mu.Lock()
+	changed := !cmp.Equal(w.previousResult, res)
+	w.previousResult = res
+	w.mu.Unlock()
+
+	if w.properties.DataItem != "" {
+		prev, ok := w.machine.DataGet(w.properties.DataItem)
+		if !ok || !cmp.Equal(prev, res) {
+			err = w.machine.DataPut(w.properties.DataItem, res)
+			if err != nil {
+				return Error, changed, err
+			}
+		}
+	}
+
+	switch r := res.(type) {
+	case bool:
+		if r {
+			return True, changed, nil
+		}
+
+		return False, changed, nil
+
+	default:
+		return Computed, changed, nil
+	}
+}
+
+func (w *Watcher) emptyEnv() map[string]any {
+	return map[string]any{
+		"data":     map[string]any{},
+		"facts":    map[string]any{},
+		"identity": "",
+		"state":    "",
+		"watcher":  w.watcherStateFunc,
+		"lookup":   func(q string, dflt any) any { return dflt },
+	}
+}
+
+func (w *Watcher) env() (map[string]any, error) {
+	jfacts := w.machine.Facts()
+	facts := map[string]any{}
+	if len(jfacts) > 0 {
+		err := json.Unmarshal(jfacts, &facts)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse facts: %s", err)
+		}
+	}
+
+	data := w.machine.Data()
+	jdata, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	jinput, err := json.Marshal(map[string]json.RawMessage{
+		"facts": jfacts,
+		"data":  jdata,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"data":     data,
+		"facts":    facts,
+		"identity": w.machine.Identity(),
+		"state":    w.machine.State(),
+		"watcher":  w.watcherStateFunc,
+		"lookup": func(q string, dflt any) any {
+			r := gjson.GetBytes(jinput, q)
+			if !r.Exists() {
+				return dflt
+			}
+
+			return r.Value()
+		},
+	}, nil
+}
+
+// watcherStateFunc retrieves the current state of another watcher in the same machine as a map, nil when unknown
+func (w *Watcher) watcherStateFunc(name string) map[string]any {
+	state, ok := w.machine.WatcherState(name)
+	if !ok || state == nil {
+		return nil
+	}
+
+	j, err := json.Marshal(state)
+	if err != nil {
+		w.Warnf("Could not encode state for watcher %s: %s", name, err)
+		return nil
+	}
+
+	res := map[string]any{}
+	err = json.Unmarshal(j, &res)
+	if err != nil {
+		w.Warnf("Could not decode state for watcher %s: %s", name, err)
+		return nil
+	}
+
+	return res
+}
+
+func (w *Watcher) CurrentState() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	s := &StateNotification{
+		Event:      event.New(w.name, wtype, version, w.machine),
+		State:      stateNames[w.previous],
+		Expression: w.properties.Expression,
+		DataItem:   w.properties.DataItem,
+		Result:     w.previousResult,
+	}
+
+	return s
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package expressionwatcher
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "AAgent/Watchers/ExpressionWatcher")
+}
+
+var _ = Describe("ExpressionWatcher", func() {
+	var (
+		mockctl     *gomock.Controller
+		mockMachine *model.MockMachine
+		watch       *Watcher
+		now         time.Time
+	)
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+		mockMachine = model.NewMockMachine(mockctl)
+		mockMachine.EXPECT().IsPaused().Return(false).AnyTimes()
+
+		now = time.Unix(1606924953, 0)
+		mockMachine.EXPECT().Name().Return("expression").AnyTimes()
+		mockMachine.EXPECT().Identity().Return("ginkgo").AnyTimes()
+		mockMachine.EXPECT().InstanceID().Return("1234567890").AnyTimes()
+		mockMachine.EXPECT().Version().Return("1.0.0").AnyTimes()
+		mockMachine.EXPECT().TimeStampSeconds().Return(now.Unix()).AnyTimes()
+		mockMachine.EXPECT().State().Return("run").AnyTimes()
+		mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+		wi, err := New(mockMachine, "ginkgo", []string{"run"}, "fail", "success", "1m", time.Second, map[string]any{
+			"expression": "data.x > 1",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		watch = wi.(*Watcher)
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("New", func() {
+		It("Should validate the interval", func() {
+			_, err := New(mockMachine, "ginkgo", nil, "", "", "500ms", 0, map[string]any{"expression": "true"})
+			Expect(err).To(MatchError("interval 500ms is too small"))
+		})
+	})
+
+	Describe("setProperties", func() {
+		It("Should parse valid properties", func() {
+			err := watch.setProperties(map[string]any{
+				"expression": "data.y * 2",
+				"data_item":  "double_y",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(watch.properties.Expression).To(Equal("data.y * 2"))
+			Expect(watch.properties.DataItem).To(Equal("double_y"))
+			Expect(watch.program).ToNot(BeNil())
+		})
+
+		It("Should require an expression", func() {
+			watch.properties = nil
+			err := watch.setProperties(map[string]any{})
+			Expect(err).To(MatchError("expression is required"))
+		})
+
+		It("Should detect invalid expressions", func() {
+			err := watch.setProperties(map[string]any{
+				"expression": "data.y *",
+			})
+			Expect(err).To(MatchError(ContainSubstring("invalid expression")))
+		})
+	})
+
+	Describe("watch", func() {
+		BeforeEach(func() {
+			mockMachine.EXPECT().Facts().Return(json.RawMessage(`{"location":"lon","cpus":4}`)).AnyTimes()
+		})
+
+		It("Should evaluate boolean expressions", func() {
+			mockMachine.EXPECT().Data().Return(map[string]any{"x": 2}).Times(1)
+			state, changed, err := watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(True))
+			Expect(changed).To(BeTrue())
+
+			mockMachine.EXPECT().Data().Return(map[string]any{"x": 2}).Times(1)
+			state, changed, err = watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(True))
+			Expect(changed).To(BeFalse())
+
+			mockMachine.EXPECT().Data().Return(map[string]any{"x": 0}).Times(1)
+			state, changed, err = watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(False))
+			Expect(changed).To(BeTrue())
+		})
+
+		It("Should store results in the data item", func() {
+			err := watch.setProperties(map[string]any{
+				"expression": `lookup("facts.cpus", 1) * 2`,
+				"data_item":  "double_cpus",
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockMachine.EXPECT().Data().Return(map[string]any{}).AnyTimes()
+			mockMachine.EXPECT().DataGet("double_cpus").Return(nil, false)
+			mockMachine.EXPECT().DataPut("double_cpus", float64(8)).Return(nil)
+
+			state, _, err := watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(Computed))
+
+			mockMachine.EXPECT().DataGet("double_cpus").Return(float64(8), true)
+			state, changed, err := watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(Computed))
+			Expect(changed).To(BeFalse())
+		})
+
+		It("Should support other watcher states", func() {
+			err := watch.setProperties(map[string]any{
+				"expression": `watcher("check").state == "changed" && facts.location == "lon"`,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockMachine.EXPECT().Data().Return(map[string]any{}).AnyTimes()
+			mockMachine.EXPECT().WatcherState("check").Return(map[string]any{"state": "changed"}, true)
+
+			state, _, err := watch.watch()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(True))
+		})
+
+		It("Should handle runtime errors", func() {
+			err := watch.setProperties(map[string]any{
+				"expression": `data.x.y.z > 1`,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			mockMachine.EXPECT().Data().Return(map[string]any{"x": 1}).AnyTimes()
+
+			state, _, err := watch.watch()
+			Expect(err).To(HaveOccurred())
+			Expect(state).To(Equal(Error))
+		})
+	})
+
+	Describe("handleCheck", func() {
+		It("Should only transition on changes", func() {
+			mockMachine.EXPECT().NotifyWatcherState(gomock.Any(), gomock.Any()).Times(2)
+			mockMachine.EXPECT().Transition("success").Return(nil).Times(1)
+			mockMachine.EXPECT().Transition("fail").Return(nil).Times(1)
+
+			Expect(watch.handleCheck(True, true, nil)).ToNot(HaveOccurred())
+			Expect(watch.handleCheck(True, false, nil)).ToNot(HaveOccurred())
+			Expect(watch.handleCheck(False, false, nil)).ToNot(HaveOccurred())
+		})
+	})
+
+	Describe("CurrentState", func() {
+		It("Should be a valid state", func() {
+			cs := watch.CurrentState()
+			csj, err := cs.(*StateNotification).JSON()
+			Expect(err).ToNot(HaveOccurred())
+
+			event := map[string]any{}
+			err = json.Unmarshal(csj, &event)
+			Expect(err).ToNot(HaveOccurred())
+			delete(event, "id")
+
+			Expect(event).To(Equal(map[string]any{
+				"time":            "2020-12-02T16:02:33Z",
+				"type":            "io.choria.machine.watcher.expression.v1.state",
+				"subject":         "ginkgo",
+				"specversion":     "1.0",
+				"source":          "io.choria.machine",
+				"datacontenttype": "application/json",
+				"data": map[string]any{
+					"id":         "1234567890",
+					"identity":   "ginkgo",
+					"machine":    "expression",
+					"name":       "ginkgo",
+					"protocol":   "io.choria.machine.watcher.expression.v1.state",
+					"state":      "unknown",
+					"expression": "data.x > 1",
+					"type":       "expression",
+					"version":    "1.0.0",
+					"timestamp":  float64(now.Unix()),
+				},
+			}))
+		})
+	})
+})
@@ -0,0 +1,44 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package expressionwatcher
+
+import (
+	"encoding/json"
+	"fmt"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+)
+
+// StateNotification describes the current state of the watcher
+// described by io.choria.machine.watcher.expression.v1.state
+type StateNotification struct {
+	event.Event
+
+	State      string `json:"state"`
+	Expression string `json:"expression"`
+	DataItem   string `json:"data_item,omitempty"`
+	Result     any    `json:"result,omitempty"`
+}
+
+// JSON creates a JSON representation of the notification
+func (s *StateNotification) JSON() ([]byte, error) {
+	return json.Marshal(s.CloudEvent())
+}
+
+// CloudEvent creates a CloudEvent from the state notification
+func (s *StateNotification) CloudEvent() cloudevents.Event {
+	return s.Event.CloudEvent(s)
+}
+
+// String is a string representation of the notification suitable for printing
+func (s *StateNotification) String() string {
+	if s.DataItem != "" {
+		return fmt.Sprintf("%s expression %s#%s state: %s result: %v stored in %s", s.Identity, s.Machine, s.Name, s.State, s.Result, s.DataItem)
+	}
+
+	return fmt.Sprintf("%s expression %s#%s state: %s result: %v", s.Identity, s.Machine, s.Name, s.State, s.Result)
+}
@@ -34,6 +34,9 @@ const (
 	version   = "v1"
 	pollMode  = "poll"
 	watchMode = "watch"
+
+	// schemaCacheTime is how long schemas loaded from the bucket are used before being loaded again
+	schemaCacheTime = time.Minute
 )
 
 var stateNames = map[State]string{
@@ -50,6 +53,7 @@ type properties struct {
 	TransitionOnSuccessfulGet bool `mapstructure:"on_successful_get"`
 	TransitionOnMatch         bool `mapstructure:"on_matching_update"`
 	BucketPrefix              bool `mapstructure:"bucket_prefix"`
+	SkipValidation            bool `mapstructure:"skip_validation"`
 }
 
 type Watcher struct {
@@ -66,6 +70,8 @@ type Watcher struct {
 	previousState State
 	polling       bool
 	lastPoll      time.Time
+	schemas       *kv.Schemas
+	schemasLoaded time.Time
 
 	terminate chan struct{}
 	mu        *sync.Mutex
@@ -166,6 +172,37 @@ func (w *Watcher) connectKV() error {
 	return nil
 }
 
+// validateValue validates a value against the bucket schemas, schemas that cannot be loaded disable validation until
+// they are loaded again after schemaCacheTime
+func (w *Watcher) validateValue(key string, value []byte) error {
+	w.mu.Lock()
+	store := w.kv
+	schemas := w.schemas
+	load := time.Since(w.schemasLoaded) >= schemaCacheTime
+	if load {
+		w.schemasLoaded = time.Now()
+	}
+	w.mu.Unlock()
+
+	if load {
+		var err error
+		schemas, _, err = kv.LoadSchemas(store)
+		if err != nil {
+			w.Warnf("Could not load schemas for bucket %s, values will not be validated: %s", w.properties.Bucket, err)
+		}
+
+		w.mu.Lock()
+		w.schemas = schemas
+		w.mu.Unlock()
+	}
+
+	if schemas == nil {
+		return nil
+	}
+
+	return schemas.Validate(key, value)
+}
+
 func (w *Watcher) poll() (State, error) {
 	if !w.ShouldWatch() {
 		return Skipped, nil
@@ -214,6 +251,14 @@ func (w *Watcher) poll() (State, error) {
 	}
 
 	val, err := w.kv.Get(parsedKey)
+	if err == nil && !w.properties.SkipValidation {
+		err = w.validateValue(parsedKey, val.Value())
+		if err != nil {
+			w.Errorf("Value for %s.%s failed validation: %s", w.properties.Bucket, parsedKey, err)
+			return Error, err
+		}
+	}
+
 	if err == nil {
 		// we try to handle json files into a map[string]interface this means nested lookups can be done
 		// in other machines using the lookup template func and it works just fine, deep compares are done
@@ -217,6 +217,20 @@ func (mr *MockMachineMockRecorder) InstanceID() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstanceID", reflect.TypeOf((*MockMachine)(nil).InstanceID))
 }
 
+// IsPaused mocks base method.
+func (m *MockMachine) IsPaused() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsPaused")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsPaused indicates an expected call of IsPaused.
+func (mr *MockMachineMockRecorder) IsPaused() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsPaused", reflect.TypeOf((*MockMachine)(nil).IsPaused))
+}
+
 // JetStreamConnection mocks base method.
 func (m *MockMachine) JetStreamConnection() (*jsm_go.Manager, error) {
 	m.ctrl.T.Helper()
@@ -396,6 +410,21 @@ func (mr *MockMachineMockRecorder) Warnf(arg0, arg1 any, arg2 ...any) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Warnf", reflect.TypeOf((*MockMachine)(nil).Warnf), varargs...)
 }
 
+// WatcherState mocks base method.
+func (m *MockMachine) WatcherState(arg0 string) (any, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatcherState", arg0)
+	ret0, _ := ret[0].(any)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// WatcherState indicates an expected call of WatcherState.
+func (mr *MockMachineMockRecorder) WatcherState(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatcherState", reflect.TypeOf((*MockMachine)(nil).WatcherState), arg0)
+}
+
 // Watchers mocks base method.
 func (m *MockMachine) Watchers() []*WatcherDef {
 	m.ctrl.T.Helper()
@@ -5,9 +5,6 @@
 package main
 
 import (
-	"crypto/ed25519"
-	"crypto/rand"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"os"
@@ -37,13 +34,13 @@ func main() {
 }
 
 func keysAction(_ *fisk.ParseContext) error {
-	pub, pri, err := ed25519.GenerateKey(rand.Reader)
+	pub, pri, err := machines.NewKeyPair()
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf(" Public Key: %s\n", hex.EncodeToString(pub))
-	fmt.Printf("Private Key: %s\n", hex.EncodeToString(pri))
+	fmt.Printf(" Public Key: %s\n", pub)
+	fmt.Printf("Private Key: %s\n", pri)
 
 	return nil
 }
@@ -54,19 +51,13 @@ func signAction(_ *fisk.ParseContext) error {
 		return err
 	}
 
-	spec := machines.Specification{
-		Machines: data,
+	if key == "" && !force {
+		logrus.Warn("No ed25519 private key given encoding without signing")
 	}
 
-	if key != "" {
-		pk, err := hex.DecodeString(key)
-		if err != nil {
-			return err
-		}
-
-		spec.Signature = hex.EncodeToString(ed25519.Sign(pk, data))
-	} else if !force {
-		logrus.Warn("No ed25519 private key given encoding without signing")
+	spec, err := machines.PackSpecification(data, key)
+	if err != nil {
+		return err
 	}
 
 	j, err := json.Marshal(spec)
@@ -60,14 +60,16 @@ type Specification struct {
 }
 
 type ManagedMachine struct {
-	Name                     string `json:"name" yaml:"name"`
-	Source                   string `json:"source" yaml:"source"`
-	Username                 string `json:"username" yaml:"username"`
-	Password                 string `json:"password" yaml:"password"`
-	ContentChecksumsChecksum string `json:"verify_checksum" yaml:"verify_checksum" mapstructure:"verify_checksum"`
-	ArchiveChecksum          string `json:"checksum" yaml:"checksum" mapstructure:"checksum"`
-	Matcher                  string `json:"match" yaml:"match" mapstructure:"match"`
-	Governor                 string `json:"governor" yaml:"governor" mapstructure:"governor"`
+	Name                     string         `json:"name" yaml:"name"`
+	Source                   string         `json:"source" yaml:"source"`
+	Username                 string         `json:"username" yaml:"username"`
+	Password                 string         `json:"password" yaml:"password"`
+	ContentChecksumsChecksum string         `json:"verify_checksum" yaml:"verify_checksum" mapstructure:"verify_checksum"`
+	ArchiveChecksum          string         `json:"checksum" yaml:"checksum" mapstructure:"checksum"`
+	Matcher                  string         `json:"match" yaml:"match" mapstructure:"match"`
+	Governor                 string         `json:"governor" yaml:"governor" mapstructure:"governor"`
+	Version                  string         `json:"version,omitempty" yaml:"version,omitempty" mapstructure:"version"`
+	Rollout                  *RolloutPolicy `json:"rollout,omitempty" yaml:"rollout,omitempty" mapstructure:"rollout"`
 
 	Interval string `json:"-"`
 	Target   string `json:"-"`
@@ -94,6 +96,8 @@ type Watcher struct {
 	interval        time.Duration
 	previousRunTime time.Duration
 	previousManaged []*ManagedMachine
+	applied         map[string]*AppliedMachine
+	deferred        []string
 	properties      *Properties
 
 	lastWatch time.Time
@@ -186,6 +190,15 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 
 	purged := false
 	updated := false
+	applied := map[string]*AppliedMachine{}
+	deferred := []string{}
+
+	defer func() {
+		w.mu.Lock()
+		w.applied = applied
+		w.deferred = deferred
+		w.mu.Unlock()
+	}()
 
 	if w.properties.PurgeUnknown {
 		purged, err = w.purgeUnknownMachines(ctx, desired)
@@ -208,6 +221,15 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 			continue
 		}
 
+		if !m.IsRolloutTarget(w.machine.Identity()) {
+			w.Debugf("Node is not included in the rollout of machine %s, deferring", m.Name)
+			deferred = append(deferred, m.Name)
+			if a, err := w.appliedMachine(m.Name); err == nil {
+				applied[m.Name] = a
+			}
+			continue
+		}
+
 		targetDir := w.targetDirForManagerMachine(m.Name)
 		target := filepath.Join(targetDir, "machine.yaml")
 		spec, err := w.renderMachine(m)
@@ -231,6 +253,9 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 
 			if ok {
 				w.Debugf("Machine in %s has the correct content, continuing", target)
+				if a, err := w.appliedMachine(m.Name); err == nil {
+					applied[m.Name] = a
+				}
 				continue
 			} else {
 				w.Warnf("Machine in %s has incorrect content, updating", target)
@@ -243,7 +268,11 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 			}
 		}
 
-		w.Warnf("Deploying Choria Autonomous Agent %s from %s", m.Name, m.Source)
+		if m.Version != "" {
+			w.Warnf("Deploying Choria Autonomous Agent %s version %s from %s", m.Name, m.Version, m.Source)
+		} else {
+			w.Warnf("Deploying Choria Autonomous Agent %s from %s", m.Name, m.Source)
+		}
 
 		err = os.MkdirAll(targetDir, 0700)
 		if err != nil {
@@ -258,6 +287,12 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 			continue
 		}
 
+		a, err := w.recordApplied(m)
+		if err != nil {
+			w.Errorf("Could not record applied version for %s: %s", m.Name, err)
+		}
+		applied[m.Name] = a
+
 		updated = true
 	}
 
@@ -468,25 +503,19 @@ func (w *Watcher) desiredState() ([]*ManagedMachine, error) {
 		m.Interval = w.properties.MachineManageInterval.String()
 		m.Target = filepath.Dir(w.machine.Directory())
 
-		if m.Name == "" {
-			return nil, fmt.Errorf("name is required")
-		}
-
-		if m.Source == "" {
-			return nil, fmt.Errorf("source is required for %s", m.Name)
+		// a rollout governor limits concurrent deploys unless the machine has its own governor
+		if m.Governor == "" && m.Rollout != nil {
+			m.Governor = m.Rollout.Governor
 		}
 
-		if m.ArchiveChecksum == "" {
-			return nil, fmt.Errorf("checksum is required for %s", m.Name)
+		err = m.Validate()
+		if err != nil {
+			return nil, err
 		}
 
 		if m.Target == "" {
 			return nil, fmt.Errorf("could not determine target for managed machine for %s", m.Name)
 		}
-
-		if m.ContentChecksumsChecksum == "" {
-			return nil, fmt.Errorf("verify_checksum is required for %s", m.Name)
-		}
 	}
 
 	return desired, nil
@@ -562,6 +591,8 @@ func (w *Watcher) CurrentState() any {
 	s := &StateNotification{
 		Event:                   event.New(w.name, wtype, version, w.machine),
 		PreviousManagedMachines: []string{},
+		AppliedMachines:         map[string]string{},
+		DeferredMachines:        append([]string{}, w.deferred...),
 		PreviousOutcome:         stateNames[w.previous],
 		PreviousRunTime:         w.previousRunTime.Nanoseconds(),
 	}
@@ -570,5 +601,17 @@ func (w *Watcher) CurrentState() any {
 		s.PreviousManagedMachines = append(s.PreviousManagedMachines, m.Name)
 	}
 
+	for name, a := range w.applied {
+		if a == nil {
+			continue
+		}
+
+		if a.Version != "" {
+			s.AppliedMachines[name] = a.Version
+		} else {
+			s.AppliedMachines[name] = a.Checksum
+		}
+	}
+
 	return s
 }
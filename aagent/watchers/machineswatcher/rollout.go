@@ -0,0 +1,113 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machines
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// appliedFileName is the file in the manager directory recording the version that was deployed
+const appliedFileName = "applied.json"
+
+// RolloutPolicy limits which nodes deploy a managed machine, nodes outside of the rollout keep
+// any version they already have but will not deploy or update the machine
+type RolloutPolicy struct {
+	// Percent is the percentage of nodes, selected using a stable hash of their identity, that deploy the machine
+	Percent int `json:"percent" yaml:"percent"`
+
+	// Canaries are identities that always deploy the machine regardless of Percent
+	Canaries []string `json:"canaries,omitempty" yaml:"canaries,omitempty"`
+
+	// Governor limits how many nodes concurrently deploy the machine, used when the machine does not set a governor
+	Governor string `json:"governor,omitempty" yaml:"governor,omitempty"`
+}
+
+// AppliedMachine records the version of a managed machine deployed on a node
+type AppliedMachine struct {
+	Name     string    `json:"name"`
+	Version  string    `json:"version,omitempty"`
+	Checksum string    `json:"checksum"`
+	Applied  time.Time `json:"applied"`
+}
+
+// Validate checks that the policy is valid
+func (p *RolloutPolicy) Validate() error {
+	if p.Percent < 0 || p.Percent > 100 {
+		return fmt.Errorf("rollout percent must be between 0 and 100")
+	}
+
+	if p.Percent == 0 && len(p.Canaries) == 0 {
+		return fmt.Errorf("rollout requires a percent or canaries")
+	}
+
+	return nil
+}
+
+// Includes determines if a node should deploy machine based on its identity
+func (p *RolloutPolicy) Includes(machine string, identity string) bool {
+	for _, c := range p.Canaries {
+		if c == identity {
+			return true
+		}
+	}
+
+	return RolloutBucket(machine, identity) < p.Percent
+}
+
+// RolloutBucket is the stable bucket between 0 and 99 a node falls in for a specific machine, including the
+// machine name ensures the same nodes are not always first to receive every machine
+func RolloutBucket(machine string, identity string) int {
+	h := fnv.New32a()
+	h.Write([]byte(machine))
+	h.Write([]byte{0})
+	h.Write([]byte(identity))
+
+	return int(h.Sum32() % 100)
+}
+
+// IsRolloutTarget determines if this node should deploy the machine
+func (m *ManagedMachine) IsRolloutTarget(identity string) bool {
+	if m.Rollout == nil {
+		return true
+	}
+
+	return m.Rollout.Includes(m.Name, identity)
+}
+
+func (w *Watcher) appliedMachine(name string) (*AppliedMachine, error) {
+	j, err := os.ReadFile(filepath.Join(w.targetDirForManagerMachine(name), appliedFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	applied := &AppliedMachine{}
+	err = json.Unmarshal(j, applied)
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, nil
+}
+
+func (w *Watcher) recordApplied(m *ManagedMachine) (*AppliedMachine, error) {
+	applied := &AppliedMachine{
+		Name:     m.Name,
+		Version:  m.Version,
+		Checksum: m.ArchiveChecksum,
+		Applied:  time.Now().UTC(),
+	}
+
+	j, err := json.Marshal(applied)
+	if err != nil {
+		return nil, err
+	}
+
+	return applied, os.WriteFile(filepath.Join(w.targetDirForManagerMachine(m.Name), appliedFileName), j, 0600)
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machines
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/choria-io/go-choria/aagent/model"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AAgent/Watchers/MachinesWatcher/Rollout", func() {
+	Describe("Validate", func() {
+		It("Should validate the percent", func() {
+			Expect((&RolloutPolicy{Percent: -1}).Validate()).To(MatchError("rollout percent must be between 0 and 100"))
+			Expect((&RolloutPolicy{Percent: 101}).Validate()).To(MatchError("rollout percent must be between 0 and 100"))
+			Expect((&RolloutPolicy{}).Validate()).To(MatchError("rollout requires a percent or canaries"))
+			Expect((&RolloutPolicy{Canaries: []string{"c1"}}).Validate()).To(Succeed())
+			Expect((&RolloutPolicy{Percent: 50}).Validate()).To(Succeed())
+		})
+
+		It("Should be validated with the machine", func() {
+			m := &ManagedMachine{Name: "x", Source: "s", ArchiveChecksum: "c", ContentChecksumsChecksum: "v", Rollout: &RolloutPolicy{Percent: 200}}
+			Expect(m.Validate()).To(MatchError("invalid rollout for x: rollout percent must be between 0 and 100"))
+		})
+	})
+
+	Describe("Includes", func() {
+		It("Should be stable and proportional", func() {
+			p := &RolloutPolicy{Percent: 20}
+			included := 0
+			for i := 0; i < 1000; i++ {
+				id := fmt.Sprintf("node%d.example.net", i)
+				res := p.Includes("facts", id)
+				Expect(p.Includes("facts", id)).To(Equal(res))
+				if res {
+					included++
+				}
+			}
+
+			Expect(included).To(BeNumerically("~", 200, 50))
+		})
+
+		It("Should keep nodes included as the percent grows", func() {
+			for i := 0; i < 100; i++ {
+				id := fmt.Sprintf("node%d.example.net", i)
+				if (&RolloutPolicy{Percent: 10}).Includes("facts", id) {
+					Expect((&RolloutPolicy{Percent: 50}).Includes("facts", id)).To(BeTrue())
+				}
+			}
+		})
+
+		It("Should always include canaries and handle the extremes", func() {
+			Expect((&RolloutPolicy{Canaries: []string{"c1"}}).Includes("facts", "c1")).To(BeTrue())
+			Expect((&RolloutPolicy{Canaries: []string{"c1"}}).Includes("facts", "c2")).To(BeFalse())
+			Expect((&RolloutPolicy{Percent: 100}).Includes("facts", "c2")).To(BeTrue())
+			Expect((&ManagedMachine{Name: "facts"}).IsRolloutTarget("c2")).To(BeTrue())
+		})
+	})
+
+	Describe("Applied versions", func() {
+		var (
+			w       *Watcher
+			mockctl *gomock.Controller
+			td      string
+		)
+
+		BeforeEach(func() {
+			var err error
+			td, err = os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			mockctl = gomock.NewController(GinkgoT())
+
+			machine := model.NewMockMachine(mockctl)
+			machine.EXPECT().Directory().Return(filepath.Join(td, "manager")).AnyTimes()
+
+			wi, err := New(machine, "machines", nil, "", "", "1m", 0, map[string]any{"data_item": "spec"})
+			Expect(err).ToNot(HaveOccurred())
+			w = wi.(*Watcher)
+		})
+
+		AfterEach(func() {
+			mockctl.Finish()
+			os.RemoveAll(td)
+		})
+
+		It("Should record and load the applied version", func() {
+			Expect(os.MkdirAll(w.targetDirForManagerMachine("facts"), 0700)).To(Succeed())
+
+			_, err := w.appliedMachine("facts")
+			Expect(err).To(HaveOccurred())
+
+			_, err = w.recordApplied(&ManagedMachine{Name: "facts", Version: "1.2.3", ArchiveChecksum: "abc"})
+			Expect(err).ToNot(HaveOccurred())
+
+			a, err := w.appliedMachine("facts")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(a.Name).To(Equal("facts"))
+			Expect(a.Version).To(Equal("1.2.3"))
+			Expect(a.Checksum).To(Equal("abc"))
+			Expect(a.Applied).ToNot(BeZero())
+		})
+	})
+})
@@ -0,0 +1,287 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machines
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/choria-io/go-choria/build"
+)
+
+// ChecksumsFile is the file in every managed machine archive holding checksums of its contents
+const ChecksumsFile = "SHA256SUMS"
+
+// NewKeyPair creates a new hex encoded ed25519 key pair for signing specifications
+func NewKeyPair() (pub string, pri string, err error) {
+	pk, sk, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+
+	return hex.EncodeToString(pk), hex.EncodeToString(sk), nil
+}
+
+// ParseManagedMachines parses and validates a JSON encoded list of machines to manage
+func ParseManagedMachines(data []byte) ([]*ManagedMachine, error) {
+	var machines []*ManagedMachine
+
+	err := json.Unmarshal(data, &machines)
+	if err != nil {
+		return nil, fmt.Errorf("invalid machines specification: %s", err)
+	}
+
+	seen := map[string]bool{}
+	for _, m := range machines {
+		if m == nil {
+			return nil, fmt.Errorf("invalid machines specification: empty entry")
+		}
+
+		err = m.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		if seen[m.Name] {
+			return nil, fmt.Errorf("machine %s is specified multiple times", m.Name)
+		}
+		seen[m.Name] = true
+	}
+
+	return machines, nil
+}
+
+// PackSpecification creates a specification for a JSON encoded list of machines, signing it when a hex encoded ed25519 private key is given
+func PackSpecification(machines []byte, privateKey string) (*Specification, error) {
+	_, err := ParseManagedMachines(machines)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Specification{Machines: machines}
+
+	if privateKey == "" {
+		return spec, nil
+	}
+
+	sk, err := hex.DecodeString(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %s", err)
+	}
+
+	if len(sk) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("invalid private key: must be %d bytes", ed25519.PrivateKeySize)
+	}
+
+	spec.Signature = hex.EncodeToString(ed25519.Sign(sk, machines))
+
+	return spec, nil
+}
+
+// VerifySignature verifies the specification was signed by the private key matching the hex encoded ed25519 public key
+func (s *Specification) VerifySignature(publicKey string) error {
+	if s.Signature == "" {
+		return fmt.Errorf("specification is not signed")
+	}
+
+	pk, err := hex.DecodeString(publicKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %s", err)
+	}
+
+	if len(pk) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid public key: must be %d bytes", ed25519.PublicKeySize)
+	}
+
+	sig, err := hex.DecodeString(s.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+
+	if !ed25519.Verify(pk, s.Machines, sig) {
+		return fmt.Errorf("signature did not verify using public key %s", publicKey)
+	}
+
+	return nil
+}
+
+// ManagedMachines parses the machines held in the specification
+func (s *Specification) ManagedMachines() ([]*ManagedMachine, error) {
+	return ParseManagedMachines(s.Machines)
+}
+
+// Validate checks that all required properties are set
+func (m *ManagedMachine) Validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if m.Source == "" {
+		return fmt.Errorf("source is required for %s", m.Name)
+	}
+
+	if m.ArchiveChecksum == "" {
+		return fmt.Errorf("checksum is required for %s", m.Name)
+	}
+
+	if m.ContentChecksumsChecksum == "" {
+		return fmt.Errorf("verify_checksum is required for %s", m.Name)
+	}
+
+	if m.Rollout != nil {
+		err := m.Rollout.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid rollout for %s: %s", m.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// IsTemplated determines if any of the source or checksums are templates that are only resolved on the managed nodes
+func (m *ManagedMachine) IsTemplated() bool {
+	for _, v := range []string{m.Source, m.ArchiveChecksum, m.ContentChecksumsChecksum, m.Username, m.Password} {
+		if strings.Contains(v, "{{") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VerifyArchive downloads the archive and verifies it as the machines and archive watchers would
+func (m *ManagedMachine) VerifyArchive(ctx context.Context, client *http.Client) error {
+	if m.IsTemplated() {
+		return fmt.Errorf("cannot verify templated source or checksums")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, m.Source, nil)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+	req.Header.Add("User-Agent", fmt.Sprintf("Choria Machines Spec Verifier %s", build.Version))
+
+	if m.Username != "" {
+		req.SetBasicAuth(m.Username, m.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request failed: %s", resp.Status)
+	}
+
+	archive, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("request failed: %s", err)
+	}
+
+	return m.VerifyArchiveBytes(archive)
+}
+
+// VerifyArchiveBytes verifies a tar.gz archive against the checksums, the archive must
+// hold a single directory matching the machine name with a valid SHA256SUMS file inside
+func (m *ManagedMachine) VerifyArchiveBytes(archive []byte) error {
+	sum := sha256.Sum256(archive)
+	if hex.EncodeToString(sum[:]) != m.ArchiveChecksum {
+		return fmt.Errorf("archive checksum mismatch, got %s", hex.EncodeToString(sum[:]))
+	}
+
+	uncompressed, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return fmt.Errorf("unzip failed: %s", err)
+	}
+
+	files := map[string]string{}
+	var sums []byte
+
+	tr := tar.NewReader(uncompressed)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if name != m.Name && !strings.HasPrefix(name, m.Name+"/") {
+			return fmt.Errorf("archive contains %s outside of the %s directory", header.Name, m.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			continue
+		case tar.TypeReg:
+		default:
+			return fmt.Errorf("only regular files and directories are supported")
+		}
+
+		body, err := io.ReadAll(tr)
+		if err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(name, m.Name+"/")
+		if rel == ChecksumsFile {
+			sums = body
+			continue
+		}
+
+		fsum := sha256.Sum256(body)
+		files[rel] = hex.EncodeToString(fsum[:])
+	}
+
+	if sums == nil {
+		return fmt.Errorf("%s not found in the %s directory", ChecksumsFile, m.Name)
+	}
+
+	ssum := sha256.Sum256(sums)
+	if hex.EncodeToString(ssum[:]) != m.ContentChecksumsChecksum {
+		return fmt.Errorf("%s checksum mismatch, got %s", ChecksumsFile, hex.EncodeToString(ssum[:]))
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		parts := strings.Fields(line)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid line in %s: %s", ChecksumsFile, line)
+		}
+
+		file := path.Clean(strings.TrimPrefix(parts[1], "*"))
+		actual, ok := files[file]
+		if !ok {
+			return fmt.Errorf("%s listed in %s is not in the archive", file, ChecksumsFile)
+		}
+
+		if actual != parts[0] {
+			return fmt.Errorf("checksum mismatch for %s", file)
+		}
+	}
+
+	return scanner.Err()
+}
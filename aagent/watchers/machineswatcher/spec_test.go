@@ -0,0 +1,120 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package machines
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AAgent/Watchers/MachinesWatcher/Specification", func() {
+	sha := func(b []byte) string {
+		s := sha256.Sum256(b)
+		return hex.EncodeToString(s[:])
+	}
+
+	mkArchive := func(files map[string]string) []byte {
+		buf := bytes.NewBuffer(nil)
+		gz := gzip.NewWriter(buf)
+		tw := tar.NewWriter(gz)
+
+		for name, body := range files {
+			Expect(tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(body)), Typeflag: tar.TypeReg})).To(Succeed())
+			_, err := tw.Write([]byte(body))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(tw.Close()).To(Succeed())
+		Expect(gz.Close()).To(Succeed())
+
+		return buf.Bytes()
+	}
+
+	Describe("PackSpecification", func() {
+		It("Should validate the machines", func() {
+			_, err := PackSpecification([]byte(`[{"name":"x"}]`), "")
+			Expect(err).To(MatchError("source is required for x"))
+
+			_, err = PackSpecification([]byte(`[{"name":"x","source":"s","checksum":"c","verify_checksum":"v"},{"name":"x","source":"s","checksum":"c","verify_checksum":"v"}]`), "")
+			Expect(err).To(MatchError("machine x is specified multiple times"))
+		})
+
+		It("Should sign and verify specifications", func() {
+			pub, pri, err := NewKeyPair()
+			Expect(err).ToNot(HaveOccurred())
+
+			spec, err := PackSpecification([]byte(`[{"name":"x","source":"s","checksum":"c","verify_checksum":"v"}]`), pri)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spec.Signature).ToNot(BeEmpty())
+			Expect(spec.VerifySignature(pub)).To(Succeed())
+
+			other, _, err := NewKeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(spec.VerifySignature(other)).To(MatchError(fmt.Sprintf("signature did not verify using public key %s", other)))
+
+			spec.Signature = ""
+			Expect(spec.VerifySignature(pub)).To(MatchError("specification is not signed"))
+
+			managed, err := spec.ManagedMachines()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(managed).To(HaveLen(1))
+			Expect(managed[0].Name).To(Equal("x"))
+		})
+	})
+
+	Describe("VerifyArchiveBytes", func() {
+		var (
+			sums    string
+			archive []byte
+			m       *ManagedMachine
+		)
+
+		BeforeEach(func() {
+			sums = fmt.Sprintf("%s  machine.yaml\n", sha([]byte("name: x\n")))
+			archive = mkArchive(map[string]string{
+				"x/machine.yaml": "name: x\n",
+				"x/SHA256SUMS":   sums,
+			})
+			m = &ManagedMachine{Name: "x", ArchiveChecksum: sha(archive), ContentChecksumsChecksum: sha([]byte(sums))}
+		})
+
+		It("Should accept valid archives", func() {
+			Expect(m.VerifyArchiveBytes(archive)).To(Succeed())
+		})
+
+		It("Should detect checksum mismatches", func() {
+			m.ArchiveChecksum = "x"
+			Expect(m.VerifyArchiveBytes(archive)).To(MatchError(ContainSubstring("archive checksum mismatch")))
+
+			m.ArchiveChecksum = sha(archive)
+			m.ContentChecksumsChecksum = "x"
+			Expect(m.VerifyArchiveBytes(archive)).To(MatchError(ContainSubstring("SHA256SUMS checksum mismatch")))
+		})
+
+		It("Should detect modified content", func() {
+			archive = mkArchive(map[string]string{
+				"x/machine.yaml": "name: y\n",
+				"x/SHA256SUMS":   sums,
+			})
+			m.ArchiveChecksum = sha(archive)
+
+			Expect(m.VerifyArchiveBytes(archive)).To(MatchError("checksum mismatch for machine.yaml"))
+		})
+
+		It("Should require the machine directory", func() {
+			archive = mkArchive(map[string]string{"y/SHA256SUMS": sums})
+			m.ArchiveChecksum = sha(archive)
+
+			Expect(m.VerifyArchiveBytes(archive)).To(MatchError("archive contains y/SHA256SUMS outside of the x directory"))
+		})
+	})
+})
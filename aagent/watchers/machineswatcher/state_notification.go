@@ -7,6 +7,7 @@ package machines
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/choria-io/go-choria/aagent/watchers/event"
@@ -18,9 +19,11 @@ import (
 type StateNotification struct {
 	event.Event
 
-	PreviousManagedMachines []string `json:"machines"`
-	PreviousOutcome         string   `json:"previous_outcome"`
-	PreviousRunTime         int64    `json:"previous_run_time"`
+	PreviousManagedMachines []string          `json:"machines"`
+	AppliedMachines         map[string]string `json:"applied"`
+	DeferredMachines        []string          `json:"deferred"`
+	PreviousOutcome         string            `json:"previous_outcome"`
+	PreviousRunTime         int64             `json:"previous_run_time"`
 }
 
 // CloudEvent creates a CloudEvent from the state notification
@@ -35,5 +38,19 @@ func (s *StateNotification) JSON() ([]byte, error) {
 
 // String is a string representation of the notification suitable for printing
 func (s *StateNotification) String() string {
-	return fmt.Sprintf("%s %s#%s machines: %s, previous: %s ran: %.3fs", s.Identity, s.Machine, s.Name, strings.Join(s.PreviousManagedMachines, ", "), s.PreviousOutcome, float64(s.PreviousRunTime)/1000000000)
+	var applied []string
+	for name, v := range s.AppliedMachines {
+		applied = append(applied, fmt.Sprintf("%s=%s", name, v))
+	}
+	sort.Strings(applied)
+
+	msg := fmt.Sprintf("%s %s#%s machines: %s, previous: %s ran: %.3fs", s.Identity, s.Machine, s.Name, strings.Join(s.PreviousManagedMachines, ", "), s.PreviousOutcome, float64(s.PreviousRunTime)/1000000000)
+	if len(applied) > 0 {
+		msg = fmt.Sprintf("%s applied: %s", msg, strings.Join(applied, ", "))
+	}
+	if len(s.DeferredMachines) > 0 {
+		msg = fmt.Sprintf("%s deferred: %s", msg, strings.Join(s.DeferredMachines, ", "))
+	}
+
+	return msg
 }
@@ -37,6 +37,7 @@ var _ = Describe("MetricWatcher", func() {
 	BeforeEach(func() {
 		mockctl = gomock.NewController(GinkgoT())
 		mockMachine = model.NewMockMachine(mockctl)
+		mockMachine.EXPECT().IsPaused().Return(false).AnyTimes()
 
 		td, err = os.MkdirTemp("", "")
 		Expect(err).ToNot(HaveOccurred())
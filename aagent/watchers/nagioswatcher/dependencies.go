@@ -0,0 +1,111 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package nagioswatcher
+
+import (
+	"sort"
+)
+
+var (
+	// checkDependencies are the checks each check depends on, keyed by machine name
+	checkDependencies = map[string][]string{}
+
+	// checkSuppressed are checks currently suppressed due to failed dependencies
+	checkSuppressed = map[string]bool{}
+
+	// checkPending are checks that have not completed a run since they were created, their initial UNKNOWN state is not a failure
+	checkPending = map[string]bool{}
+)
+
+func registerDependencies(name string, deps []string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(deps) == 0 {
+		delete(checkDependencies, name)
+		return
+	}
+
+	checkDependencies[name] = deps
+}
+
+func deregisterDependencies(name string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	delete(checkDependencies, name)
+	delete(checkSuppressed, name)
+	delete(checkPending, name)
+}
+
+func setPending(name string, pending bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if pending {
+		checkPending[name] = true
+	} else {
+		delete(checkPending, name)
+	}
+}
+
+func setSuppressed(name string, suppressed bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if suppressed {
+		checkSuppressed[name] = true
+	} else {
+		delete(checkSuppressed, name)
+	}
+}
+
+// failedDependencies finds the checks that cause name to be suppressed, parents that are themselves
+// suppressed are followed to find the root cause and dependency cycles are ignored
+func failedDependencies(name string) []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	failed := map[string]bool{}
+	walkDependencies(name, map[string]bool{name: true}, failed)
+
+	res := []string{}
+	for f := range failed {
+		res = append(res, f)
+	}
+	sort.Strings(res)
+
+	return res
+}
+
+// lock should be held by caller
+func walkDependencies(name string, visited map[string]bool, failed map[string]bool) {
+	for _, dep := range checkDependencies[name] {
+		if visited[dep] {
+			continue
+		}
+		visited[dep] = true
+
+		if checkSuppressed[dep] {
+			walkDependencies(dep, visited, failed)
+			continue
+		}
+
+		// checks that did not run yet, like after a restart, do not suppress their dependents
+		if checkPending[dep] {
+			continue
+		}
+
+		state, ok := promStates[dep]
+		if !ok {
+			continue
+		}
+
+		switch state {
+		case WARNING, CRITICAL, UNKNOWN:
+			failed[dep] = true
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package nagioswatcher
+
+import (
+	"time"
+)
+
+const (
+	// maxHistoryTransitions is the number of state transitions kept in the check history
+	maxHistoryTransitions = 50
+
+	// maxHistoryOutputs is the number of check outputs kept in the check history
+	maxHistoryOutputs = 10
+
+	// defaultFlapWindow is the window flapping is detected in when only a threshold is set
+	defaultFlapWindow = 30 * time.Minute
+)
+
+// StateTransition is a change in check state
+type StateTransition struct {
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	Time     time.Time `json:"time"`
+	Duration float64   `json:"duration"`
+}
+
+// CheckOutput is the result of a single check
+type CheckOutput struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+	Output string    `json:"output"`
+}
+
+// CheckHistory is the bounded history of a check kept on the node
+type CheckHistory struct {
+	// State is the current state of the check
+	State string `json:"state"`
+	// Since is when the check entered the current state
+	Since time.Time `json:"since"`
+	// Transitions are the most recent state changes, oldest first
+	Transitions []*StateTransition `json:"transitions"`
+	// StateDurations are the seconds spent in each state, excluding the current state
+	StateDurations map[string]float64 `json:"state_durations"`
+	// Outputs are the most recent check outputs, oldest first
+	Outputs []*CheckOutput `json:"outputs"`
+	// Flapping indicates the check changed state more than the flap threshold within the flap window
+	Flapping bool `json:"flapping"`
+	// FlapCount is the number of state changes seen within the flap window
+	FlapCount int `json:"flap_count"`
+}
+
+// History retrieves a copy of the check history
+func (w *Watcher) History() any {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	h := &CheckHistory{
+		State:          w.checkHistory.State,
+		Since:          w.checkHistory.Since,
+		Transitions:    make([]*StateTransition, len(w.checkHistory.Transitions)),
+		StateDurations: make(map[string]float64, len(w.checkHistory.StateDurations)),
+		Outputs:        make([]*CheckOutput, len(w.checkHistory.Outputs)),
+		Flapping:       w.checkHistory.Flapping,
+		FlapCount:      w.checkHistory.FlapCount,
+	}
+
+	for i, t := range w.checkHistory.Transitions {
+		c := *t
+		h.Transitions[i] = &c
+	}
+	for i, o := range w.checkHistory.Outputs {
+		c := *o
+		h.Outputs[i] = &c
+	}
+	for k, v := range w.checkHistory.StateDurations {
+		h.StateDurations[k] = v
+	}
+
+	return h
+}
+
+// recordHistory records the outcome of a check and updates flapping state, returns true when the
+// state notification for this check should be published
+//
+// lock should be held by caller
+func (w *Watcher) recordHistory(now time.Time, s State, output string) bool {
+	h := w.checkHistory
+	state := stateNames[s]
+
+	h.Outputs = append(h.Outputs, &CheckOutput{Time: now, Status: state, Output: output})
+	if len(h.Outputs) > maxHistoryOutputs {
+		h.Outputs = h.Outputs[len(h.Outputs)-maxHistoryOutputs:]
+	}
+
+	if h.State != state {
+		if h.State != "" {
+			duration := now.Sub(h.Since).Seconds()
+			h.StateDurations[h.State] += duration
+			h.Transitions = append(h.Transitions, &StateTransition{From: h.State, To: state, Time: now, Duration: duration})
+			if len(h.Transitions) > maxHistoryTransitions {
+				h.Transitions = h.Transitions[len(h.Transitions)-maxHistoryTransitions:]
+			}
+		}
+
+		h.State = state
+		h.Since = now
+	}
+
+	if w.properties.FlapThreshold == 0 {
+		return true
+	}
+
+	wasFlapping := h.Flapping
+
+	h.FlapCount = 0
+	for _, t := range h.Transitions {
+		if now.Sub(t.Time) <= w.properties.FlapWindow {
+			h.FlapCount++
+		}
+	}
+	h.Flapping = h.FlapCount >= w.properties.FlapThreshold
+
+	switch {
+	case h.Flapping && !wasFlapping:
+		w.Warnf("Check is flapping with %d state changes in %v, suppressing notifications", h.FlapCount, w.properties.FlapWindow)
+		return true
+
+	case !h.Flapping && wasFlapping:
+		w.Infof("Check stopped flapping, resuming notifications")
+		return true
+
+	default:
+		return !h.Flapping
+	}
+}
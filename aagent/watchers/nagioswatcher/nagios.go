@@ -82,6 +82,13 @@ type properties struct {
 	LastMessage time.Duration `mapstructure:"last_message"`
 	CertExpiry  time.Duration `mapstructure:"pubcert_expire"`
 	TokenExpiry time.Duration `mapstructure:"token_expire"`
+
+	// FlapThreshold is how many state changes within FlapWindow marks a check as flapping, 0 disables detection
+	FlapThreshold int           `mapstructure:"flap_threshold"`
+	FlapWindow    time.Duration `mapstructure:"flap_window"`
+
+	// Dependencies are other checks that must be OK for this check to run and notify
+	Dependencies []string
 }
 
 type Execution struct {
@@ -104,7 +111,9 @@ type Watcher struct {
 	previousPlugin   string
 	previous         State
 	force            bool
+	suppressedBy     []string
 	history          []*Execution
+	checkHistory     *CheckHistory
 	machineName      string
 	textFileDir      string
 
@@ -122,7 +131,12 @@ func New(machine model.Machine, name string, states []string, failEvent string,
 		machine:     machine,
 		previous:    NOTCHECKED,
 		history:     []*Execution{},
-		mu:          &sync.Mutex{},
+		checkHistory: &CheckHistory{
+			Transitions:    []*StateTransition{},
+			StateDurations: map[string]float64{},
+			Outputs:        []*CheckOutput{},
+		},
+		mu: &sync.Mutex{},
 	}
 
 	nw.Watcher, err = watcher.NewWatcher(name, wtype, ai, states, machine, failEvent, successEvent)
@@ -146,11 +160,26 @@ func New(machine model.Machine, name string, states []string, failEvent string,
 		}
 	}
 
+	setPromType(nw.machineName, nw.checkType())
 	updatePromState(nw.machineName, UNKNOWN, machine.TextFileDirectory(), nw)
+	setPending(nw.machineName, true)
+	registerDependencies(nw.machineName, nw.properties.Dependencies)
 
 	return nw, err
 }
 
+// checkType is the kind of check being run, one of builtin, goss or plugin
+func (w *Watcher) checkType() string {
+	switch {
+	case w.properties.Plugin != "":
+		return "plugin"
+	case w.properties.Builtin == "goss":
+		return "goss"
+	default:
+		return "builtin"
+	}
+}
+
 // Delete stops the watcher and remove it from the prom state after the check was removed from disk
 func (w *Watcher) Delete() {
 	w.mu.Lock()
@@ -159,6 +188,7 @@ func (w *Watcher) Delete() {
 	// suppress next check and set state to unknown
 	w.previousCheck = time.Now()
 	deletePromState(w.machineName, w.textFileDir, w)
+	deregisterDependencies(w.machineName)
 }
 
 func (w *Watcher) CurrentState() any {
@@ -176,6 +206,16 @@ func (w *Watcher) CurrentState() any {
 		History:     w.history,
 		Annotations: w.properties.Annotations,
 		CheckTime:   w.previousCheck.Unix(),
+		Flapping:    w.checkHistory.Flapping,
+	}
+
+	if len(w.properties.Dependencies) > 0 {
+		s.Dependencies = w.properties.Dependencies
+	}
+
+	if len(w.suppressedBy) > 0 {
+		s.Suppressed = true
+		s.SuppressedBy = w.suppressedBy
 	}
 
 	if !w.previousCheck.IsZero() {
@@ -206,6 +246,20 @@ func (w *Watcher) validate() error {
 		w.properties.Timeout = time.Second
 	}
 
+	for _, d := range w.properties.Dependencies {
+		if d == w.machineName {
+			return fmt.Errorf("check cannot depend on itself")
+		}
+	}
+
+	if w.properties.FlapThreshold < 0 {
+		return fmt.Errorf("flap_threshold cannot be negative")
+	}
+
+	if w.properties.FlapThreshold > 0 && w.properties.FlapWindow == 0 {
+		w.properties.FlapWindow = defaultFlapWindow
+	}
+
 	return nil
 }
 
@@ -247,6 +301,7 @@ func (w *Watcher) NotifyStateChance() {
 	w.previous = s
 	w.mu.Unlock()
 
+	setPending(w.machineName, false)
 	err := updatePromState(w.machineName, s, w.textFileDir, w)
 	if err != nil {
 		w.Errorf("Could not update prometheus: %s", err)
@@ -333,15 +388,23 @@ func (w *Watcher) handleCheck(start time.Time, s State, external bool, err error
 	}
 	w.history = append(w.history, &Execution{Executed: start, Status: int(s), PerfData: w.previousPerfData})
 
+	notify := w.recordHistory(start, s, w.previousOutput)
+	suppressed := len(w.suppressedBy) > 0
+	perfData := w.previousPerfData
+	runTime := w.previousRunTime
+
 	w.mu.Unlock()
 
-	// dont notify if we are externally transitioning because probably notifications were already sent
-	if !external {
+	// dont notify if we are externally transitioning because probably notifications were already sent,
+	// flapping checks only notify when they start and stop flapping and suppressed checks never notify
+	if !external && notify && !suppressed {
 		w.NotifyWatcherState(w.CurrentState())
 	}
 
 	w.Debugf("Notifying prometheus")
 
+	setPromResult(w.machineName, perfData, runTime)
+	setPending(w.machineName, false)
 	err = updatePromState(w.machineName, s, w.textFileDir, w)
 	if err != nil {
 		w.Errorf("Could not update prometheus: %s", err)
@@ -495,7 +558,17 @@ func (w *Watcher) watch(ctx context.Context) (state State, err error) {
 
 	var output string
 
+	failed := failedDependencies(w.machineName)
+	w.mu.Lock()
+	w.suppressedBy = failed
+	w.mu.Unlock()
+	setSuppressed(w.machineName, len(failed) > 0)
+
 	switch {
+	case len(failed) > 0:
+		w.Infof("Suppressing check due to failed dependencies %s", strings.Join(failed, ", "))
+		state = UNKNOWN
+		output = fmt.Sprintf("UNKNOWN: suppressed by failed dependencies %s", strings.Join(failed, ", "))
 	case w.properties.Plugin != "":
 		state, output, err = w.watchUsingPlugin(ctx)
 	case w.properties.Builtin != "":
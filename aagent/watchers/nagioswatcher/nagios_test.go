@@ -5,10 +5,12 @@
 package nagioswatcher
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,6 +18,7 @@ import (
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/choria-io/go-choria/aagent/util"
 	"github.com/choria-io/go-choria/statistics"
@@ -39,6 +42,7 @@ var _ = Describe("NagiosWatcher", func() {
 	BeforeEach(func() {
 		mockctl = gomock.NewController(GinkgoT())
 		mockMachine = model.NewMockMachine(mockctl)
+		mockMachine.EXPECT().IsPaused().Return(false).AnyTimes()
 
 		td, err = os.MkdirTemp("", "")
 		Expect(err).ToNot(HaveOccurred())
@@ -198,6 +202,224 @@ var _ = Describe("NagiosWatcher", func() {
 		})
 	})
 
+	Describe("recordHistory", func() {
+		BeforeEach(func() {
+			mockMachine.EXPECT().Warnf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		})
+
+		It("Should record transitions, durations and outputs", func() {
+			start := time.Now()
+			Expect(watch.recordHistory(start, OK, "ok 1")).To(BeTrue())
+			Expect(watch.recordHistory(start.Add(time.Minute), OK, "ok 2")).To(BeTrue())
+			Expect(watch.recordHistory(start.Add(2*time.Minute), CRITICAL, "crit")).To(BeTrue())
+
+			h := watch.History().(*CheckHistory)
+			Expect(h.State).To(Equal("CRITICAL"))
+			Expect(h.Since).To(Equal(start.Add(2 * time.Minute)))
+			Expect(h.Transitions).To(HaveLen(1))
+			Expect(h.Transitions[0].From).To(Equal("OK"))
+			Expect(h.Transitions[0].To).To(Equal("CRITICAL"))
+			Expect(h.Transitions[0].Duration).To(Equal(120.0))
+			Expect(h.StateDurations).To(Equal(map[string]float64{"OK": 120}))
+			Expect(h.Outputs).To(HaveLen(3))
+			Expect(h.Outputs[2].Output).To(Equal("crit"))
+			Expect(h.Flapping).To(BeFalse())
+
+			for i := 0; i < 20; i++ {
+				watch.recordHistory(start.Add(time.Duration(3+i)*time.Minute), OK, "ok")
+			}
+			Expect(watch.History().(*CheckHistory).Outputs).To(HaveLen(maxHistoryOutputs))
+		})
+
+		It("Should detect flapping and suppress notifications", func() {
+			watch.properties.FlapThreshold = 3
+			watch.properties.FlapWindow = time.Hour
+
+			start := time.Now()
+			Expect(watch.recordHistory(start, OK, "")).To(BeTrue())
+			Expect(watch.recordHistory(start.Add(time.Minute), CRITICAL, "")).To(BeTrue())
+			Expect(watch.recordHistory(start.Add(2*time.Minute), OK, "")).To(BeTrue())
+
+			// third change starts flapping and notifies once
+			Expect(watch.recordHistory(start.Add(3*time.Minute), CRITICAL, "")).To(BeTrue())
+			Expect(watch.CurrentState().(*StateNotification).Flapping).To(BeTrue())
+
+			// further changes are suppressed
+			Expect(watch.recordHistory(start.Add(4*time.Minute), OK, "")).To(BeFalse())
+			Expect(watch.recordHistory(start.Add(5*time.Minute), OK, "")).To(BeFalse())
+
+			// once the changes leave the window flapping stops and notifies
+			Expect(watch.recordHistory(start.Add(2*time.Hour), OK, "")).To(BeTrue())
+			h := watch.History().(*CheckHistory)
+			Expect(h.Flapping).To(BeFalse())
+			Expect(h.FlapCount).To(Equal(0))
+		})
+
+		It("Should default the flap window", func() {
+			watch.properties = nil
+			Expect(watch.setProperties(map[string]any{"plugin": "cmd", "flap_threshold": 5})).To(Succeed())
+			Expect(watch.properties.FlapWindow).To(Equal(defaultFlapWindow))
+		})
+	})
+
+	Describe("Dependencies", func() {
+		BeforeEach(func() {
+			mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		})
+
+		AfterEach(func() {
+			for _, n := range []string{"net", "http", "app", "a", "b"} {
+				deregisterDependencies(n)
+				deletePromState(n, "", watch)
+			}
+		})
+
+		It("Should not allow self dependencies", func() {
+			watch.properties = nil
+			Expect(watch.setProperties(map[string]any{"plugin": "cmd", "dependencies": []string{"nagios"}})).To(MatchError("check cannot depend on itself"))
+		})
+
+		It("Should find failed dependencies transitively", func() {
+			registerDependencies("http", []string{"net"})
+			registerDependencies("app", []string{"http"})
+			updatePromState("net", OK, "", watch)
+			updatePromState("http", OK, "", watch)
+
+			Expect(failedDependencies("app")).To(BeEmpty())
+
+			updatePromState("net", CRITICAL, "", watch)
+			Expect(failedDependencies("http")).To(Equal([]string{"net"}))
+
+			// http is suppressed so app is suppressed by the root cause
+			setSuppressed("http", true)
+			updatePromState("http", UNKNOWN, "", watch)
+			Expect(failedDependencies("app")).To(Equal([]string{"net"}))
+
+			updatePromState("net", OK, "", watch)
+			setSuppressed("http", false)
+			updatePromState("http", OK, "", watch)
+			Expect(failedDependencies("app")).To(BeEmpty())
+		})
+
+		It("Should not treat checks that did not run yet as failed", func() {
+			netMachine := model.NewMockMachine(mockctl)
+			netMachine.EXPECT().Name().Return("net").AnyTimes()
+			netMachine.EXPECT().TextFileDirectory().Return(td).AnyTimes()
+			netMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			wi, err := New(netMachine, "check", []string{"always"}, "fail", "success", "1s", time.Second, map[string]any{"plugin": "/bin/sh"})
+			Expect(err).ToNot(HaveOccurred())
+			net := wi.(*Watcher)
+
+			registerDependencies("app", []string{"net"})
+			Expect(failedDependencies("app")).To(BeEmpty())
+
+			netMachine.EXPECT().State().Return("CRITICAL")
+			net.NotifyStateChance()
+			Expect(failedDependencies("app")).To(Equal([]string{"net"}))
+		})
+
+		It("Should ignore unknown dependencies and cycles", func() {
+			registerDependencies("a", []string{"b", "missing"})
+			registerDependencies("b", []string{"a"})
+			setSuppressed("b", true)
+			updatePromState("b", UNKNOWN, "", watch)
+
+			Expect(failedDependencies("a")).To(BeEmpty())
+		})
+
+		It("Should suppress checks with failed dependencies", func() {
+			mockMachine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			mockMachine.EXPECT().State().Return("always").AnyTimes()
+			registerDependencies("nagios", []string{"net"})
+			defer deregisterDependencies("nagios")
+			updatePromState("net", CRITICAL, "", watch)
+
+			watch.properties.Dependencies = []string{"net"}
+			watch.previousCheck = time.Time{}
+
+			state, err := watch.watch(context.Background())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(state).To(Equal(UNKNOWN))
+			Expect(watch.previousOutput).To(Equal("UNKNOWN: suppressed by failed dependencies net"))
+
+			cs := watch.CurrentState().(*StateNotification)
+			Expect(cs.Suppressed).To(BeTrue())
+			Expect(cs.SuppressedBy).To(Equal([]string{"net"}))
+			Expect(cs.Dependencies).To(Equal([]string{"net"}))
+		})
+	})
+
+	Describe("Prometheus", func() {
+		BeforeEach(func() {
+			mockMachine.EXPECT().Debugf(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		})
+
+		AfterEach(func() {
+			deletePromState("disk", "", watch)
+		})
+
+		It("Should expose check states, perf data and run times", func() {
+			deletePromState("nagios", "", watch)
+			setPromType("disk", "plugin")
+			updatePromState("disk", WARNING, "", watch)
+			setPromResult("disk", util.ParsePerfData("WARNING|used=90%;; free=10MB;; used=91%;;"), 1500*time.Millisecond)
+
+			expected := `
+# HELP choria_machine_nagios_watcher_info Choria Nagios Check Information
+# TYPE choria_machine_nagios_watcher_info gauge
+choria_machine_nagios_watcher_info{name="disk",type="plugin"} 1
+# HELP choria_machine_nagios_watcher_checks_count Choria Nagios Check Count
+# TYPE choria_machine_nagios_watcher_checks_count counter
+choria_machine_nagios_watcher_checks_count{name="disk"} 1
+# HELP choria_machine_nagios_watcher_perfdata Choria Nagios Check Performance Data
+# TYPE choria_machine_nagios_watcher_perfdata gauge
+choria_machine_nagios_watcher_perfdata{label="free",name="disk",unit="MB"} 10
+choria_machine_nagios_watcher_perfdata{label="used",name="disk",unit="%"} 91
+# HELP choria_machine_nagios_watcher_run_time_seconds Choria Nagios Check Run Time
+# TYPE choria_machine_nagios_watcher_run_time_seconds gauge
+choria_machine_nagios_watcher_run_time_seconds{name="disk"} 1.5
+# HELP choria_machine_nagios_watcher_status Choria Nagios Check Status
+# TYPE choria_machine_nagios_watcher_status gauge
+choria_machine_nagios_watcher_status{name="disk",status="WARNING"} 1
+`
+			Expect(testutil.CollectAndCompare(&collector{}, strings.NewReader(expected),
+				"choria_machine_nagios_watcher_checks_count",
+				"choria_machine_nagios_watcher_info",
+				"choria_machine_nagios_watcher_perfdata",
+				"choria_machine_nagios_watcher_run_time_seconds",
+				"choria_machine_nagios_watcher_status",
+			)).To(Succeed())
+		})
+
+		It("Should write the same metrics to the text file directory", func() {
+			td, err := os.MkdirTemp("", "")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.RemoveAll(td)
+
+			setPromType("disk", "goss")
+			setPromResult("disk", util.ParsePerfData("OK|used=10%;;"), time.Second)
+			Expect(updatePromState("disk", OK, td, watch)).To(Succeed())
+
+			out, err := os.ReadFile(filepath.Join(td, "choria_machine_nagios_watcher_status.prom"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(out)).To(ContainSubstring(`choria_machine_nagios_watcher_status{name="disk",status="OK"} 0`))
+			Expect(string(out)).To(ContainSubstring(`choria_machine_nagios_watcher_perfdata{name="disk",label="used",unit="%"} 10`))
+			Expect(string(out)).To(ContainSubstring(`choria_machine_nagios_watcher_run_time_seconds{name="disk"} 1.000000`))
+			Expect(string(out)).To(ContainSubstring(`choria_machine_nagios_watcher_info{name="disk",type="goss"} 1`))
+		})
+
+		It("Should determine the check type", func() {
+			watch.properties = &properties{Plugin: "/bin/true"}
+			Expect(watch.checkType()).To(Equal("plugin"))
+			watch.properties = &properties{Builtin: "goss"}
+			Expect(watch.checkType()).To(Equal("goss"))
+			watch.properties = &properties{Builtin: "heartbeat"}
+			Expect(watch.checkType()).To(Equal("builtin"))
+		})
+	})
+
 	Describe("CurrentState", func() {
 		It("Should be a valid state", func() {
 			cs := watch.CurrentState()
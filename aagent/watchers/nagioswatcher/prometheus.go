@@ -8,24 +8,44 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
-	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/aagent/util"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 var promStates map[string]State
 var promTimes map[string]time.Time
 var promChecks map[string]int
+var promTypes map[string]string
+var promPerfData map[string][]util.PerfData
+var promRunTimes map[string]time.Duration
 var startTime int64
 
 var mu sync.Mutex
+var registerOnce sync.Once
+
+var (
+	startTimeDesc   = prometheus.NewDesc("choria_machine_nagios_start_time", "Time the Choria Machine subsystem started in unix seconds", nil, nil)
+	statusDesc      = prometheus.NewDesc("choria_machine_nagios_watcher_status", "Choria Nagios Check Status", []string{"name", "status"}, nil)
+	lastRunDesc     = prometheus.NewDesc("choria_machine_nagios_watcher_last_run_seconds", "Choria Nagios Check Time", []string{"name"}, nil)
+	checksCountDesc = prometheus.NewDesc("choria_machine_nagios_watcher_checks_count", "Choria Nagios Check Count", []string{"name"}, nil)
+	runTimeDesc     = prometheus.NewDesc("choria_machine_nagios_watcher_run_time_seconds", "Choria Nagios Check Run Time", []string{"name"}, nil)
+	perfDataDesc    = prometheus.NewDesc("choria_machine_nagios_watcher_perfdata", "Choria Nagios Check Performance Data", []string{"name", "label", "unit"}, nil)
+	infoDesc        = prometheus.NewDesc("choria_machine_nagios_watcher_info", "Choria Nagios Check Information", []string{"name", "type"}, nil)
+)
 
 func init() {
 	mu.Lock()
 	promTimes = make(map[string]time.Time)
 	promStates = make(map[string]State)
 	promChecks = make(map[string]int)
+	promTypes = make(map[string]string)
+	promPerfData = make(map[string][]util.PerfData)
+	promRunTimes = make(map[string]time.Duration)
 	startTime = time.Now().Unix()
 	mu.Unlock()
 }
@@ -34,6 +54,88 @@ type logger interface {
 	Debugf(format string, args ...any)
 }
 
+// RegisterPrometheus exposes the state of all nagios checks using the default Prometheus registry
+func RegisterPrometheus() error {
+	var err error
+
+	registerOnce.Do(func() {
+		err = prometheus.Register(&collector{})
+	})
+
+	return err
+}
+
+type collector struct{}
+
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- startTimeDesc
+	ch <- statusDesc
+	ch <- lastRunDesc
+	ch <- checksCountDesc
+	ch <- runTimeDesc
+	ch <- perfDataDesc
+	ch <- infoDesc
+}
+
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	ch <- prometheus.MustNewConstMetric(startTimeDesc, prometheus.GaugeValue, float64(startTime))
+
+	for name, s := range promStates {
+		if reportableState(s) {
+			ch <- prometheus.MustNewConstMetric(statusDesc, prometheus.GaugeValue, float64(s), name, stateNames[s])
+		}
+	}
+
+	for name, t := range promTimes {
+		ch <- prometheus.MustNewConstMetric(lastRunDesc, prometheus.GaugeValue, float64(t.Unix()), name)
+	}
+
+	for name, c := range promChecks {
+		ch <- prometheus.MustNewConstMetric(checksCountDesc, prometheus.CounterValue, float64(c), name)
+	}
+
+	for name, d := range promRunTimes {
+		ch <- prometheus.MustNewConstMetric(runTimeDesc, prometheus.GaugeValue, d.Seconds(), name)
+	}
+
+	for name, pd := range promPerfData {
+		for _, p := range uniquePerfData(pd) {
+			ch <- prometheus.MustNewConstMetric(perfDataDesc, prometheus.GaugeValue, p.Value, name, p.Label, p.Unit)
+		}
+	}
+
+	for name, kind := range promTypes {
+		ch <- prometheus.MustNewConstMetric(infoDesc, prometheus.GaugeValue, 1, name, kind)
+	}
+}
+
+func reportableState(s State) bool {
+	return s == UNKNOWN || s == OK || s == CRITICAL || s == WARNING
+}
+
+// uniquePerfData removes duplicate labels from perf data, the last value wins,
+// as duplicate label sets are not allowed in a single prometheus metric
+func uniquePerfData(pd []util.PerfData) []util.PerfData {
+	seen := make(map[string]int)
+	res := []util.PerfData{}
+
+	for _, p := range pd {
+		idx, ok := seen[p.Label]
+		if ok {
+			res[idx] = p
+			continue
+		}
+
+		seen[p.Label] = len(res)
+		res = append(res, p)
+	}
+
+	return res
+}
+
 func updatePromState(name string, state State, dir string, log logger) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -50,6 +152,23 @@ func updatePromState(name string, state State, dir string, log logger) error {
 	return savePromState(dir, log)
 }
 
+// setPromType records the kind of check, one of builtin, goss or plugin
+func setPromType(name string, kind string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	promTypes[name] = kind
+}
+
+// setPromResult records the performance data and run time of the most recent check
+func setPromResult(name string, perfData []util.PerfData, runTime time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	promPerfData[name] = perfData
+	promRunTimes[name] = runTime
+}
+
 func deletePromState(name string, dir string, log logger) error {
 	mu.Lock()
 	defer mu.Unlock()
@@ -57,6 +176,9 @@ func deletePromState(name string, dir string, log logger) error {
 	delete(promStates, name)
 	delete(promTimes, name)
 	delete(promChecks, name)
+	delete(promTypes, name)
+	delete(promPerfData, name)
+	delete(promRunTimes, name)
 
 	return savePromState(dir, log)
 }
@@ -68,7 +190,7 @@ func savePromState(td string, log logger) error {
 		return nil
 	}
 
-	if !util.FileIsDir(td) {
+	if !iu.FileIsDir(td) {
 		log.Debugf("%q is not a directory", td)
 		return nil
 	}
@@ -78,28 +200,67 @@ func savePromState(td string, log logger) error {
 		return fmt.Errorf("failed to create prometheus metric in %q: %s", td, err)
 	}
 
+	names := make([]string, 0, len(promStates))
+	for name := range promStates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
 	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_start_time Time the Choria Machine subsystem started in unix seconds\n")
 	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_start_time gauge\n")
 	fmt.Fprintf(tfile, "choria_machine_nagios_start_time %d\n", startTime)
 
 	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_status Choria Nagios Check Status\n")
 	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_status gauge\n")
-	for name, s := range promStates {
-		if s == UNKNOWN || s == OK || s == CRITICAL || s == WARNING {
+	for _, name := range names {
+		s := promStates[name]
+		if reportableState(s) {
 			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_status{name=%q,status=%q} %d\n", name, stateNames[s], int(s))
 		}
 	}
 
 	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_last_run_seconds Choria Nagios Check Time\n")
 	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_last_run_seconds gauge\n")
-	for name, t := range promTimes {
-		fmt.Fprintf(tfile, "choria_machine_nagios_watcher_last_run_seconds{name=%q} %d\n", name, t.Unix())
+	for _, name := range names {
+		t, ok := promTimes[name]
+		if ok {
+			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_last_run_seconds{name=%q} %d\n", name, t.Unix())
+		}
 	}
 
 	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_checks_count Choria Nagios Check Count\n")
 	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_checks_count counter\n")
-	for name, c := range promChecks {
-		fmt.Fprintf(tfile, "choria_machine_nagios_watcher_checks_count{name=%q} %d\n", name, c)
+	for _, name := range names {
+		c, ok := promChecks[name]
+		if ok {
+			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_checks_count{name=%q} %d\n", name, c)
+		}
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_run_time_seconds Choria Nagios Check Run Time\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_run_time_seconds gauge\n")
+	for _, name := range names {
+		d, ok := promRunTimes[name]
+		if ok {
+			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_run_time_seconds{name=%q} %f\n", name, d.Seconds())
+		}
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_perfdata Choria Nagios Check Performance Data\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_perfdata gauge\n")
+	for _, name := range names {
+		for _, p := range uniquePerfData(promPerfData[name]) {
+			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_perfdata{name=%q,label=%q,unit=%q} %v\n", name, p.Label, p.Unit, p.Value)
+		}
+	}
+
+	fmt.Fprintf(tfile, "# HELP choria_machine_nagios_watcher_info Choria Nagios Check Information\n")
+	fmt.Fprintf(tfile, "# TYPE choria_machine_nagios_watcher_info gauge\n")
+	for _, name := range names {
+		kind, ok := promTypes[name]
+		if ok {
+			fmt.Fprintf(tfile, "choria_machine_nagios_watcher_info{name=%q,type=%q} 1\n", name, kind)
+		}
 	}
 
 	tfile.Close()
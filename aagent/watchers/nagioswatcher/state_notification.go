@@ -7,6 +7,7 @@ package nagioswatcher
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	cloudevents "github.com/cloudevents/sdk-go/v2"
 
@@ -17,15 +18,19 @@ import (
 type StateNotification struct {
 	event.Event
 
-	Plugin      string            `json:"plugin"`
-	Status      string            `json:"status"`
-	StatusCode  int               `json:"status_code"`
-	Output      string            `json:"output"`
-	CheckTime   int64             `json:"check_time"`
-	PerfData    []util.PerfData   `json:"perfdata"`
-	RunTime     float64           `json:"runtime"`
-	History     []*Execution      `json:"history"`
-	Annotations map[string]string `json:"annotations"`
+	Plugin       string            `json:"plugin"`
+	Status       string            `json:"status"`
+	StatusCode   int               `json:"status_code"`
+	Output       string            `json:"output"`
+	CheckTime    int64             `json:"check_time"`
+	PerfData     []util.PerfData   `json:"perfdata"`
+	RunTime      float64           `json:"runtime"`
+	History      []*Execution      `json:"history"`
+	Annotations  map[string]string `json:"annotations"`
+	Flapping     bool              `json:"flapping,omitempty"`
+	Dependencies []string          `json:"dependencies,omitempty"`
+	Suppressed   bool              `json:"suppressed,omitempty"`
+	SuppressedBy []string          `json:"suppressed_by,omitempty"`
 }
 
 // JSON creates a JSON representation of the notification
@@ -40,5 +45,13 @@ func (s *StateNotification) CloudEvent() cloudevents.Event {
 
 // String is a string representation of the notification suitable for printing
 func (s *StateNotification) String() string {
+	if s.Suppressed {
+		return fmt.Sprintf("%s %s#%s %s (suppressed by %s): %s", s.Identity, s.Machine, s.Name, s.Status, strings.Join(s.SuppressedBy, ", "), s.Output)
+	}
+
+	if s.Flapping {
+		return fmt.Sprintf("%s %s#%s %s (flapping): %s", s.Identity, s.Machine, s.Name, s.Status, s.Output)
+	}
+
 	return fmt.Sprintf("%s %s#%s %s: %s", s.Identity, s.Machine, s.Name, s.Status, s.Output)
 }
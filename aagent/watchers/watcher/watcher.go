@@ -18,7 +18,7 @@ import (
 	"github.com/choria-io/go-choria/backoff"
 	"github.com/choria-io/go-choria/internal/util"
 	"github.com/choria-io/go-choria/lifecycle"
-	"github.com/nats-io/jsm.go/governor"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/tidwall/gjson"
 )
 
@@ -31,6 +31,8 @@ type Watcher struct {
 	machine          model.Machine
 	succEvent        string
 	failEvent        string
+	election         string
+	isLeader         func() bool
 
 	deleteCb       func()
 	currentStateCb func() any
@@ -116,16 +118,35 @@ func (w *Watcher) CancelGovernor() {
 	}
 }
 
-func (w *Watcher) sendGovernorLC(t lifecycle.GovernorEventType, name string, seq uint64) {
+func (w *Watcher) sendGovernorLC(t lifecycle.GovernorEventType, name string, seq uint64, duration time.Duration) {
 	w.machine.PublishLifecycleEvent(lifecycle.Governor,
 		lifecycle.Identity(w.machine.Identity()),
 		lifecycle.Component(w.machine.Name()),
 		lifecycle.GovernorType(t),
 		lifecycle.GovernorSequence(seq),
-		lifecycle.GovernorName(name))
+		lifecycle.GovernorName(name),
+		lifecycle.GovernorDuration(duration))
 }
 
-func (w *Watcher) EnterGovernor(ctx context.Context, name string, timeout time.Duration) (governor.Finisher, error) {
+// GovernorOptions creates the options used to obtain weighted and prioritized governor leases, a weight of 0 is treated as 1
+func GovernorOptions(weight int, priority string) ([]governor.Option, error) {
+	if weight < 0 {
+		return nil, fmt.Errorf("governor weight should be 0 or more")
+	}
+
+	if weight == 0 {
+		weight = 1
+	}
+
+	p, err := governor.ParsePriority(priority)
+	if err != nil {
+		return nil, err
+	}
+
+	return []governor.Option{governor.WithWeight(weight), governor.WithPriority(p)}, nil
+}
+
+func (w *Watcher) EnterGovernor(ctx context.Context, name string, timeout time.Duration, opts ...governor.Option) (governor.Finisher, error) {
 	var err error
 
 	name, err = w.ProcessTemplate(name)
@@ -142,7 +163,12 @@ func (w *Watcher) EnterGovernor(ctx context.Context, name string, timeout time.D
 
 	w.Infof("Obtaining a slot in the %s Governor with %v timeout", name, timeout)
 	subj := util.GovernorSubject(name, w.machine.MainCollective())
-	gov := governor.NewJSGovernor(name, mgr, governor.WithLogger(w), governor.WithSubject(subj), governor.WithBackoff(backoff.FiveSec))
+	waiting := func() { w.sendGovernorLC(lifecycle.GovernorWaitingEvent, name, 0, 0) }
+	opts = append([]governor.Option{governor.WithLogger(w), governor.WithSubject(subj), governor.WithBackoff(backoff.FiveSec), governor.WithWaitingCallback(waiting)}, opts...)
+	gov, err := governor.New(name, mgr, opts...)
+	if err != nil {
+		return nil, err
+	}
 
 	var gCtx context.Context
 	w.mu.Lock()
@@ -150,17 +176,19 @@ func (w *Watcher) EnterGovernor(ctx context.Context, name string, timeout time.D
 	w.mu.Unlock()
 	defer w.govCancel()
 
+	start := time.Now()
 	fin, seq, err := gov.Start(gCtx, fmt.Sprintf("Auto Agent  %s#%s @ %s", w.machine.Name(), w.name, w.machine.Identity()))
 	if err != nil {
 		w.Errorf("Could not obtain a slot in the Governor %s: %s", name, err)
-		w.sendGovernorLC(lifecycle.GovernorTimeoutEvent, name, 0)
+		w.sendGovernorLC(lifecycle.GovernorTimeoutEvent, name, 0, time.Since(start))
 		return nil, err
 	}
 
-	w.sendGovernorLC(lifecycle.GovernorEnterEvent, name, seq)
+	w.sendGovernorLC(lifecycle.GovernorEnterEvent, name, seq, time.Since(start))
+	entered := time.Now()
 
 	finisher := func() error {
-		w.sendGovernorLC(lifecycle.GovernorExitEvent, name, seq)
+		w.sendGovernorLC(lifecycle.GovernorExitEvent, name, seq, time.Since(entered))
 		return fin()
 	}
 
@@ -291,6 +319,11 @@ func (w *Watcher) CurrentState() any {
 	return nil
 }
 
+// History is the history kept by the watcher, nil for watchers that do not keep history
+func (w *Watcher) History() any {
+	return nil
+}
+
 func (w *Watcher) AnnounceInterval() time.Duration {
 	return w.announceInterval
 }
@@ -312,7 +345,39 @@ func (w *Watcher) Delete() {
 	}
 }
 
+// SetLeaderElection gates the watcher on being the leader of a named election, isLeader reports the current leadership
+func (w *Watcher) SetLeaderElection(election string, isLeader func() bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.election = election
+	w.isLeader = isLeader
+}
+
+// IsLeader determines if the machine is the leader of the election the watcher is gated on, true when not gated
+func (w *Watcher) IsLeader() bool {
+	w.mu.Lock()
+	isLeader := w.isLeader
+	w.mu.Unlock()
+
+	if isLeader == nil {
+		return true
+	}
+
+	return isLeader()
+}
+
 func (w *Watcher) ShouldWatch() bool {
+	// paused machines do not run any watchers
+	if w.machine.IsPaused() {
+		return false
+	}
+
+	// watchers gated on an election only run on the leader
+	if !w.IsLeader() {
+		return false
+	}
+
 	if len(w.activeStates) == 0 {
 		return true
 	}
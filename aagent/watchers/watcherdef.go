@@ -23,6 +23,13 @@ type WatcherDef struct {
 	AnnounceInterval  string         `json:"announce_interval" yaml:"announce_interval"`
 	Properties        map[string]any `json:"properties" yaml:"properties"`
 	AnnounceDuration  time.Duration  `json:"-" yaml:"-"`
+
+	// LeaderElection is the name of a leader election this watcher is gated on, the watcher only runs while leader
+	LeaderElection string `json:"leader_election" yaml:"leader_election"`
+	// LeaderGainedTransition is fired when becoming the leader of LeaderElection
+	LeaderGainedTransition string `json:"leader_gained_transition" yaml:"leader_gained_transition"`
+	// LeaderLostTransition is fired when losing leadership of LeaderElection
+	LeaderLostTransition string `json:"leader_lost_transition" yaml:"leader_lost_transition"`
 }
 
 // ParseAnnounceInterval parses the announce interval and ensures its not too small
@@ -82,5 +89,17 @@ func (w *WatcherDef) ValidateTransitions(valid []string) (err error) {
 		return fmt.Errorf("invalid success_transition %s specified in watcher %s", w.SuccessTransition, w.Name)
 	}
 
+	if w.LeaderGainedTransition != "" && !hasf(w.LeaderGainedTransition) {
+		return fmt.Errorf("invalid leader_gained_transition %s specified in watcher %s", w.LeaderGainedTransition, w.Name)
+	}
+
+	if w.LeaderLostTransition != "" && !hasf(w.LeaderLostTransition) {
+		return fmt.Errorf("invalid leader_lost_transition %s specified in watcher %s", w.LeaderLostTransition, w.Name)
+	}
+
+	if w.LeaderElection == "" && (w.LeaderGainedTransition != "" || w.LeaderLostTransition != "") {
+		return fmt.Errorf("leader transitions require a leader_election in watcher %s", w.Name)
+	}
+
 	return nil
 }
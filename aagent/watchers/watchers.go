@@ -27,8 +27,10 @@ type Machine interface {
 // Manager manages all the defined watchers in a specific machine
 // implements machine.WatcherManager
 type Manager struct {
-	watchers map[string]model.Watcher
-	machine  Machine
+	watchers  map[string]model.Watcher
+	machine   Machine
+	elections map[string]*leaderElection
+	leaders   map[string]bool
 
 	ctx    context.Context
 	cancel func()
@@ -65,7 +67,9 @@ func RegisterWatcherPlugin(name string, plugin model.WatcherConstructor) error {
 
 func New(ctx context.Context) *Manager {
 	m := &Manager{
-		watchers: make(map[string]model.Watcher),
+		watchers:  make(map[string]model.Watcher),
+		elections: make(map[string]*leaderElection),
+		leaders:   make(map[string]bool),
 	}
 
 	m.ctx, m.cancel = context.WithCancel(ctx)
@@ -159,6 +163,23 @@ func (m *Manager) WatcherState(watcher string) (any, bool) {
 	return w.CurrentState(), true
 }
 
+// WatcherHistory retrieves the history kept by watchers that support it
+func (m *Manager) WatcherHistory(watcher string) (any, bool) {
+	m.Lock()
+	defer m.Unlock()
+	w, ok := m.watchers[watcher]
+	if !ok {
+		return nil, false
+	}
+
+	h := w.History()
+	if h == nil {
+		return nil, false
+	}
+
+	return h, true
+}
+
 func (m *Manager) configureWatchers() (err error) {
 	for _, w := range m.machine.Watchers() {
 		err = w.ParseAnnounceInterval()
@@ -193,6 +214,13 @@ func (m *Manager) configureWatchers() (err error) {
 		if err != nil {
 			return err
 		}
+
+		if w.LeaderElection != "" {
+			err = m.addLeaderElection(w, watcher)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -220,6 +248,11 @@ func (m *Manager) Run(ctx context.Context, wg *sync.WaitGroup) error {
 		}
 	}
 
+	for _, e := range m.elections {
+		wg.Add(1)
+		go m.runLeaderElection(ctx, wg, e)
+	}
+
 	return nil
 }
 
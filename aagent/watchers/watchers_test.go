@@ -111,5 +111,55 @@ var _ = Describe("Aagent/Watchers", func() {
 			Expect(ok).To(BeTrue())
 			Expect(w).To(Equal(watcher))
 		})
+
+		It("Should gate watchers on leader elections", func() {
+			machine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			machine.EXPECT().Watchers().Return([]*WatcherDef{
+				{Name: "mwatcher", Type: "mock", LeaderElection: "ginkgo", LeaderGainedTransition: "gained", LeaderLostTransition: "lost"},
+			})
+
+			gated := &gatedWatcher{MockWatcher: watcher}
+			watcher.EXPECT().Name().Return("mwatcher").AnyTimes()
+			watcherC.EXPECT().New(machine, "mwatcher", nil, "", "", "", 0*time.Second, nil).Return(gated, nil)
+			machine.EXPECT().DataPut("leader_elections", map[string]any{"ginkgo": false})
+
+			err = manager.configureWatchers()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gated.election).To(Equal("ginkgo"))
+			Expect(gated.isLeader()).To(BeFalse())
+			Expect(manager.elections).To(HaveKey("ginkgo"))
+			Expect(manager.elections["ginkgo"].gained).To(Equal([]string{"gained"}))
+			Expect(manager.elections["ginkgo"].lost).To(Equal([]string{"lost"}))
+
+			machine.EXPECT().DataPut("leader_elections", map[string]any{"ginkgo": true})
+			machine.EXPECT().Transition("gained")
+			watcher.EXPECT().NotifyStateChance()
+			manager.setLeader(manager.elections["ginkgo"], true)
+			Expect(gated.isLeader()).To(BeTrue())
+		})
+
+		It("Should fail for watchers that cannot be gated", func() {
+			machine.EXPECT().Infof(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			machine.EXPECT().Watchers().Return([]*WatcherDef{
+				{Name: "mwatcher", Type: "mock", LeaderElection: "ginkgo"},
+			})
+
+			watcher.EXPECT().Name().Return("mwatcher").AnyTimes()
+			watcherC.EXPECT().New(machine, "mwatcher", nil, "", "", "", 0*time.Second, nil).Return(watcher, nil)
+
+			err = manager.configureWatchers()
+			Expect(err).To(MatchError("mock watcher mwatcher does not support leader elections"))
+		})
 	})
 })
+
+type gatedWatcher struct {
+	*model.MockWatcher
+	election string
+	isLeader func() bool
+}
+
+func (g *gatedWatcher) SetLeaderElection(election string, isLeader func() bool) {
+	g.election = election
+	g.isLeader = isLeader
+}
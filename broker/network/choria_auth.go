@@ -12,9 +12,15 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/kv"
+	"github.com/choria-io/go-choria/providers/revocation"
 	"github.com/choria-io/go-choria/tokens"
 	"github.com/nats-io/nats-server/v2/server"
 	"github.com/sirupsen/logrus"
@@ -42,6 +48,12 @@ import (
 // will be placed in the provisioning account and unable to connect to the
 // fleet or provisioned nodes. This is only enabled if plugin.choria.network.provisioning.signer_cert
 // is set
+//
+// When organizations are configured JWT tokens are placed in the account of
+// the organization they belong to isolating organizations from each other
+//
+// When a revocation list is configured JWT tokens matching it are denied
+// and connections already established using them are disconnected
 type ChoriaAuth struct {
 	clientAllowList         []string
 	isTLS                   bool
@@ -50,12 +62,30 @@ type ChoriaAuth struct {
 	clientJwtSigner         string
 	serverJwtSigner         string
 	choriaAccount           *server.Account
+	organizations           map[string]*server.Account
+	orgCollectives          map[string][]string
 	systemAccount           *server.Account
 	provisioningAccount     *server.Account
 	provPass                string
 	systemUser              string
 	systemPass              string
+	revocations             *revocation.List
+	revocationKV            bool
+	revokedAccount          *server.Account
+	sessions                map[uint64]*authSession
 	log                     *logrus.Entry
+
+	mu sync.Mutex
+}
+
+// authSession is a connection that was authenticated using a JWT token and that
+// could later be revoked
+type authSession struct {
+	client  server.ClientAuthentication
+	remote  string
+	name    string
+	values  map[revocation.Kind]string
+	tracked time.Time
 }
 
 const (
@@ -65,6 +95,10 @@ const (
 
 var allSubjects = []string{">"}
 
+// connectionIDPattern extracts the connection ID from a connection description like "192.168.1.1:4222 - cid:10",
+// nats-server only exposes the ID of a connection being authenticated in its description
+var connectionIDPattern = regexp.MustCompile(` - [a-z]+id[^:\s]*:(\d+)`)
+
 // Check checks and registers the incoming connection
 func (a *ChoriaAuth) Check(c server.ClientAuthentication) bool {
 	var (
@@ -202,6 +236,11 @@ func (a *ChoriaAuth) verifyServerJWTBasedAuth(remote net.Addr, jwts string, nonc
 		return nil, fmt.Errorf("invalid nonce signature")
 	}
 
+	if r, revoked := a.revocations.CheckServerClaims(claims); revoked {
+		log.Errorf("Denying connection from %s using revoked %s", remote.String(), r)
+		return nil, fmt.Errorf("revoked token")
+	}
+
 	return claims, nil
 }
 
@@ -223,6 +262,11 @@ func (a *ChoriaAuth) verifyClientJWTBasedAuth(remote net.Addr, jwts string, nonc
 		return nil, fmt.Errorf("invalid nonce signature")
 	}
 
+	if r, revoked := a.revocations.CheckClientClaims(claims); revoked {
+		log.Errorf("Denying connection from %s using revoked %s", remote.String(), r)
+		return nil, fmt.Errorf("revoked token")
+	}
+
 	return claims, nil
 }
 
@@ -277,6 +321,11 @@ func (a *ChoriaAuth) handleDefaultConnection(c server.ClientAuthentication, conn
 			setClientPerms = true
 			user.Username = caller
 
+			user.Account, err = a.organizationAccount(clientClaims.OrganizationUnit)
+			if err != nil {
+				return false, err
+			}
+
 		case tokens.ServerPurpose:
 			if c.Kind() != server.CLIENT {
 				return false, fmt.Errorf("a server JWT was presented by a %d connection", c.Kind())
@@ -292,6 +341,11 @@ func (a *ChoriaAuth) handleDefaultConnection(c server.ClientAuthentication, conn
 			setServerPerms = true
 			user.Username = serverClaims.ChoriaIdentity
 
+			user.Account, err = a.organizationAccount(serverClaims.OrganizationUnit)
+			if err != nil {
+				return false, err
+			}
+
 		default:
 			return false, fmt.Errorf("do not know how to handle %v purpose token", purpose)
 		}
@@ -317,6 +371,8 @@ func (a *ChoriaAuth) handleDefaultConnection(c server.ClientAuthentication, conn
 		log.Debugf("Allowing pipe connection without any limitations")
 	}
 
+	a.denyOrganizationRevocations(user)
+
 	if user.Account != nil {
 		log.Debugf("Registering user '%s' in account '%s'", user.Username, user.Account.Name)
 	} else {
@@ -325,6 +381,22 @@ func (a *ChoriaAuth) handleDefaultConnection(c server.ClientAuthentication, conn
 
 	c.RegisterUser(user)
 
+	switch {
+	case clientClaims != nil:
+		a.trackSession(c, remote, caller, map[revocation.Kind]string{
+			revocation.TokenID:   clientClaims.ID,
+			revocation.Caller:    clientClaims.CallerID,
+			revocation.PublicKey: clientClaims.PublicKey,
+		})
+
+	case serverClaims != nil:
+		a.trackSession(c, remote, serverClaims.ChoriaIdentity, map[revocation.Kind]string{
+			revocation.TokenID:   serverClaims.ID,
+			revocation.Identity:  serverClaims.ChoriaIdentity,
+			revocation.PublicKey: serverClaims.PublicKey,
+		})
+	}
+
 	return true, nil
 }
 
@@ -573,22 +645,69 @@ func (a *ChoriaAuth) setMinimalClientPermissions(_ *server.User, caller string,
 	return subs, pubs
 }
 
+// revocationDenies are the subjects that modify the revocation bucket, only organization administrators that
+// issue revocations and the broker may change it, prefix is the JetStream API prefix
+func revocationDenies(prefix string) []string {
+	stream := revocation.StreamName
+
+	return []string{
+		fmt.Sprintf("$KV.%s.>", revocation.BucketName),
+		fmt.Sprintf("%s.STREAM.MSG.DELETE.%s", prefix, stream),
+		fmt.Sprintf("%s.STREAM.PURGE.%s", prefix, stream),
+		fmt.Sprintf("%s.STREAM.DELETE.%s", prefix, stream),
+		fmt.Sprintf("%s.STREAM.UPDATE.%s", prefix, stream),
+	}
+}
+
+// denyOrganizationRevocations prevents users in organization accounts, including organization administrators,
+// from creating a revocation bucket in their account, servers there read the bucket imported from the choria account
+func (a *ChoriaAuth) denyOrganizationRevocations(user *server.User) {
+	if user.Account == nil || user.Account == a.choriaAccount || !a.isOrganizationAccount(user.Account) {
+		return
+	}
+
+	if user.Permissions.Publish == nil {
+		user.Permissions.Publish = &server.SubjectPermission{}
+	}
+
+	for _, deny := range []string{fmt.Sprintf("$JS.API.STREAM.CREATE.%s", revocation.StreamName), fmt.Sprintf("$KV.%s.>", revocation.BucketName)} {
+		if !util.StringInList(user.Permissions.Publish.Deny, deny) {
+			user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny, deny)
+		}
+	}
+}
+
 func (a *ChoriaAuth) setStreamsAdminPermissions(user *server.User, subs []string, pubs []string) ([]string, []string) {
-	if user.Account != a.choriaAccount {
+	if !a.isOrganizationAccount(user.Account) {
 		return subs, pubs
 	}
 
+	user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny, revocationDenies("$JS.API")...)
+
 	subs = append(subs, "$JS.EVENT.>")
 	pubs = append(pubs, "$JS.>")
 
 	return subs, pubs
 }
 
+// setStreamsUserPermissions grants access to use streams, NATS permissions can not limit message access to
+// a subset of stream names so deleting messages from the streams Choria manages is denied instead.
+//
+// Streams users can read and delete messages in all KV_ streams and so in all Key-Value buckets regardless
+// of their KV grants
 func (a *ChoriaAuth) setStreamsUserPermissions(user *server.User, subs []string, pubs []string) ([]string, []string) {
-	if user.Account != a.choriaAccount {
+	if !a.isOrganizationAccount(user.Account) {
 		return subs, pubs
 	}
 
+	user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny,
+		"$JS.API.STREAM.MSG.DELETE.CHORIA_EVENTS",
+		"$JS.API.STREAM.MSG.DELETE.CHORIA_MACHINE",
+		"$JS.API.STREAM.MSG.DELETE.CHORIA_STREAM_ADVISORIES",
+		"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_LEADER_ELECTION",
+		"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_GOVERNOR_QUEUE")
+	user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny, revocationDenies("$JS.API")...)
+
 	pubs = append(pubs,
 		"$JS.API.INFO",
 		"$JS.API.STREAM.NAMES",
@@ -610,13 +729,13 @@ func (a *ChoriaAuth) setStreamsUserPermissions(user *server.User, subs []string,
 }
 
 func (a *ChoriaAuth) setEventsViewerPermissions(user *server.User, subs []string, pubs []string) ([]string, []string) {
-	switch user.Account {
-	case a.choriaAccount:
+	switch {
+	case a.isOrganizationAccount(user.Account):
 		subs = append(subs,
 			"choria.lifecycle.event.>",
 			"choria.machine.watcher.>",
 			"choria.machine.transition")
-	case a.provisioningAccount:
+	case user.Account == a.provisioningAccount:
 		// provisioner should only listen to one specific kind of event, not strictly needed but its what it is
 		subs = append(subs, "choria.lifecycle.event.*.provision_mode_server")
 	}
@@ -625,22 +744,32 @@ func (a *ChoriaAuth) setEventsViewerPermissions(user *server.User, subs []string
 }
 
 func (a *ChoriaAuth) setClientGovernorPermissions(user *server.User, subs []string, pubs []string) ([]string, []string) {
-	if user.Account != a.choriaAccount {
+	if !a.isOrganizationAccount(user.Account) {
 		return subs, pubs
 	}
 
-	pubs = append(pubs, "*.governor.*")
+	pubs = append(pubs,
+		"*.governor.*",
+		// weighted leases and renewals load the governor stream and read and remove its entries
+		"$JS.API.STREAM.INFO.*",
+		"$JS.API.STREAM.MSG.GET.*",
+		"$JS.API.STREAM.MSG.DELETE.*",
+		// waiters record themselves in the provisioned wait queue bucket
+		"$JS.API.STREAM.INFO.KV_CHORIA_GOVERNOR_QUEUE",
+		"$JS.API.CONSUMER.CREATE.KV_CHORIA_GOVERNOR_QUEUE",
+		"$JS.API.CONSUMER.DELETE.KV_CHORIA_GOVERNOR_QUEUE.*",
+		"$KV.CHORIA_GOVERNOR_QUEUE.>")
 
 	return subs, pubs
 }
 
 func (a *ChoriaAuth) setElectionPermissions(user *server.User, subs []string, pubs []string) ([]string, []string) {
-	switch user.Account {
-	case a.choriaAccount:
+	switch {
+	case a.isOrganizationAccount(user.Account):
 		pubs = append(pubs,
 			"$JS.API.STREAM.INFO.KV_CHORIA_LEADER_ELECTION",
 			"$KV.CHORIA_LEADER_ELECTION.>")
-	case a.provisioningAccount:
+	case user.Account == a.provisioningAccount:
 		// provisioner account is special and can only access one very specific election
 		pubs = append(pubs,
 			"choria.streams.STREAM.INFO.KV_CHORIA_LEADER_ELECTION",
@@ -650,6 +779,87 @@ func (a *ChoriaAuth) setElectionPermissions(user *server.User, subs []string, pu
 	return subs, pubs
 }
 
+// setKVPermissions grants access to specific buckets and keys, access by prefix only allows reading
+// individual keys using direct get as consumers that support watches and history cannot be limited by key.
+// Writes can not be limited by prefix as buckets allow rollups, a Nats-Rollup: all message would purge every
+// key, grants doing so are invalid.
+//
+// Direct get requires buckets to have AllowDirect set, buckets created with older versions of Choria or NATS
+// do not have it and prefix scoped readers can only read them once it was enabled on the KV_<bucket> stream
+func (a *ChoriaAuth) setKVPermissions(user *server.User, grants []*tokens.KVPermission, subs []string, pubs []string) ([]string, []string, error) {
+	if !a.isOrganizationAccount(user.Account) {
+		return subs, pubs, nil
+	}
+
+	// grants to the revocation bucket allow reading only
+	user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny, revocationDenies("$JS.API")...)
+
+	seen := map[string]bool{}
+	add := func(subjects ...string) {
+		for _, subject := range subjects {
+			if !seen[subject] {
+				seen[subject] = true
+				pubs = append(pubs, subject)
+			}
+		}
+	}
+
+	for _, grant := range grants {
+		err := grant.Validate()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if !(grant.Read || grant.Write) {
+			continue
+		}
+
+		stream := fmt.Sprintf("KV_%s", grant.Bucket)
+
+		var keys []string
+		switch {
+		case grant.Prefix == "":
+			keys = []string{fmt.Sprintf("$KV.%s.>", grant.Bucket)}
+		case strings.HasSuffix(grant.Prefix, ">"):
+			keys = []string{fmt.Sprintf("$KV.%s.%s", grant.Bucket, grant.Prefix)}
+		default:
+			keys = []string{
+				fmt.Sprintf("$KV.%s.%s", grant.Bucket, grant.Prefix),
+				fmt.Sprintf("$KV.%s.%s.>", grant.Bucket, grant.Prefix),
+			}
+		}
+
+		add(fmt.Sprintf("$JS.API.STREAM.INFO.%s", stream))
+
+		// users limited to a prefix can read the bucket schemas so values they read can be validated
+		if grant.Prefix != "" {
+			add(fmt.Sprintf("$JS.API.DIRECT.GET.%s.$KV.%s.%s", stream, grant.Bucket, kv.SchemaKey))
+		}
+
+		if grant.Read {
+			for _, key := range keys {
+				add(fmt.Sprintf("$JS.API.DIRECT.GET.%s.%s", stream, key))
+			}
+
+			if grant.Prefix == "" {
+				add(
+					fmt.Sprintf("$JS.API.DIRECT.GET.%s", stream),
+					fmt.Sprintf("$JS.API.STREAM.MSG.GET.%s", stream),
+					fmt.Sprintf("$JS.API.CONSUMER.CREATE.%s", stream),
+					fmt.Sprintf("$JS.API.CONSUMER.DELETE.%s.*", stream),
+					fmt.Sprintf("$JS.API.CONSUMER.INFO.%s.*", stream),
+					fmt.Sprintf("$JS.FC.%s.>", stream))
+			}
+		}
+
+		if grant.Write {
+			add(keys...)
+		}
+	}
+
+	return subs, pubs, nil
+}
+
 func (a *ChoriaAuth) setClientTokenPermissions(user *server.User, caller string, perms *tokens.ClientPermissions, log *logrus.Entry) (pubs []string, subs []string, err error) {
 	if perms != nil && perms.OrgAdmin {
 		log.Infof("Granting user access to all subjects (OrgAdmin)")
@@ -668,8 +878,8 @@ func (a *ChoriaAuth) setClientTokenPermissions(user *server.User, caller string,
 		subs, pubs = a.setStreamsAdminPermissions(user, subs, pubs)
 	}
 
-	// Can use streams but not make new ones etc
-	if perms.StreamsUser {
+	// Can use streams but not make new ones etc, admins already have full access
+	if perms.StreamsUser && !perms.StreamsAdmin {
 		log.Infof("Granting user Streams User access")
 		subs, pubs = a.setStreamsUserPermissions(user, subs, pubs)
 	}
@@ -691,6 +901,15 @@ func (a *ChoriaAuth) setClientTokenPermissions(user *server.User, caller string,
 		subs, pubs = a.setClientGovernorPermissions(user, subs, pubs)
 	}
 
+	// Specific Key-Value buckets and keys
+	if len(perms.KV) > 0 {
+		log.Infof("Granting user access to %d Key-Value buckets", len(perms.KV))
+		subs, pubs, err = a.setKVPermissions(user, perms.KV, subs, pubs)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return pubs, subs, nil
 }
 
@@ -731,7 +950,13 @@ func (a *ChoriaAuth) setDenyServersPermissions(user *server.User) {
 }
 
 func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *tokens.ServerClaims, log *logrus.Entry) {
-	if len(claims.Collectives) == 0 {
+	org := claims.OrganizationUnit
+	if org == emptyString {
+		org = defaultOrganization
+	}
+
+	collectives := a.organizationCollectives(claims, log)
+	if len(collectives) == 0 {
 		log.Warnf("no collectives in server token, denying access")
 		a.setDenyServersPermissions(user)
 		return
@@ -748,7 +973,7 @@ func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *
 
 	user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow, claims.AdditionalPublishSubjects...)
 
-	for _, c := range claims.Collectives {
+	for _, c := range collectives {
 		user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
 			fmt.Sprintf("%s.reply.>", c),
 			fmt.Sprintf("%s.broadcast.agent.registration", c),
@@ -783,11 +1008,13 @@ func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *
 	}
 
 	if claims.Permissions != nil && claims.Permissions.Streams {
+		// organizations hosted in their own accounts have their own Choria Streams
 		prefix := "$JS.API"
-		if claims.OrganizationUnit != "choria" {
+		if org != defaultOrganization && len(a.organizations) == 0 {
 			prefix = "choria.streams"
 		}
 
+		user.Permissions.Publish.Deny = append(user.Permissions.Publish.Deny, revocationDenies(prefix)...)
 		user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
 			fmt.Sprintf("%s.STREAM.INFO.*", prefix),
 			fmt.Sprintf("%s.STREAM.MSG.GET.*", prefix),
@@ -799,6 +1026,36 @@ func (a *ChoriaAuth) setClaimsBasedServerPermissions(user *server.User, claims *
 			"$JS.ACK.>",
 			"$JS.FC.>",
 		)
+
+		// governor entries are managed using the stream grants above, waiters are recorded in the wait queue bucket
+		if claims.Permissions.Governor && prefix == "$JS.API" {
+			user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
+				"$JS.API.CONSUMER.DELETE.KV_CHORIA_GOVERNOR_QUEUE.*",
+				"$KV.CHORIA_GOVERNOR_QUEUE.>",
+			)
+		}
+	}
+
+	// servers need to watch the revocation list but revoking is only for the organization, organizations
+	// hosted in their own accounts read the bucket using the API the broker imports into their accounts
+	switch {
+	case a.revocationKV && org == defaultOrganization:
+		user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
+			fmt.Sprintf("$JS.API.STREAM.INFO.%s", revocation.StreamName),
+			fmt.Sprintf("$JS.API.CONSUMER.CREATE.%s", revocation.StreamName),
+			fmt.Sprintf("$JS.API.CONSUMER.DELETE.%s.*", revocation.StreamName),
+			fmt.Sprintf("$JS.FC.%s.>", revocation.StreamName),
+		)
+
+	case a.revocationKV && len(a.organizations) > 0:
+		prefix := revocation.ImportAPIPrefix
+		user.Permissions.Publish.Allow = append(user.Permissions.Publish.Allow,
+			fmt.Sprintf("%s.STREAM.INFO.%s", prefix, revocation.StreamName),
+			fmt.Sprintf("%s.CONSUMER.CREATE.%s", prefix, revocation.StreamName),
+			fmt.Sprintf("%s.CONSUMER.DELETE.%s.*", prefix, revocation.StreamName),
+			fmt.Sprintf("%s.CONSUMER.MSG.NEXT.%s.*", prefix, revocation.StreamName),
+			fmt.Sprintf("$JS.ACK.%s.>", revocation.StreamName),
+		)
 	}
 }
 
@@ -814,12 +1071,12 @@ func (a *ChoriaAuth) setDefaultServerPermissions(user *server.User) {
 	user.Permissions.Publish = &server.SubjectPermission{
 		Allow: allSubjects,
 
-		Deny: []string{
+		Deny: append([]string{
 			"*.broadcast.agent.>",
 			"*.broadcast.service.>",
 			"*.node.>",
 			"choria.federation.*.federation",
-		},
+		}, revocationDenies("$JS.API")...),
 	}
 }
 
@@ -839,6 +1096,67 @@ func (a *ChoriaAuth) setServerPermissions(user *server.User, claims *tokens.Serv
 	}
 }
 
+// organizationAccount finds the account for an organization, when no organizations are configured all are in the choria account
+func (a *ChoriaAuth) organizationAccount(org string) (*server.Account, error) {
+	if len(a.organizations) == 0 {
+		return a.choriaAccount, nil
+	}
+
+	if org == emptyString {
+		org = defaultOrganization
+	}
+
+	acct, ok := a.organizations[org]
+	if !ok {
+		return nil, fmt.Errorf("organization %s is not hosted on this broker", org)
+	}
+
+	return acct, nil
+}
+
+// isOrganizationAccount determines if acct is the choria account or the account for an organization
+func (a *ChoriaAuth) isOrganizationAccount(acct *server.Account) bool {
+	if acct == nil {
+		return false
+	}
+
+	if acct == a.choriaAccount {
+		return true
+	}
+
+	for _, oacct := range a.organizations {
+		if acct == oacct {
+			return true
+		}
+	}
+
+	return false
+}
+
+// organizationCollectives are the collectives in the token that its organization allows, all are allowed when not restricted
+func (a *ChoriaAuth) organizationCollectives(claims *tokens.ServerClaims, log *logrus.Entry) []string {
+	org := claims.OrganizationUnit
+	if org == emptyString {
+		org = defaultOrganization
+	}
+
+	allowed, ok := a.orgCollectives[org]
+	if !ok || len(allowed) == 0 {
+		return claims.Collectives
+	}
+
+	var collectives []string
+	for _, c := range claims.Collectives {
+		if util.StringInList(allowed, c) {
+			collectives = append(collectives, c)
+		} else {
+			log.Warnf("Collective %s is not allowed in organization %s, ignoring", c, org)
+		}
+	}
+
+	return collectives
+}
+
 func (a *ChoriaAuth) remoteInClientAllowList(remote net.Addr) bool {
 	if len(a.clientAllowList) == 0 {
 		return true
@@ -902,3 +1220,90 @@ func (a *ChoriaAuth) createUser(c server.ClientAuthentication) *server.User {
 		Permissions: &server.Permissions{},
 	}
 }
+
+// connectionID determines the broker assigned ID of a connection
+func connectionID(c server.ClientAuthentication) (uint64, bool) {
+	desc, ok := c.(fmt.Stringer)
+	if !ok {
+		return 0, false
+	}
+
+	matches := connectionIDPattern.FindStringSubmatch(desc.String())
+	if matches == nil {
+		return 0, false
+	}
+
+	id, err := strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return id, true
+}
+
+// trackSession records a JWT authenticated connection so that it can be disconnected when revoked
+func (a *ChoriaAuth) trackSession(c server.ClientAuthentication, remote net.Addr, name string, values map[revocation.Kind]string) {
+	if a.revocations == nil || remote == nil {
+		return
+	}
+
+	id, ok := connectionID(c)
+	if !ok {
+		a.log.Warnf("Could not determine the connection ID for %s from %s, it cannot be disconnected when revoked", name, remote.String())
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.sessions == nil {
+		a.sessions = make(map[uint64]*authSession)
+	}
+
+	a.sessions[id] = &authSession{client: c, remote: remote.String(), name: name, values: values, tracked: time.Now()}
+}
+
+// pruneSessions forgets connections that are not in open, the IDs of connections at the time listed,
+// sessions tracked after listed are kept as they might not have been in the list
+func (a *ChoriaAuth) pruneSessions(open map[uint64]bool, listed time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for id, session := range a.sessions {
+		if !open[id] && session.tracked.Before(listed) {
+			delete(a.sessions, id)
+		}
+	}
+}
+
+// disconnectRevoked disconnects all connections established using a revoked token by moving
+// them to an account that allows no connections
+func (a *ChoriaAuth) disconnectRevoked(r *revocation.Revocation) {
+	if a.revokedAccount == nil {
+		return
+	}
+
+	var revoked []*authSession
+
+	a.mu.Lock()
+	for id, session := range a.sessions {
+		if session.values[r.Kind] == r.Value {
+			revoked = append(revoked, session)
+			delete(a.sessions, id)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, session := range revoked {
+		a.log.Warnf("Disconnecting %s from %s using revoked %s", session.name, session.remote, r)
+
+		user := &server.User{
+			Username:    session.name,
+			Account:     a.revokedAccount,
+			Permissions: &server.Permissions{},
+		}
+		a.setDenyServersPermissions(user)
+
+		session.client.RegisterUser(user)
+	}
+}
@@ -19,9 +19,11 @@ import (
 
 	"github.com/choria-io/go-choria/choria"
 	"github.com/choria-io/go-choria/integration/testutil"
+	"github.com/choria-io/go-choria/providers/revocation"
 	"github.com/choria-io/go-choria/tokens"
 	"github.com/golang/mock/gomock"
 	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/sirupsen/logrus"
@@ -59,6 +61,26 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 		mockctl.Finish()
 	})
 
+	Describe("connectionID", func() {
+		It("Should determine connection IDs", func() {
+			_, ok := connectionID(mockClient)
+			Expect(ok).To(BeFalse())
+
+			for desc, expected := range map[string]uint64{
+				"192.168.0.1:4222 - cid:10":                      10,
+				"192.168.0.1:4222 - wid:11":                      11,
+				"192.168.0.1:4222 - cid:12 - \"go:1.16:ginkgo\"": 12,
+			} {
+				id, ok := connectionID(&describedClient{MockClientAuthentication: mockClient, desc: desc})
+				Expect(ok).To(BeTrue())
+				Expect(id).To(Equal(expected))
+			}
+
+			_, ok = connectionID(&describedClient{MockClientAuthentication: mockClient, desc: "SYSTEM"})
+			Expect(ok).To(BeFalse())
+		})
+	})
+
 	createKeyPair := func() (td string, pri *rsa.PrivateKey) {
 		td, err := os.MkdirTemp("", "")
 		Expect(err).ToNot(HaveOccurred())
@@ -499,7 +521,10 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 								"$JS.API.CONSUMER.MSG.NEXT.*.*",
 								"$JS.ACK.>",
 								"$JS.FC.>",
+								"$JS.API.CONSUMER.DELETE.KV_CHORIA_GOVERNOR_QUEUE.*",
+								"$KV.CHORIA_GOVERNOR_QUEUE.>",
 							},
+							Deny: revocationDeny("$JS.API"),
 						}))
 					})
 
@@ -541,6 +566,84 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 					Expect(verified).To(BeTrue())
 				})
 
+				It("Should allow watching the revocation bucket", func() {
+					auth.revocationKV = true
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Permissions.Publish.Allow).To(ContainElements(
+							"$JS.API.STREAM.INFO.KV_CHORIA_REVOCATIONS",
+							"$JS.API.CONSUMER.CREATE.KV_CHORIA_REVOCATIONS",
+							"$JS.API.CONSUMER.DELETE.KV_CHORIA_REVOCATIONS.*",
+							"$JS.FC.KV_CHORIA_REVOCATIONS.>",
+						))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
+				It("Should treat servers without an organization as being in the choria organization", func() {
+					auth.revocationKV = true
+
+					copts.Token = createSignedServerJWT(privateKey, edPublicKey, map[string]any{
+						"purpose":     tokens.ServerPurpose,
+						"public_key":  hex.EncodeToString(edPublicKey),
+						"collectives": []string{"c1"},
+						"ou":          "",
+						"permissions": &tokens.ServerPermissions{Streams: true},
+					})
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(auth.choriaAccount))
+						Expect(user.Permissions.Publish.Allow).To(ContainElements(
+							"$JS.API.STREAM.INFO.*",
+							"$JS.API.STREAM.INFO.KV_CHORIA_REVOCATIONS",
+							"$JS.API.CONSUMER.CREATE.KV_CHORIA_REVOCATIONS",
+							"$JS.API.CONSUMER.DELETE.KV_CHORIA_REVOCATIONS.*",
+							"$JS.FC.KV_CHORIA_REVOCATIONS.>",
+						))
+						Expect(user.Permissions.Publish.Allow).ToNot(ContainElement("choria.streams.STREAM.INFO.*"))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
+				It("Should allow organization servers to watch the imported revocation bucket", func() {
+					auth.revocationKV = true
+					acme := &server.Account{Name: "acme"}
+					auth.organizations = map[string]*server.Account{"choria": auth.choriaAccount, "acme": acme}
+
+					copts.Token = createSignedServerJWT(privateKey, edPublicKey, map[string]any{
+						"purpose":     tokens.ServerPurpose,
+						"public_key":  hex.EncodeToString(edPublicKey),
+						"collectives": []string{"c1"},
+						"ou":          "acme",
+					})
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(acme))
+						Expect(user.Permissions.Publish.Allow).To(ContainElements(
+							"choria.streams.STREAM.INFO.KV_CHORIA_REVOCATIONS",
+							"choria.streams.CONSUMER.CREATE.KV_CHORIA_REVOCATIONS",
+							"choria.streams.CONSUMER.DELETE.KV_CHORIA_REVOCATIONS.*",
+							"choria.streams.CONSUMER.MSG.NEXT.KV_CHORIA_REVOCATIONS.*",
+							"$JS.ACK.KV_CHORIA_REVOCATIONS.>",
+						))
+						Expect(user.Permissions.Publish.Allow).ToNot(ContainElement("$JS.API.STREAM.INFO.KV_CHORIA_REVOCATIONS"))
+						Expect(user.Permissions.Publish.Deny).To(ContainElements(
+							"$JS.API.STREAM.CREATE.KV_CHORIA_REVOCATIONS",
+							"$KV.CHORIA_REVOCATIONS.>",
+						))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
 				Describe("Should support Streams", func() {
 					It("Should support Streams in the choria org", func() {
 						copts.Token = createSignedServerJWT(privateKey, edPublicKey, map[string]any{
@@ -574,6 +677,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 									"$JS.ACK.>",
 									"$JS.FC.>",
 								},
+								Deny: revocationDeny("$JS.API"),
 							}))
 						})
 
@@ -614,6 +718,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 									"$JS.ACK.>",
 									"$JS.FC.>",
 								},
+								Deny: revocationDeny("choria.streams"),
 							}))
 						})
 
@@ -721,6 +826,160 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				Expect(verified).To(BeTrue())
 			})
 
+			Describe("Revocations", func() {
+				var (
+					revoked *server.Account
+					client  server.ClientAuthentication
+				)
+
+				BeforeEach(func() {
+					sig, err := choria.Ed25519Sign(edPrivateKey, []byte("toomanysecrets"))
+					Expect(err).ToNot(HaveOccurred())
+					copts.Sig = base64.RawURLEncoding.EncodeToString(sig)
+
+					revoked = &server.Account{Name: revokedAccountName}
+					auth.revocations = revocation.NewList()
+					auth.revokedAccount = revoked
+					auth.revocations.OnRevoke(auth.disconnectRevoked)
+
+					mockClient.EXPECT().RemoteAddress().Return(&net.IPAddr{IP: net.ParseIP("192.168.0.1"), Zone: ""})
+					mockClient.EXPECT().GetNonce().Return([]byte("toomanysecrets"))
+					client = &describedClient{MockClientAuthentication: mockClient, desc: "192.168.0.1:4222 - cid:10"}
+				})
+
+				revoke := func(kind revocation.Kind, value string) {
+					r, err := revocation.New(kind, value, "")
+					Expect(err).ToNot(HaveOccurred())
+					file := filepath.Join(td, "revoked.json")
+					Expect(revocation.WriteFile(file, []*revocation.Revocation{r})).To(Succeed())
+					Expect(auth.revocations.LoadFile(file)).To(Succeed())
+				}
+
+				It("Should deny revoked clients", func() {
+					revoke(revocation.Caller, "up=ginkgo")
+
+					verified, err := auth.handleDefaultConnection(client, verifiedConn, true, log)
+					Expect(err).To(MatchError("invalid nonce signature or jwt token"))
+					Expect(verified).To(BeFalse())
+				})
+
+				It("Should disconnect connected clients when revoked", func() {
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(auth.choriaAccount))
+					})
+
+					verified, err := auth.handleDefaultConnection(client, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+					Expect(auth.sessions).To(HaveLen(1))
+
+					revoke(revocation.Caller, "up=other")
+					Expect(auth.sessions).To(HaveLen(1))
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(revoked))
+						Expect(user.Permissions.Publish.Deny).To(Equal(allSubjects))
+						Expect(user.Permissions.Subscribe.Deny).To(Equal(allSubjects))
+					})
+
+					revoke(revocation.PublicKey, hex.EncodeToString(edPublicKey))
+					Expect(auth.sessions).To(BeEmpty())
+				})
+
+				It("Should prune closed sessions", func() {
+					mockClient.EXPECT().RegisterUser(gomock.Any())
+
+					_, err := auth.handleDefaultConnection(client, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(auth.sessions).To(HaveKey(uint64(10)))
+
+					auth.pruneSessions(map[uint64]bool{}, time.Now().Add(-time.Minute))
+					Expect(auth.sessions).To(HaveLen(1))
+
+					auth.pruneSessions(map[uint64]bool{10: true}, time.Now())
+					Expect(auth.sessions).To(HaveLen(1))
+
+					auth.pruneSessions(map[uint64]bool{}, time.Now())
+					Expect(auth.sessions).To(BeEmpty())
+				})
+			})
+
+			Describe("Organizations", func() {
+				var acme *server.Account
+
+				BeforeEach(func() {
+					sig, err := choria.Ed25519Sign(edPrivateKey, []byte("toomanysecrets"))
+					Expect(err).ToNot(HaveOccurred())
+					copts.Sig = base64.RawURLEncoding.EncodeToString(sig)
+
+					acme = &server.Account{Name: "acme"}
+					auth.organizations = map[string]*server.Account{"choria": auth.choriaAccount, "acme": acme}
+
+					mockClient.EXPECT().RemoteAddress().Return(&net.IPAddr{IP: net.ParseIP("192.168.0.1"), Zone: ""})
+					mockClient.EXPECT().GetNonce().Return([]byte("toomanysecrets"))
+				})
+
+				It("Should place clients without an organization in the choria account", func() {
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(auth.choriaAccount))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
+				It("Should place clients in their organization account", func() {
+					copts.Token = createSignedClientJWT(privateKey, map[string]any{
+						"purpose":    tokens.ClientIDPurpose,
+						"public_key": hex.EncodeToString(edPublicKey),
+						"ou":         "acme",
+					})
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(acme))
+						Expect(user.Permissions.Subscribe.Allow).To(Equal([]string{"*.reply.e33bf0376d4accbb4a8fd24b2f840b2e.>"}))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
+				It("Should not allow organization administrators to create a revocation bucket", func() {
+					copts.Token = createSignedClientJWT(privateKey, map[string]any{
+						"purpose":     tokens.ClientIDPurpose,
+						"public_key":  hex.EncodeToString(edPublicKey),
+						"ou":          "acme",
+						"permissions": &tokens.ClientPermissions{OrgAdmin: true},
+					})
+
+					mockClient.EXPECT().RegisterUser(gomock.Any()).Do(func(user *server.User) {
+						Expect(user.Account).To(Equal(acme))
+						Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+							Allow: allSubjects,
+							Deny:  []string{"$JS.API.STREAM.CREATE.KV_CHORIA_REVOCATIONS", "$KV.CHORIA_REVOCATIONS.>"},
+						}))
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(verified).To(BeTrue())
+				})
+
+				It("Should deny clients for organizations not hosted on the broker", func() {
+					copts.Token = createSignedClientJWT(privateKey, map[string]any{
+						"purpose":    tokens.ClientIDPurpose,
+						"public_key": hex.EncodeToString(edPublicKey),
+						"ou":         "other",
+					})
+
+					verified, err := auth.handleDefaultConnection(mockClient, verifiedConn, true, log)
+					Expect(err).To(MatchError("organization other is not hosted on this broker"))
+					Expect(verified).To(BeFalse())
+				})
+			})
+
 			It("Should register other clients without restriction", func() {
 				mockClient.GetOpts().Token = ""
 				mockClient.EXPECT().RemoteAddress().Return(&net.IPAddr{IP: net.ParseIP("192.168.0.1"), Zone: ""})
@@ -1198,8 +1457,40 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 						"$JS.API.CONSUMER.MSG.NEXT.*.*",
 						"$JS.ACK.>",
 						"$JS.FC.>"),
+					Deny: []string{
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_EVENTS",
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_MACHINE",
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_STREAM_ADVISORIES",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_LEADER_ELECTION",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_GOVERNOR_QUEUE",
+						"$KV.CHORIA_REVOCATIONS.>",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.PURGE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.DELETE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.UPDATE.KV_CHORIA_REVOCATIONS",
+					},
+				}))
+			})
+
+			It("Should not apply streams user limits to streams admins", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{StreamsUser: true, StreamsAdmin: true}}, log)
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Allow: append(minPub, "$JS.>"),
+					Deny:  revocationDeny("$JS.API"),
 				}))
 			})
+
+			It("Should not limit streams users to their KV grants", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{StreamsUser: true, KV: []*tokens.KVPermission{{Bucket: "CONFIG", Prefix: "users", Read: true}}}}, log)
+				Expect(user.Permissions.Publish.Allow).To(ContainElements(
+					"$JS.API.STREAM.MSG.GET.*",
+					"$JS.API.STREAM.MSG.DELETE.*",
+					"$JS.API.CONSUMER.CREATE.*",
+					"$JS.API.DIRECT.GET.KV_CONFIG.$KV.CONFIG.users",
+				))
+			})
 		})
 
 		Describe("Governor Users", func() {
@@ -1238,7 +1529,26 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 						"$JS.ACK.>",
 						"$JS.FC.>",
 						"*.governor.*",
+						"$JS.API.STREAM.INFO.*",
+						"$JS.API.STREAM.MSG.GET.*",
+						"$JS.API.STREAM.MSG.DELETE.*",
+						"$JS.API.STREAM.INFO.KV_CHORIA_GOVERNOR_QUEUE",
+						"$JS.API.CONSUMER.CREATE.KV_CHORIA_GOVERNOR_QUEUE",
+						"$JS.API.CONSUMER.DELETE.KV_CHORIA_GOVERNOR_QUEUE.*",
+						"$KV.CHORIA_GOVERNOR_QUEUE.>",
 					}...),
+					Deny: []string{
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_EVENTS",
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_MACHINE",
+						"$JS.API.STREAM.MSG.DELETE.CHORIA_STREAM_ADVISORIES",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_LEADER_ELECTION",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_GOVERNOR_QUEUE",
+						"$KV.CHORIA_REVOCATIONS.>",
+						"$JS.API.STREAM.MSG.DELETE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.PURGE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.DELETE.KV_CHORIA_REVOCATIONS",
+						"$JS.API.STREAM.UPDATE.KV_CHORIA_REVOCATIONS",
+					},
 				}))
 			})
 
@@ -1297,6 +1607,111 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				}))
 			})
 		})
+
+		Describe("KV Users", func() {
+			It("Should set no permissions for non choria users", func() {
+				user.Account = auth.provisioningAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{KV: []*tokens.KVPermission{{Bucket: "CONFIG", Read: true, Write: true}}}}, log)
+				Expect(user.Permissions.Subscribe).To(Equal(&server.SubjectPermission{
+					Allow: minSub,
+				}))
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Allow: minPub,
+				}))
+			})
+
+			It("Should deny all for invalid grants", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{KV: []*tokens.KVPermission{{Bucket: "CONFIG.>", Read: true}}}}, log)
+				Expect(user.Permissions.Subscribe).To(Equal(&server.SubjectPermission{
+					Deny: allSubjects,
+				}))
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Deny: allSubjects,
+				}))
+			})
+
+			It("Should deny prefix scoped writers that could roll up the entire bucket", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{KV: []*tokens.KVPermission{{Bucket: "CONFIG", Prefix: "users", Write: true}}}}, log)
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Deny: allSubjects,
+				}))
+
+				srv, err := server.NewServer(&server.Options{
+					JetStream: true,
+					StoreDir:  GinkgoT().TempDir(),
+					Port:      -1,
+					Host:      "localhost",
+					Users: []*server.User{
+						{Username: "admin", Password: "admin"},
+						{Username: "writer", Password: "writer", Permissions: user.Permissions},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+				go srv.Start()
+				defer srv.Shutdown()
+				Expect(srv.ReadyForConnections(10 * time.Second)).To(BeTrue())
+
+				admin, err := nats.Connect(srv.ClientURL(), nats.UserInfo("admin", "admin"))
+				Expect(err).ToNot(HaveOccurred())
+				defer admin.Close()
+				js, err := admin.JetStream()
+				Expect(err).ToNot(HaveOccurred())
+				bucket, err := js.CreateKeyValue(&nats.KeyValueConfig{Bucket: "CONFIG"})
+				Expect(err).ToNot(HaveOccurred())
+				_, err = bucket.Put("users.bob", []byte("bob"))
+				Expect(err).ToNot(HaveOccurred())
+				_, err = bucket.Put("other", []byte("other"))
+				Expect(err).ToNot(HaveOccurred())
+
+				writer, err := nats.Connect(srv.ClientURL(), nats.UserInfo("writer", "writer"))
+				Expect(err).ToNot(HaveOccurred())
+				defer writer.Close()
+
+				// a rollup of all subjects using a key below the prefix would purge every key in the bucket
+				msg := nats.NewMsg("$KV.CONFIG.users.bob")
+				msg.Header.Set("Nats-Rollup", "all")
+				Expect(writer.PublishMsg(msg)).To(Succeed())
+				Expect(writer.Flush()).To(Succeed())
+
+				Consistently(func() []string {
+					keys, _ := bucket.Keys()
+					return keys
+				}, 500*time.Millisecond).Should(ConsistOf("users.bob", "other"))
+			})
+
+			It("Should set bucket and key permissions", func() {
+				user.Account = auth.choriaAccount
+				auth.setClientPermissions(user, "", &tokens.ClientIDClaims{Permissions: &tokens.ClientPermissions{KV: []*tokens.KVPermission{
+					{Bucket: "CONFIG", Read: true},
+					{Bucket: "CONFIG", Write: true},
+					{Bucket: "PLANS", Prefix: "web.*", Read: true},
+					{Bucket: "NONE"},
+				}}}, log)
+				Expect(user.Permissions.Subscribe).To(Equal(&server.SubjectPermission{
+					Allow: minSub,
+				}))
+				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
+					Allow: append(minPub,
+						"$JS.API.STREAM.INFO.KV_CONFIG",
+						"$JS.API.DIRECT.GET.KV_CONFIG.$KV.CONFIG.>",
+						"$JS.API.DIRECT.GET.KV_CONFIG",
+						"$JS.API.STREAM.MSG.GET.KV_CONFIG",
+						"$JS.API.CONSUMER.CREATE.KV_CONFIG",
+						"$JS.API.CONSUMER.DELETE.KV_CONFIG.*",
+						"$JS.API.CONSUMER.INFO.KV_CONFIG.*",
+						"$JS.FC.KV_CONFIG.>",
+						"$KV.CONFIG.>",
+						"$JS.API.STREAM.INFO.KV_PLANS",
+						"$JS.API.DIRECT.GET.KV_PLANS.$KV.PLANS._choria.schemas",
+						"$JS.API.DIRECT.GET.KV_PLANS.$KV.PLANS.web.*",
+						"$JS.API.DIRECT.GET.KV_PLANS.$KV.PLANS.web.*.>"),
+					Deny: revocationDeny("$JS.API"),
+				}))
+			})
+		})
+
 		Describe("Streams Admin", func() {
 			It("Should set no permissions for non choria users", func() {
 				user.Account = auth.provisioningAccount
@@ -1317,6 +1732,7 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				}))
 				Expect(user.Permissions.Publish).To(Equal(&server.SubjectPermission{
 					Allow: append(minPub, "$JS.>"),
+					Deny:  revocationDeny("$JS.API"),
 				}))
 			})
 		})
@@ -1349,6 +1765,25 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 		})
 	})
 
+	Describe("organizationCollectives", func() {
+		It("Should allow all collectives when not restricted", func() {
+			claims := &tokens.ServerClaims{Collectives: []string{"c1", "c2"}, OrganizationUnit: "acme"}
+			Expect(auth.organizationCollectives(claims, log)).To(Equal([]string{"c1", "c2"}))
+		})
+
+		It("Should only allow collectives in the organization", func() {
+			auth.orgCollectives = map[string][]string{"acme": {"c2"}}
+			claims := &tokens.ServerClaims{Collectives: []string{"c1", "c2"}, OrganizationUnit: "acme"}
+			Expect(auth.organizationCollectives(claims, log)).To(Equal([]string{"c2"}))
+		})
+
+		It("Should treat an empty organization as the choria organization", func() {
+			auth.orgCollectives = map[string][]string{"choria": {"c1"}}
+			claims := &tokens.ServerClaims{Collectives: []string{"c1", "c2"}}
+			Expect(auth.organizationCollectives(claims, log)).To(Equal([]string{"c1"}))
+		})
+	})
+
 	Describe("setServerPermissions", func() {
 		It("Should set correct permissions", func() {
 			auth.setServerPermissions(user, nil, log)
@@ -1357,12 +1792,12 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 				">",
 			}))
 
-			Expect(user.Permissions.Publish.Deny).To(Equal([]string{
+			Expect(user.Permissions.Publish.Deny).To(Equal(append([]string{
 				"*.broadcast.agent.>",
 				"*.broadcast.service.>",
 				"*.node.>",
 				"choria.federation.*.federation",
-			}))
+			}, revocationDeny("$JS.API")...)))
 
 			Expect(user.Permissions.Subscribe.Allow).To(HaveLen(0))
 			Expect(user.Permissions.Subscribe.Deny).To(Equal([]string{
@@ -1380,3 +1815,24 @@ var _ = Describe("Network Broker/ChoriaAuth", func() {
 		})
 	})
 })
+
+// describedClient is a client connection with a nats-server style connection description
+type describedClient struct {
+	*MockClientAuthentication
+	desc string
+}
+
+func (c *describedClient) String() string {
+	return c.desc
+}
+
+// revocationDeny are the subjects modifying the revocation bucket that are denied to everyone but organization admins
+func revocationDeny(prefix string) []string {
+	return []string{
+		"$KV.CHORIA_REVOCATIONS.>",
+		prefix + ".STREAM.MSG.DELETE.KV_CHORIA_REVOCATIONS",
+		prefix + ".STREAM.PURGE.KV_CHORIA_REVOCATIONS",
+		prefix + ".STREAM.DELETE.KV_CHORIA_REVOCATIONS",
+		prefix + ".STREAM.UPDATE.KV_CHORIA_REVOCATIONS",
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/providers/revocation"
 )
 
 // BuildInfoProvider provider build time flag information, example go-choria/build
@@ -38,6 +39,11 @@ type Server struct {
 	systemAccount       *natsd.Account
 	provisioningAccount *natsd.Account
 
+	organizationAccounts    map[string]*natsd.Account
+	organizationCollectives map[string][]string
+
+	revocations *revocation.List
+
 	started bool
 
 	mu *sync.Mutex
@@ -118,6 +124,11 @@ func NewServer(c inter.Framework, bi BuildInfoProvider, debug bool) (s *Server,
 		return s, fmt.Errorf("could not set up accounts: %s", err)
 	}
 
+	err = s.setupOrganizations()
+	if err != nil {
+		return s, fmt.Errorf("could not set up organizations: %s", err)
+	}
+
 	err = s.setupMappings()
 	if err != nil {
 		s.log.Errorf("Network Mapping setup failed: %v", err)
@@ -137,6 +148,8 @@ func NewServer(c inter.Framework, bi BuildInfoProvider, debug bool) (s *Server,
 	choriaAuth := &ChoriaAuth{
 		clientAllowList: s.config.Choria.NetworkAllowedClientHosts,
 		choriaAccount:   s.choriaAccount,
+		organizations:   s.organizationAccounts,
+		orgCollectives:  s.organizationCollectives,
 		denyServers:     s.config.Choria.NetworkDenyServers,
 		isTLS:           s.isClientTlSBroker(),
 		log:             s.choria.Logger("authentication"),
@@ -166,6 +179,11 @@ func NewServer(c inter.Framework, bi BuildInfoProvider, debug bool) (s *Server,
 		s.opts.AlwaysEnableNonce = true
 	}
 
+	err = s.setupRevocations(choriaAuth)
+	if err != nil {
+		return s, fmt.Errorf("could not set up revocations: %s", err)
+	}
+
 	s.opts.CustomClientAuthentication = choriaAuth
 
 	return
@@ -199,6 +217,8 @@ func (s *Server) Start(ctx context.Context, wg *sync.WaitGroup) {
 
 	go s.publishStats(ctx, 10*time.Second)
 
+	s.startRevocations(ctx, wg)
+
 	err := s.setupStreaming()
 	if err != nil {
 		s.log.Errorf("Could not set up Choria Streams: %s", err)
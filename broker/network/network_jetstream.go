@@ -39,6 +39,24 @@ func (s *Server) setupStreaming() error {
 		s.log.Errorf("Choria Streams enabled for account %q but it's not reporting as enabled", s.choriaAccount.Name)
 	}
 
+	for org, acct := range s.organizationAccounts {
+		if acct == s.choriaAccount {
+			continue
+		}
+
+		streams := s.extractKeyedConfigString("organization", org, "streams", "yes")
+		if streams == "false" || streams == "no" || streams == "off" || streams == "0" {
+			s.log.Infof("Not enabling Choria Streams for organization %s", org)
+			continue
+		}
+
+		s.log.Infof("Enabling Choria Streams for organization %s", org)
+		err = acct.EnableJetStream(nil)
+		if err != nil {
+			s.log.Errorf("Could not enable Choria Streams for organization %s: %s", org, err)
+		}
+	}
+
 	return nil
 }
 
@@ -152,6 +170,23 @@ func (s *Server) configureSystemStreams(ctx context.Context) error {
 		return err
 	}
 
+	gCfg, err := jsm.NewStreamConfiguration(jsm.DefaultStream,
+		jsm.Replicas(cfg.NetworkLeaderElectionReplicas),
+		jsm.MaxAge(24*time.Hour),
+		jsm.AllowRollup(),
+		jsm.DenyDelete(),
+		jsm.Subjects("$KV.CHORIA_GOVERNOR_QUEUE.>"),
+		jsm.StreamDescription("Choria Governor wait queue"),
+		jsm.FileStorage(),
+		jsm.MaxMessagesPerSubject(1))
+	if err != nil {
+		return err
+	}
+	err = s.createOrUpdateStreamWithConfig("KV_CHORIA_GOVERNOR_QUEUE", *gCfg, mgr)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
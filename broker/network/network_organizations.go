@@ -0,0 +1,125 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package network
+
+import (
+	"fmt"
+	"strings"
+
+	natsd "github.com/nats-io/nats-server/v2/server"
+
+	"github.com/choria-io/go-choria/tokens"
+)
+
+const defaultOrganization = "choria"
+
+var reservedOrganization = []string{"system", "provisioning", revokedAccountName}
+
+// setupOrganizations creates an isolated account for every configured organization, the choria
+// organization is always hosted in the choria account
+func (s *Server) setupOrganizations() error {
+	if len(s.config.Choria.NetworkOrganizations) == 0 {
+		return nil
+	}
+
+	s.organizationAccounts = map[string]*natsd.Account{defaultOrganization: s.choriaAccount}
+	s.organizationCollectives = map[string][]string{}
+
+	for _, org := range s.config.Choria.NetworkOrganizations {
+		if org == defaultOrganization {
+			continue
+		}
+
+		err := validateOrganization(org)
+		if err != nil {
+			return err
+		}
+
+		acct, _ := s.gnatsd.LookupOrRegisterAccount(org)
+		if acct == nil {
+			return fmt.Errorf("could not create account for organization %s", org)
+		}
+
+		s.log.Infof("Hosting organization %s in an isolated account", org)
+		s.organizationAccounts[org] = acct
+	}
+
+	for org, acct := range s.organizationAccounts {
+		collectives := s.extractKeyedConfigString("organization", org, "collectives", "")
+		if collectives != "" {
+			for _, c := range strings.Split(collectives, ",") {
+				s.organizationCollectives[org] = append(s.organizationCollectives[org], strings.TrimSpace(c))
+			}
+		}
+
+		err := s.setupOrganizationExports(org, acct)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupOrganizationExports shares subjects between organizations based on exports and service_exports
+// in ORG:SUBJECT format, the subjects are available unchanged in the importing organization
+func (s *Server) setupOrganizationExports(org string, acct *natsd.Account) error {
+	for _, kind := range []string{"exports", "service_exports"} {
+		exports := s.extractKeyedConfigString("organization", org, kind, "")
+		if exports == "" {
+			continue
+		}
+
+		for _, export := range strings.Split(exports, ",") {
+			target, subject, ok := strings.Cut(strings.TrimSpace(export), ":")
+			if !ok || target == "" || subject == "" {
+				return fmt.Errorf("invalid export %q for organization %s, exports should be in ORG:SUBJECT format", export, org)
+			}
+
+			tacct, ok := s.organizationAccounts[target]
+			if !ok {
+				return fmt.Errorf("organization %s exports %s to unknown organization %s", org, subject, target)
+			}
+
+			if target == org {
+				return fmt.Errorf("organization %s can not export %s to itself", org, subject)
+			}
+
+			var err error
+			if kind == "exports" {
+				err = acct.AddStreamExport(subject, []*natsd.Account{tacct})
+				if err == nil {
+					err = tacct.AddStreamImport(acct, subject, "")
+				}
+			} else {
+				err = acct.AddServiceExport(subject, []*natsd.Account{tacct})
+				if err == nil {
+					err = tacct.AddServiceImport(acct, subject, subject)
+				}
+			}
+			if err != nil {
+				return fmt.Errorf("could not export %s from organization %s to %s: %s", subject, org, target, err)
+			}
+
+			s.log.Infof("Exported %s from organization %s to %s", subject, org, target)
+		}
+	}
+
+	return nil
+}
+
+func validateOrganization(org string) error {
+	if !tokens.IsValidOrganization(org) {
+		return fmt.Errorf("invalid organization name %q", org)
+	}
+
+	for _, r := range reservedOrganization {
+		if strings.EqualFold(org, r) {
+			return fmt.Errorf("organization name %q is reserved", org)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package network
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/jwt/v2"
+	natsd "github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+
+	"github.com/choria-io/go-choria/backoff"
+	"github.com/choria-io/go-choria/providers/revocation"
+)
+
+const revokedAccountName = "revoked"
+
+func (s *Server) setupRevocations(auth *ChoriaAuth) error {
+	cfg := s.config.Choria
+	if cfg.RevocationFile == "" && !cfg.RevocationKV {
+		return nil
+	}
+
+	// revoked connections are moved to an account that allows no connections which
+	// makes the broker close them, nats-server has no other way to close a connection
+	acct, _ := s.gnatsd.LookupOrRegisterAccount(revokedAccountName)
+	if acct == nil {
+		return fmt.Errorf("could not create %s account", revokedAccountName)
+	}
+	claims := jwt.NewAccountClaims(revokedAccountName)
+	claims.Limits.Conn = 0
+	s.gnatsd.UpdateAccountClaims(acct, claims)
+
+	s.revocations = revocation.NewList()
+	s.revocations.OnRevoke(auth.disconnectRevoked)
+
+	auth.revocations = s.revocations
+	auth.revocationKV = cfg.RevocationKV
+	auth.revokedAccount = acct
+
+	if cfg.RevocationFile != "" {
+		err := s.revocations.LoadFile(cfg.RevocationFile)
+		if err != nil {
+			s.log.Errorf("Could not load revocation list %s: %s", cfg.RevocationFile, err)
+		}
+	}
+
+	if cfg.RevocationKV {
+		err := s.setupOrganizationRevocations()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// setupOrganizationRevocations imports the API needed to read the revocations bucket into organization accounts
+// on the revocation.ImportAPIPrefix prefix, messages are fetched using pull consumers as push consumers can not
+// deliver across accounts
+func (s *Server) setupOrganizationRevocations() error {
+	var accounts []*natsd.Account
+	for org, acct := range s.organizationAccounts {
+		if org != defaultOrganization {
+			accounts = append(accounts, acct)
+		}
+	}
+
+	if len(accounts) == 0 {
+		return nil
+	}
+
+	apis := map[string]natsd.ServiceRespType{
+		fmt.Sprintf("STREAM.INFO.%s", revocation.StreamName):         natsd.Singleton,
+		fmt.Sprintf("CONSUMER.CREATE.%s", revocation.StreamName):     natsd.Singleton,
+		fmt.Sprintf("CONSUMER.DELETE.%s.*", revocation.StreamName):   natsd.Singleton,
+		fmt.Sprintf("CONSUMER.MSG.NEXT.%s.*", revocation.StreamName): natsd.Streamed,
+	}
+
+	for api, rt := range apis {
+		subject := fmt.Sprintf("$JS.API.%s", api)
+
+		err := s.choriaAccount.AddServiceExportWithResponse(subject, rt, accounts)
+		if err != nil {
+			return fmt.Errorf("could not export %s to organizations: %s", subject, err)
+		}
+
+		for _, acct := range accounts {
+			err = acct.AddServiceImport(s.choriaAccount, fmt.Sprintf("%s.%s", revocation.ImportAPIPrefix, api), subject)
+			if err != nil {
+				return fmt.Errorf("could not import %s into organization %s: %s", subject, acct.Name, err)
+			}
+		}
+	}
+
+	ack := fmt.Sprintf("$JS.ACK.%s.>", revocation.StreamName)
+	err := s.choriaAccount.AddServiceExport(ack, accounts)
+	if err != nil {
+		return fmt.Errorf("could not export %s to organizations: %s", ack, err)
+	}
+
+	for _, acct := range accounts {
+		err = acct.AddServiceImport(s.choriaAccount, ack, ack)
+		if err != nil {
+			return fmt.Errorf("could not import %s into organization %s: %s", ack, acct.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) startRevocations(ctx context.Context, wg *sync.WaitGroup) {
+	if s.revocations == nil {
+		return
+	}
+
+	log := s.log.WithField("component", "revocation")
+
+	if s.config.Choria.RevocationFile != "" {
+		wg.Add(1)
+		go s.revocations.WatchFile(ctx, wg, s.config.Choria.RevocationFile, 10*time.Second, log)
+	}
+
+	if s.config.Choria.RevocationKV {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			var nc *nats.Conn
+			var err error
+
+			err = backoff.TwentySec.For(ctx, func(try int) error {
+				// in-process connections do not need tls
+				nc, err = nats.Connect(s.opts.ClientAdvertise, nats.InProcessServer(s), nats.Secure(&tls.Config{InsecureSkipVerify: true}))
+				if err != nil {
+					log.Warnf("Could not connect to broker using in-process connection to watch revocations: %s", err)
+					return err
+				}
+
+				return nil
+			})
+			if err != nil {
+				return
+			}
+			defer nc.Close()
+
+			wg.Add(1)
+			s.revocations.WatchBucket(ctx, wg, nc, log)
+		}()
+	}
+
+	wg.Add(1)
+	go s.pruneRevocationSessions(ctx, wg)
+}
+
+func (s *Server) pruneRevocationSessions(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	auth, ok := s.opts.CustomClientAuthentication.(*ChoriaAuth)
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			listed := time.Now()
+			connz, err := s.gnatsd.Connz(&natsd.ConnzOptions{Limit: s.gnatsd.NumClients() + 1024})
+			if err != nil {
+				s.log.Errorf("Could not retrieve connections to prune revocation sessions: %s", err)
+				continue
+			}
+
+			open := make(map[uint64]bool, len(connz.Conns))
+			for _, conn := range connz.Conns {
+				open[conn.Cid] = true
+			}
+
+			auth.pruneSessions(open, listed)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
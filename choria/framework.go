@@ -24,11 +24,13 @@ import (
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/providers/ddlresolver"
 	election "github.com/choria-io/go-choria/providers/election/streams"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/choria-io/go-choria/providers/kv"
 	"github.com/choria-io/go-choria/providers/provtarget"
 	"github.com/choria-io/go-choria/providers/signers"
 	"github.com/choria-io/go-choria/tokens"
 	"github.com/fatih/color"
+	"github.com/nats-io/jsm.go"
 	"github.com/nats-io/nats.go"
 	"golang.org/x/term"
 
@@ -939,6 +941,41 @@ func (fw *Framework) NewElectionWithConn(ctx context.Context, conn inter.Connect
 	return e, conn, nil
 }
 
+// NewGovernor creates a client for a Choria Governor that obtains leases using Start() or Acquire().
+// This will create a new network connection per governor, see NewGovernorWithConn() to re-use an existing connection
+func (fw *Framework) NewGovernor(ctx context.Context, conn inter.Connector, name string, opts ...governor.Option) (*governor.Governor, error) {
+	g, _, err := fw.NewGovernorWithConn(ctx, conn, name, opts...)
+
+	return g, err
+}
+
+// NewGovernorWithConn creates a client for a Choria Governor that obtains leases using Start() or Acquire().
+func (fw *Framework) NewGovernorWithConn(ctx context.Context, conn inter.Connector, name string, opts ...governor.Option) (*governor.Governor, inter.Connector, error) {
+	var err error
+
+	logger := fw.Logger("governor").WithField("governor", name)
+
+	if conn == nil {
+		conn, err = fw.NewConnector(ctx, fw.MiddlewareServers, fmt.Sprintf("governor %s %s", name, fw.Config.Identity), logger)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	mgr, err := jsm.New(conn.Nats())
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to Choria Streams: %s", err)
+	}
+
+	opts = append([]governor.Option{governor.WithSubject(fw.GovernorSubject(name)), governor.WithLogger(logger)}, opts...)
+	g, err := governor.New(name, mgr, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return g, conn, nil
+}
+
 // KV creates a connection to a key-value store and gives access to the connector
 func (fw *Framework) KV(ctx context.Context, conn inter.Connector, bucket string, create bool, opts ...kv.Option) (nats.KeyValue, error) {
 	kv, _, err := fw.KVWithConn(ctx, conn, bucket, create, opts...)
@@ -251,6 +251,16 @@ func (d *MachineStateOutput) Path() string {
 
 }
 
+// Paused is the value of the paused output
+//
+// Description: True when the autonomous agent is paused
+func (d *MachineStateOutput) Paused() bool {
+	val := d.reply["paused"]
+
+	return val.(bool)
+
+}
+
 // Scout is the value of the scout output
 //
 // Description: True when this autonomous agent represents a Choria Scout Check
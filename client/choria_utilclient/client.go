@@ -214,6 +214,113 @@ func (p *ChoriaUtilClient) Info() *InfoRequester {
 	return d
 }
 
+// MachineDataPut performs the machine_data_put action
+//
+// Description: Stores a data item in a hosted Choria Autonomous Agent
+//
+// Required Inputs:
+//   - key (string) - The data item to store
+//   - value (string) - The value to store, JSON values are stored as their parsed type
+//
+// Optional Inputs:
+//   - instance (string) - Machine Instance ID
+//   - name (string) - Machine Name
+//   - path (string) - Machine Path
+//   - version (string) - Machine Version
+func (p *ChoriaUtilClient) MachineDataPut(inputKey string, inputValue string) *MachineDataPutRequester {
+	d := &MachineDataPutRequester{
+		outc: nil,
+		r: &requester{
+			args: map[string]any{
+				"key":   inputKey,
+				"value": inputValue,
+			},
+			action: "machine_data_put",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
+// MachinePause performs the machine_pause action
+//
+// Description: Pauses a hosted Choria Autonomous Agent, paused machines do not run watchers or transition
+//
+// Optional Inputs:
+//   - instance (string) - Machine Instance ID
+//   - name (string) - Machine Name
+//   - path (string) - Machine Path
+//   - version (string) - Machine Version
+func (p *ChoriaUtilClient) MachinePause() *MachinePauseRequester {
+	d := &MachinePauseRequester{
+		outc: nil,
+		r: &requester{
+			args:   map[string]any{},
+			action: "machine_pause",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
+// MachineReload performs the machine_reload action
+//
+// Description: Reloads a hosted Choria Autonomous Agent from its source directory
+//
+// Optional Inputs:
+//   - instance (string) - Machine Instance ID
+//   - name (string) - Machine Name
+//   - path (string) - Machine Path
+//   - version (string) - Machine Version
+func (p *ChoriaUtilClient) MachineReload() *MachineReloadRequester {
+	d := &MachineReloadRequester{
+		outc: nil,
+		r: &requester{
+			args:   map[string]any{},
+			action: "machine_reload",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
+// MachineResume performs the machine_resume action
+//
+// Description: Resumes a paused hosted Choria Autonomous Agent
+//
+// Optional Inputs:
+//   - instance (string) - Machine Instance ID
+//   - name (string) - Machine Name
+//   - path (string) - Machine Path
+//   - version (string) - Machine Version
+func (p *ChoriaUtilClient) MachineResume() *MachineResumeRequester {
+	d := &MachineResumeRequester{
+		outc: nil,
+		r: &requester{
+			args:   map[string]any{},
+			action: "machine_resume",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
 // MachineState performs the machine_state action
 //
 // Description: Retrieves the current state of a specific Choria Autonomous Agent
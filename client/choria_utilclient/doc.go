@@ -6,6 +6,10 @@
 //
 // Actions:
 //   - Info - Choria related information from the running Daemon and Middleware
+//   - MachineDataPut - Stores a data item in a hosted Choria Autonomous Agent
+//   - MachinePause - Pauses a hosted Choria Autonomous Agent, paused machines do not run watchers or transition
+//   - MachineReload - Reloads a hosted Choria Autonomous Agent from its source directory
+//   - MachineResume - Resumes a paused hosted Choria Autonomous Agent
 //   - MachineState - Retrieves the current state of a specific Choria Autonomous Agent
 //   - MachineStates - States of the hosted Choria Autonomous Agents
 //   - MachineTransition - Attempts to force a transition in a hosted Choria Autonomous Agent
@@ -0,0 +1,190 @@
+// generated code; DO NOT EDIT
+//
+// Client for Choria RPC Agent 'scout' Version 0.26.0 generated using Choria version 0.26.0
+
+package scoutclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/choria-io/go-choria/protocol"
+	rpcclient "github.com/choria-io/go-choria/providers/agent/mcorpc/client"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/ddl/agent"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc/replyfmt"
+)
+
+// HistoryRequester performs a RPC request to scout#history
+type HistoryRequester struct {
+	r    *requester
+	outc chan *HistoryOutput
+}
+
+// HistoryOutput is the output from the history action
+type HistoryOutput struct {
+	details *ResultDetails
+	reply   map[string]any
+}
+
+// HistoryResult is the result from a history action
+type HistoryResult struct {
+	ddl        *agent.DDL
+	stats      *rpcclient.Stats
+	outputs    []*HistoryOutput
+	rpcreplies []*replyfmt.RPCReply
+	mu         sync.Mutex
+}
+
+func (d *HistoryResult) RenderResults(w io.Writer, format RenderFormat, displayMode DisplayMode, verbose bool, silent bool, colorize bool, log Log) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.stats == nil {
+		return fmt.Errorf("result stats is not set, result was not completed")
+	}
+
+	results := &replyfmt.RPCResults{
+		Agent:   d.stats.Agent(),
+		Action:  d.stats.Action(),
+		Replies: d.rpcreplies,
+		Stats:   d.stats,
+	}
+
+	addl, err := d.ddl.ActionInterface(d.stats.Action())
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case JSONFormat:
+		return results.RenderJSON(w, addl)
+	case TableFormat:
+		return results.RenderTable(w, addl)
+	case TXTFooter:
+		results.RenderTXTFooter(w, verbose)
+		return nil
+	default:
+		return results.RenderTXT(w, addl, verbose, silent, replyfmt.DisplayMode(displayMode), colorize, log)
+	}
+}
+
+// Stats is the rpc request stats
+func (d *HistoryResult) Stats() Stats {
+	return d.stats
+}
+
+// ResultDetails is the details about the request
+func (d *HistoryOutput) ResultDetails() *ResultDetails {
+	return d.details
+}
+
+// HashMap is the raw output data
+func (d *HistoryOutput) HashMap() map[string]any {
+	return d.reply
+}
+
+// JSON is the JSON representation of the output data
+func (d *HistoryOutput) JSON() ([]byte, error) {
+	return json.Marshal(d.reply)
+}
+
+// ParseHistoryOutput parses the result value from the History action into target
+func (d *HistoryOutput) ParseHistoryOutput(target any) error {
+	j, err := d.JSON()
+	if err != nil {
+		return fmt.Errorf("could not access payload: %s", err)
+	}
+
+	err = json.Unmarshal(j, target)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal JSON payload: %s", err)
+	}
+
+	return nil
+}
+
+// Do performs the request
+func (d *HistoryRequester) Do(ctx context.Context) (*HistoryResult, error) {
+	dres := &HistoryResult{ddl: d.r.client.ddl}
+
+	handler := func(pr protocol.Reply, r *rpcclient.RPCReply) {
+		// filtered by expr filter
+		if r == nil {
+			return
+		}
+
+		output := &HistoryOutput{
+			reply: make(map[string]any),
+			details: &ResultDetails{
+				sender:  pr.SenderID(),
+				code:    int(r.Statuscode),
+				message: r.Statusmsg,
+				ts:      pr.Time(),
+			},
+		}
+
+		err := json.Unmarshal(r.Data, &output.reply)
+		if err != nil {
+			d.r.client.errorf("Could not decode reply from %s: %s", pr.SenderID(), err)
+		}
+
+		// caller wants a channel so we dont return a resulset too (lots of memory etc)
+		// this is unused now, no support for setting a channel
+		if d.outc != nil {
+			d.outc <- output
+			return
+		}
+
+		// else prepare our result set
+		dres.mu.Lock()
+		dres.outputs = append(dres.outputs, output)
+		dres.rpcreplies = append(dres.rpcreplies, &replyfmt.RPCReply{
+			Sender:   pr.SenderID(),
+			RPCReply: r,
+		})
+		dres.mu.Unlock()
+	}
+
+	res, err := d.r.do(ctx, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	dres.stats = res
+
+	return dres, nil
+}
+
+// AllOutputs provide access to all outputs
+func (d *HistoryResult) AllOutputs() []*HistoryOutput {
+	return d.outputs
+}
+
+// EachOutput iterates over all results received
+func (d *HistoryResult) EachOutput(h func(r *HistoryOutput)) {
+	for _, resp := range d.outputs {
+		h(resp)
+	}
+}
+
+// Checks is an optional input to the history action
+//
+// Description: Checks to retrieve history for, empty means all
+func (d *HistoryRequester) Checks(v []any) *HistoryRequester {
+	d.r.args["checks"] = v
+
+	return d
+}
+
+// Checks is the value of the checks output
+//
+// Description: History for each check
+func (d *HistoryOutput) Checks() []any {
+	val := d.reply["checks"]
+
+	return val.([]any)
+
+}
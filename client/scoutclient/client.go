@@ -214,6 +214,28 @@ func (p *ScoutClient) Checks() *ChecksRequester {
 	return d
 }
 
+// History performs the history action
+//
+// Description: Obtain the history of one or more checks
+//
+// Optional Inputs:
+//   - checks ([]any) - Checks to retrieve history for, empty means all
+func (p *ScoutClient) History() *HistoryRequester {
+	d := &HistoryRequester{
+		outc: nil,
+		r: &requester{
+			args:   map[string]any{},
+			action: "history",
+			client: p,
+		},
+	}
+
+	action, _ := p.ddl.ActionInterface(d.r.action)
+	action.SetDefaults(d.r.args)
+
+	return d
+}
+
 // Resume performs the resume action
 //
 // Description: Resume active checking of one or more checks
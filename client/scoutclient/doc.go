@@ -6,6 +6,7 @@
 //
 // Actions:
 //   - Checks - Obtain a list of checks and their current status
+//   - History - Obtain the history of one or more checks
 //   - Resume - Resume active checking of one or more checks
 //   - Maintenance - Pause checking of one or more checks
 //   - GossValidate - Performs a Goss validation using a specific file
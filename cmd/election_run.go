@@ -11,12 +11,15 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"strings"
 	"sync"
 	"syscall"
 	"time"
 
+	"github.com/choria-io/go-choria/inter"
 	iu "github.com/choria-io/go-choria/internal/util"
 	election "github.com/choria-io/go-choria/providers/election/streams"
 	"github.com/kballard/go-shellquote"
@@ -34,6 +37,11 @@ type tElectRunCommand struct {
 	executable string
 	args       []string
 	signaled   bool
+	leading    bool
+	handover   time.Duration
+	stopGrace  time.Duration
+	tokenFile  string
+	election   inter.Election
 
 	log *logrus.Entry
 	mu  sync.Mutex
@@ -45,6 +53,8 @@ func (f *tElectRunCommand) Setup() (err error) {
 		f.cmd.Arg("name", "The name for the Leader Election to campaign in").Required().StringVar(&f.name)
 		f.cmd.Arg("command", "Command to execute").Required().StringsVar(&f.fullCmd)
 		f.cmd.Flag("terminate", "Terminates the command when leadership is lost").UnNegatableBoolVar(&f.killOnLost)
+		f.cmd.Flag("handover", "Time allowed for a previous leader to stop before starting the command").Default("1s").DurationVar(&f.handover)
+		f.cmd.Flag("stop-grace", "Time allowed for the command to stop after SIGINT before sending SIGTERM when terminated").Default("1s").DurationVar(&f.stopGrace)
 		f.cmd.Flag("bucket", "Use a specific bucket for elections").Default("CHORIA_LEADER_ELECTION").StringVar(&f.bucket)
 	}
 
@@ -73,6 +83,7 @@ func (f *tElectRunCommand) Configure() (err error) {
 func (f *tElectRunCommand) handleLeaderState() {
 	f.mu.Lock()
 	f.state = election.LeaderState
+	f.leading = true
 	proc := f.proc
 	signaled := f.signaled
 	f.mu.Unlock()
@@ -81,6 +92,7 @@ func (f *tElectRunCommand) handleLeaderState() {
 		if !signaled {
 			p := f.proc.Process
 			if p != nil {
+				f.writeToken()
 				f.log.Infof("Sending USR1 to %d", p.Pid)
 				p.Signal(syscall.SIGUSR1)
 				f.mu.Lock()
@@ -104,6 +116,7 @@ func (f *tElectRunCommand) handleCampaignerState() {
 		lost = true
 		f.signaled = false
 	}
+	f.leading = false
 
 	if f.proc == nil || !lost {
 		return
@@ -119,7 +132,7 @@ func (f *tElectRunCommand) handleCampaignerState() {
 			f.log.Warnf("Sending SIGINT to %d", process.Pid)
 			process.Signal(syscall.SIGINT)
 
-			if iu.InterruptibleSleep(ctx, time.Second) == context.Canceled {
+			if iu.InterruptibleSleep(ctx, f.stopGrace) == context.Canceled {
 				return
 			}
 		}
@@ -134,40 +147,104 @@ func (f *tElectRunCommand) handleCampaignerState() {
 func (f *tElectRunCommand) campaign(s election.State) {
 	switch s {
 	case election.LeaderState:
-		f.handleLeaderState()
+		// we only act as leader once notified of winning after the handover grace period
+		f.mu.Lock()
+		leading := f.leading
+		f.mu.Unlock()
+
+		if leading {
+			f.handleLeaderState()
+		}
 	default:
 		f.handleCampaignerState()
 	}
 }
 
 func (f *tElectRunCommand) won() {
-	f.log.Infof("Became leader")
+	f.log.Infof("Became leader with fencing token %d", f.election.Token())
+	f.handleLeaderState()
+}
+
+// stepDownOnSignal gives up leadership on SIGHUP to allow leadership to be moved during maintenance
+func (f *tElectRunCommand) stepDownOnSignal() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGHUP)
+
+	for {
+		select {
+		case <-sigs:
+			f.log.Warnf("Stepping down from leadership on SIGHUP")
+			err := f.election.StepDown()
+			if err != nil {
+				f.log.Errorf("Could not step down: %v", err)
+			}
+
+		case <-ctx.Done():
+			signal.Stop(sigs)
+			return
+		}
+	}
 }
 
 func (f *tElectRunCommand) lost() {
 	f.handleCampaignerState()
 }
 
+// writeToken writes the current fencing token to the token file, it's called before every SIGUSR1 so that
+// commands that keep running between terms can read the token of their current term
+func (f *tElectRunCommand) writeToken() {
+	tf, err := os.CreateTemp(filepath.Dir(f.tokenFile), filepath.Base(f.tokenFile))
+	if err != nil {
+		f.log.Errorf("Could not write fencing token: %v", err)
+		return
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = fmt.Fprintf(tf, "%d\n", f.election.Token())
+	tf.Close()
+	if err != nil {
+		f.log.Errorf("Could not write fencing token: %v", err)
+		return
+	}
+
+	err = os.Rename(tf.Name(), f.tokenFile)
+	if err != nil {
+		f.log.Errorf("Could not write fencing token: %v", err)
+	}
+}
+
+func (f *tElectRunCommand) exit(code int) {
+	os.Remove(f.tokenFile)
+	os.Exit(code)
+}
+
 func (f *tElectRunCommand) runCommand() {
 	f.log.Infof("Running command %q with %s", f.executable, f.args)
+	f.writeToken()
 	f.proc = exec.Command(f.executable, f.args...)
+	f.proc.Env = append(os.Environ(),
+		fmt.Sprintf("CHORIA_ELECTION_NAME=%s", f.name),
+		fmt.Sprintf("CHORIA_ELECTION_BUCKET=%s", f.bucket),
+		fmt.Sprintf("CHORIA_ELECTION_TOKEN=%d", f.election.Token()),
+		fmt.Sprintf("CHORIA_ELECTION_TOKEN_FILE=%s", f.tokenFile))
 	f.proc.Stdin = os.Stdin
 	f.proc.Stdout = os.Stdout
 	f.proc.Stderr = os.Stderr
 	err = f.proc.Start()
 	if err != nil {
 		f.log.Errorf("Execution failed: %v", err)
-		os.Exit(1)
+		f.exit(1)
 	}
 
 	// give it some time to start properly
 	if iu.InterruptibleSleep(ctx, time.Second) == context.Canceled {
 		f.log.Errorf("Exiting on context interrupt")
-		os.Exit(1)
+		f.exit(1)
 	}
 
 	if f.proc.Process != nil {
 		f.mu.Lock()
+		f.writeToken()
 		f.proc.Process.Signal(syscall.SIGUSR1)
 		f.signaled = true
 		f.mu.Unlock()
@@ -182,14 +259,14 @@ func (f *tElectRunCommand) runCommand() {
 				code = 1
 			}
 			f.log.Errorf("Execution failed with exit code: %d", code)
-			os.Exit(code)
+			f.exit(code)
 		} else {
 			f.log.Errorf("Execution failed: %v", err)
-			os.Exit(1)
+			f.exit(1)
 		}
 	}
 
-	os.Exit(0)
+	f.exit(0)
 }
 
 func (f *tElectRunCommand) Run(wg *sync.WaitGroup) (err error) {
@@ -212,12 +289,22 @@ func (f *tElectRunCommand) Run(wg *sync.WaitGroup) (err error) {
 		return fmt.Errorf("cannot access KV Bucket %s: %v", f.bucket, err)
 	}
 
-	el, err := election.NewElection(c.Config.Identity, f.name, kv, election.OnLost(f.lost), election.OnCampaign(f.campaign), election.OnWon(f.won), election.WithDebug(f.log.Infof))
+	tf, err := os.CreateTemp("", "choria-election-token")
+	if err != nil {
+		return fmt.Errorf("could not create fencing token file: %v", err)
+	}
+	tf.Close()
+	f.tokenFile = tf.Name()
+	defer os.Remove(f.tokenFile)
+
+	f.election, err = election.NewElection(c.Config.Identity, f.name, kv, election.OnLost(f.lost), election.OnCampaign(f.campaign), election.OnWon(f.won), election.WithHandoverGrace(f.handover), election.WithDebug(f.log.Infof))
 	if err != nil {
 		return err
 	}
 
-	return el.Start(ctx)
+	go f.stepDownOnSignal()
+
+	return f.election.Start(ctx)
 }
 
 func init() {
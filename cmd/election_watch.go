@@ -0,0 +1,87 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	election "github.com/choria-io/go-choria/providers/election/streams"
+)
+
+type tElectionWatchCommand struct {
+	command
+
+	election string
+	bucket   string
+	json     bool
+}
+
+func (w *tElectionWatchCommand) Setup() (err error) {
+	if elect, ok := cmdWithFullCommand("election"); ok {
+		w.cmd = elect.Cmd().Command("watch", "Watch leadership changes in an Election bucket").Alias("w")
+		w.cmd.Arg("election", "Limit to a specific election").StringVar(&w.election)
+		w.cmd.Flag("bucket", "Use a specific bucket for elections").Default("CHORIA_LEADER_ELECTION").StringVar(&w.bucket)
+		w.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&w.json)
+	}
+
+	return nil
+}
+
+func (w *tElectionWatchCommand) Configure() (err error) {
+	return commonConfigure()
+}
+
+func (w *tElectionWatchCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	logger := c.Logger("election")
+
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, fmt.Sprintf("election %s %s", w.bucket, c.Config.Identity), logger)
+	if err != nil {
+		return err
+	}
+
+	js, err := conn.Nats().JetStream()
+	if err != nil {
+		return err
+	}
+
+	kv, err := js.KeyValue(w.bucket)
+	if err != nil {
+		return fmt.Errorf("cannot access KV Bucket %s: %v", w.bucket, err)
+	}
+
+	return election.Observe(ctx, kv, w.election, w.show)
+}
+
+func (w *tElectionWatchCommand) show(l election.Leadership) {
+	if w.json {
+		j, err := json.Marshal(l)
+		if err != nil {
+			return
+		}
+		fmt.Println(string(j))
+
+		return
+	}
+
+	ts := l.Since.Local().Format(time.RFC3339)
+
+	switch {
+	case l.Leader == "":
+		fmt.Printf("[%s] %s: leader %s was evicted\n", ts, l.Election, l.Previous)
+	case l.Previous == "":
+		fmt.Printf("[%s] %s: %s is the leader\n", ts, l.Election, l.Leader)
+	default:
+		fmt.Printf("[%s] %s: %s took over leadership from %s\n", ts, l.Election, l.Leader, l.Previous)
+	}
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tElectionWatchCommand{})
+}
@@ -16,9 +16,9 @@ import (
 
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/lifecycle"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/kballard/go-shellquote"
 	"github.com/nats-io/jsm.go"
-	"github.com/nats-io/jsm.go/governor"
 )
 
 type tGovRunCommand struct {
@@ -28,6 +28,8 @@ type tGovRunCommand struct {
 	maxWait  time.Duration
 	interval time.Duration
 	noLEave  bool
+	weight   int
+	priority string
 }
 
 func (g *tGovRunCommand) Setup() (err error) {
@@ -38,6 +40,8 @@ func (g *tGovRunCommand) Setup() (err error) {
 		g.cmd.Flag("max-wait", "Maximum amount of time to wait to obtain a lease").Default("5m").DurationVar(&g.maxWait)
 		g.cmd.Flag("interval", "Interval for attempting to get a lease").Default("5s").DurationVar(&g.interval)
 		g.cmd.Flag("max-per-period", "Instead of limiting concurrent runs, limit runs per governor period").UnNegatableBoolVar(&g.noLEave)
+		g.cmd.Flag("weight", "How many slots in the Governor the command consumes").Default("1").IntVar(&g.weight)
+		g.cmd.Flag("priority", "The priority class used when waiting for a lease").Default("normal").EnumVar(&g.priority, governor.PriorityNames...)
 	}
 
 	return nil
@@ -48,8 +52,8 @@ func (g *tGovRunCommand) Configure() (err error) {
 	return systemConfigureIfRoot(true)
 }
 
-func (g *tGovRunCommand) trySendEvent(et lifecycle.GovernorEventType, seq uint64, conn inter.RawNATSConnector) {
-	event, err := lifecycle.New(lifecycle.Governor, lifecycle.Component("CLI"), lifecycle.Identity(c.Config.Identity), lifecycle.GovernorType(et), lifecycle.GovernorName(g.name), lifecycle.GovernorSequence(seq))
+func (g *tGovRunCommand) trySendEvent(et lifecycle.GovernorEventType, seq uint64, duration time.Duration, conn inter.RawNATSConnector) {
+	event, err := lifecycle.New(lifecycle.Governor, lifecycle.Component("CLI"), lifecycle.Identity(c.Config.Identity), lifecycle.GovernorType(et), lifecycle.GovernorName(g.name), lifecycle.GovernorSequence(seq), lifecycle.GovernorDuration(duration))
 	if err == nil {
 		lifecycle.PublishEvent(event, conn)
 	}
@@ -62,6 +66,15 @@ func (g *tGovRunCommand) Run(wg *sync.WaitGroup) (err error) {
 		return fmt.Errorf("interval should be >=1s")
 	}
 
+	if g.weight < 1 {
+		return fmt.Errorf("weight should be >=1")
+	}
+
+	priority, err := governor.ParsePriority(g.priority)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(ctx, g.maxWait)
 	defer cancel()
 
@@ -97,30 +110,39 @@ func (g *tGovRunCommand) Run(wg *sync.WaitGroup) (err error) {
 		governor.WithSubject(c.GovernorSubject(g.name)),
 		governor.WithInterval(g.interval),
 		governor.WithLogger(log),
+		governor.WithWeight(g.weight),
+		governor.WithPriority(priority),
+		governor.WithWaitingCallback(func() { g.trySendEvent(lifecycle.GovernorWaitingEvent, 0, 0, conn) }),
 	}
 
 	if g.noLEave {
 		opts = append(opts, governor.WithoutLeavingOnCompletion())
 	}
 
-	gov := governor.NewJSGovernor(g.name, mgr, opts...)
+	gov, err := governor.New(g.name, mgr, opts...)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
 	finisher, seq, err := gov.Start(ctx, cfg.Identity)
 	if err != nil {
 		if g.noLEave && err == context.DeadlineExceeded {
 			return nil
 		}
 
-		g.trySendEvent(lifecycle.GovernorTimeoutEvent, 0, conn)
+		g.trySendEvent(lifecycle.GovernorTimeoutEvent, 0, time.Since(start), conn)
 		return fmt.Errorf("could not get a execution slot: %s", err)
 	}
 
-	g.trySendEvent(lifecycle.GovernorEnterEvent, seq, conn)
+	g.trySendEvent(lifecycle.GovernorEnterEvent, seq, time.Since(start), conn)
+	entered := time.Now()
 
 	finish := func(wg *sync.WaitGroup) {
 		defer wg.Done()
 
 		finisher()
-		g.trySendEvent(lifecycle.GovernorExitEvent, seq, conn)
+		g.trySendEvent(lifecycle.GovernorExitEvent, seq, time.Since(entered), conn)
 		conn.Close()
 	}
 
@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -11,8 +11,9 @@ import (
 
 	"github.com/choria-io/go-choria/choria"
 	"github.com/choria-io/go-choria/internal/util"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/nats-io/jsm.go"
-	"github.com/nats-io/jsm.go/governor"
+	jsmgov "github.com/nats-io/jsm.go/governor"
 )
 
 type tGovViewCommand struct {
@@ -46,7 +47,7 @@ func (g *tGovViewCommand) Run(wg *sync.WaitGroup) (err error) {
 		return err
 	}
 
-	gov, err := governor.NewJSGovernorManager(g.name, 0, 0, 1, mgr, false)
+	gov, err := jsmgov.NewJSGovernorManager(g.name, 0, 0, 1, mgr, false)
 	if err != nil {
 		return err
 	}
@@ -62,13 +63,17 @@ func (g *tGovViewCommand) Run(wg *sync.WaitGroup) (err error) {
 		return err
 	}
 
-	fmt.Printf("  Active Leases: %d\n", nfo.State.Msgs)
-	fmt.Println()
+	type lease struct {
+		seq      uint64
+		name     string
+		weight   int
+		priority string
+		age      time.Duration
+	}
 
-	if nfo.State.Msgs > 0 {
-		fmt.Println()
-		table := util.NewUTF8Table("ID", "Process Name", "Age")
+	var leases []*lease
 
+	if nfo.State.Msgs > 0 {
 		sub, err := conn.Nats().SubscribeSync(choria.Inbox(cfg.MainCollective, cfg.Identity))
 		if err != nil {
 			return err
@@ -80,6 +85,8 @@ func (g *tGovViewCommand) Run(wg *sync.WaitGroup) (err error) {
 			return err
 		}
 
+		byID := map[string]*lease{}
+
 		for {
 			msg, err := sub.NextMsg(time.Second)
 			if err != nil {
@@ -91,12 +98,54 @@ func (g *tGovViewCommand) Run(wg *sync.WaitGroup) (err error) {
 				continue
 			}
 
-			table.AddRow(meta.StreamSequence(), string(msg.Data), fmt.Sprintf("%v", time.Since(meta.TimeStamp()).Round(time.Millisecond)))
+			id := msg.Header.Get(governor.LeaseHeader)
+			if l, ok := byID[id]; ok && id != "" {
+				l.weight++
+			} else {
+				l = &lease{seq: meta.StreamSequence(), name: string(msg.Data), weight: 1, priority: msg.Header.Get(governor.PriorityHeader), age: time.Since(meta.TimeStamp())}
+				if l.priority == "" {
+					l.priority = governor.NormalPriority.String()
+				}
+				byID[id] = l
+				leases = append(leases, l)
+			}
+
 			if meta.Pending() == 0 {
 				break
 			}
 		}
+	}
+
+	fmt.Printf("  Used Capacity: %d\n", nfo.State.Msgs)
+	fmt.Printf("  Active Leases: %d\n", len(leases))
+
+	var waiters []*governor.Waiter
+	queue, err := governor.WaitQueue(conn.Nats())
+	if err == nil {
+		waiters, err = governor.Waiters(queue, g.name)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("        Waiting: %d\n", len(waiters))
+	fmt.Println()
+
+	if len(leases) > 0 {
+		fmt.Println()
+		table := util.NewUTF8Table("ID", "Process Name", "Weight", "Priority", "Age")
+		for _, l := range leases {
+			table.AddRow(l.seq, l.name, l.weight, l.priority, l.age.Round(time.Millisecond))
+		}
+		fmt.Println(table.Render())
+	}
 
+	if len(waiters) > 0 {
+		fmt.Println()
+		table := util.NewUTF8Table("Position", "Waiting Process Name", "Weight", "Priority", "Waiting")
+		for i, w := range waiters {
+			table.AddRow(i+1, w.Name, w.Weight, w.Priority, time.Since(w.Since).Round(time.Millisecond))
+		}
 		fmt.Println(table.Render())
 	}
 
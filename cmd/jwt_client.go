@@ -7,6 +7,7 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +32,8 @@ type jWTCreateClientCommand struct {
 	service       bool
 	system        bool
 	pk            string
+	kvRead        []string
+	kvWrite       []string
 
 	command
 }
@@ -42,17 +45,19 @@ func (c *jWTCreateClientCommand) Setup() (err error) {
 		c.cmd.Arg("identity", "The Caller ID for this user").Required().StringVar(&c.identity)
 		c.cmd.Arg("signing-key", "Path to a private key used to sign the JWT").Required().ExistingFileVar(&c.signingKey)
 		c.cmd.Flag("agents", "Allow the user to access certain agents").StringsVar(&c.agents)
-		c.cmd.Flag("org", "Adds the user to a specific organization").Default("choria").StringVar(&c.org)
+		c.cmd.Flag("org", "Adds the user to a specific organization, brokers hosting multiple organizations place it in that organization's account").Default("choria").StringVar(&c.org)
 		c.cmd.Flag("opa-file", "Path to a file holding a Open Policy Agent Policy for this user").ExistingFileVar(&c.opaPolicyFile)
 		c.cmd.Flag("opa", "Open Policy Agent Policy as a string").StringVar(&c.opaPolicy)
 		c.cmd.Flag("validity", "How long the token should be valid for").Default("1h").DurationVar(&c.validity)
 		c.cmd.Flag("public-key", "Ed25519 public key to embed in the token").StringVar(&c.pk)
 		c.cmd.Flag("stream-admin", "Allow the user to administer and use Choria Streams").UnNegatableBoolVar(&c.streamAdmin)
-		c.cmd.Flag("stream-user", "Allow the user to use Choria Streams").UnNegatableBoolVar(&c.streamUser)
+		c.cmd.Flag("stream-user", "Allow the user to use Choria Streams, this includes reading and deleting data in all Key-Value buckets").UnNegatableBoolVar(&c.streamUser)
 		c.cmd.Flag("event-viewer", "Allow the user to view various Choria Events").UnNegatableBoolVar(&c.eventViewer)
 		c.cmd.Flag("elections-user", "Allow the user to use Choria Elections").UnNegatableBoolVar(&c.electionUser)
 		c.cmd.Flag("service", "Indicates that the user can have long validity tokens").UnNegatableBoolVar(&c.service)
 		c.cmd.Flag("system", "Allow the user to access the broker system account").UnNegatableBoolVar(&c.system)
+		c.cmd.Flag("kv-read", "Allow the user to read a Key-Value bucket or keys in BUCKET:PREFIX format, reading by prefix requires buckets with direct get enabled").PlaceHolder("BUCKET").StringsVar(&c.kvRead)
+		c.cmd.Flag("kv-write", "Allow the user to write to a Key-Value bucket").PlaceHolder("BUCKET").StringsVar(&c.kvWrite)
 	}
 
 	return nil
@@ -104,6 +109,11 @@ func (c *jWTCreateClientCommand) createJWT() error {
 		SystemUser:              c.system,
 	}
 
+	perms.KV, err = c.kvPermissions()
+	if err != nil {
+		return err
+	}
+
 	claims, err := tokens.NewClientIDClaims(c.identity, c.agents, c.org, nil, string(opa), "Choria CLI", c.validity, perms, []byte(c.pk))
 	if err != nil {
 		return err
@@ -119,6 +129,51 @@ func (c *jWTCreateClientCommand) createJWT() error {
 	return nil
 }
 
+func (c *jWTCreateClientCommand) kvPermissions() ([]*tokens.KVPermission, error) {
+	var grants []*tokens.KVPermission
+
+	grant := func(spec string) (*tokens.KVPermission, error) {
+		bucket, prefix, _ := strings.Cut(spec, ":")
+		for _, g := range grants {
+			if g.Bucket == bucket && g.Prefix == prefix {
+				return g, nil
+			}
+		}
+
+		g := &tokens.KVPermission{Bucket: bucket, Prefix: prefix}
+		err := g.Validate()
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, g)
+
+		return g, nil
+	}
+
+	for _, spec := range c.kvRead {
+		g, err := grant(spec)
+		if err != nil {
+			return nil, err
+		}
+		g.Read = true
+	}
+
+	for _, spec := range c.kvWrite {
+		g, err := grant(spec)
+		if err != nil {
+			return nil, err
+		}
+		g.Write = true
+
+		err = g.Validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return grants, nil
+}
+
 func init() {
 	cli.commands = append(cli.commands, &jWTCreateClientCommand{})
 }
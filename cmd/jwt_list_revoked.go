@@ -0,0 +1,109 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/config"
+	iu "github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/revocation"
+)
+
+type tJWTListRevokedCommand struct {
+	file string
+	json bool
+
+	command
+}
+
+func (l *tJWTListRevokedCommand) Setup() (err error) {
+	if jwt, ok := cmdWithFullCommand("jwt"); ok {
+		l.cmd = jwt.Cmd().Command("list-revoked", "List revoked JWT tokens, callers, identities and public keys").Alias("revoked")
+		l.cmd.Flag("file", "Reads a revocation list file rather than the Choria Streams bucket").ExistingFileVar(&l.file)
+		l.cmd.Flag("json", "Render the revocations as JSON").UnNegatableBoolVar(&l.json)
+	}
+
+	return nil
+}
+
+func (l *tJWTListRevokedCommand) Configure() error {
+	if l.file == "" {
+		return commonConfigure()
+	}
+
+	cfg, err = config.NewDefaultConfig()
+	if err != nil {
+		return fmt.Errorf("could not create default configuration: %s", err)
+	}
+
+	cfg.DisableSecurityProviderVerify = true
+	cfg.Choria.SecurityProvider = "file"
+
+	return nil
+}
+
+func (l *tJWTListRevokedCommand) loadBucket() ([]*revocation.Revocation, error) {
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, c.CallerID(), c.Logger("revocation"))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	bucket, err := revocation.Bucket(conn.Nats(), false, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	return revocation.LoadBucket(bucket)
+}
+
+func (l *tJWTListRevokedCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	var revs []*revocation.Revocation
+	if l.file != "" {
+		revs, err = revocation.ReadFile(l.file)
+	} else {
+		revs, err = l.loadBucket()
+	}
+	if err != nil {
+		return err
+	}
+
+	if l.json {
+		if revs == nil {
+			revs = []*revocation.Revocation{}
+		}
+
+		j, err := json.MarshalIndent(revs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+
+		return nil
+	}
+
+	if len(revs) == 0 {
+		fmt.Println("No revocations found")
+		return nil
+	}
+
+	table := iu.NewUTF8Table("Kind", "Value", "Reason", "Revoked")
+	for _, r := range revs {
+		table.AddRow(r.Kind, r.Value, r.Reason, r.Created.Local().Format(time.RFC3339))
+	}
+	fmt.Println(table.Render())
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tJWTListRevokedCommand{})
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/providers/revocation"
+	"github.com/choria-io/go-choria/tokens"
+)
+
+type tJWTRevokeCommand struct {
+	token    string
+	id       []string
+	callers  []string
+	ids      []string
+	keys     []string
+	reason   string
+	file     string
+	replicas int
+	remove   bool
+
+	command
+}
+
+func (r *tJWTRevokeCommand) Setup() (err error) {
+	if jwt, ok := cmdWithFullCommand("jwt"); ok {
+		r.cmd = jwt.Cmd().Command("revoke", "Revoke JWT tokens, callers, identities or public keys, updating the Choria Streams bucket requires choria organization administrator access")
+		r.cmd.Arg("token", "A client or server JWT file to revoke").ExistingFileVar(&r.token)
+		r.cmd.Flag("id", "Revoke a token by its unique ID").PlaceHolder("ID").StringsVar(&r.id)
+		r.cmd.Flag("caller", "Revoke all tokens for a caller").PlaceHolder("CALLER").StringsVar(&r.callers)
+		r.cmd.Flag("identity", "Revoke all tokens for a server identity").PlaceHolder("IDENTITY").StringsVar(&r.ids)
+		r.cmd.Flag("public-key", "Revoke all tokens holding a public key").PlaceHolder("KEY").StringsVar(&r.keys)
+		r.cmd.Flag("reason", "The reason for revoking access").StringVar(&r.reason)
+		r.cmd.Flag("file", "Updates a revocation list file rather than the Choria Streams bucket").StringVar(&r.file)
+		r.cmd.Flag("replicas", "Number of replicas to create the Choria Streams bucket with").Default("1").IntVar(&r.replicas)
+		r.cmd.Flag("remove", "Removes previous revocations rather than adding new ones").UnNegatableBoolVar(&r.remove)
+	}
+
+	return nil
+}
+
+func (r *tJWTRevokeCommand) Configure() error {
+	if r.file == "" {
+		return commonConfigure()
+	}
+
+	cfg, err = config.NewDefaultConfig()
+	if err != nil {
+		return fmt.Errorf("could not create default configuration: %s", err)
+	}
+
+	cfg.DisableSecurityProviderVerify = true
+	cfg.Choria.SecurityProvider = "file"
+
+	return nil
+}
+
+func (r *tJWTRevokeCommand) revocations() ([]*revocation.Revocation, error) {
+	var res []*revocation.Revocation
+
+	add := func(kind revocation.Kind, values ...string) error {
+		for _, v := range values {
+			rev, err := revocation.New(kind, v, r.reason)
+			if err != nil {
+				return err
+			}
+			res = append(res, rev)
+		}
+
+		return nil
+	}
+
+	if r.token != "" {
+		kind, value, err := r.tokenRevocation()
+		if err != nil {
+			return nil, err
+		}
+
+		err = add(kind, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	values := map[revocation.Kind][]string{
+		revocation.TokenID:   r.id,
+		revocation.Caller:    r.callers,
+		revocation.Identity:  r.ids,
+		revocation.PublicKey: r.keys,
+	}
+
+	for _, kind := range revocation.Kinds {
+		err := add(kind, values[kind]...)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, fmt.Errorf("a token or one of --id, --caller, --identity or --public-key is required")
+	}
+
+	return res, nil
+}
+
+// tokenRevocation revokes a token file by its ID, older tokens without IDs are revoked by public key
+func (r *tJWTRevokeCommand) tokenRevocation() (revocation.Kind, string, error) {
+	t, err := os.ReadFile(r.token)
+	if err != nil {
+		return "", "", err
+	}
+	token := strings.TrimSpace(string(t))
+
+	var id, pk string
+
+	switch tokens.TokenPurpose(token) {
+	case tokens.ClientIDPurpose:
+		claims, err := tokens.ParseClientIDTokenUnverified(token)
+		if err != nil {
+			return "", "", err
+		}
+		id, pk = claims.ID, claims.PublicKey
+
+	case tokens.ServerPurpose:
+		claims, err := tokens.ParseServerTokenUnverified(token)
+		if err != nil {
+			return "", "", err
+		}
+		id, pk = claims.ID, claims.PublicKey
+
+	default:
+		return "", "", fmt.Errorf("can only revoke client and server tokens")
+	}
+
+	switch {
+	case id != "":
+		return revocation.TokenID, id, nil
+	case pk != "":
+		return revocation.PublicKey, pk, nil
+	default:
+		return "", "", fmt.Errorf("%s has no ID or public key to revoke", r.token)
+	}
+}
+
+func (r *tJWTRevokeCommand) updateFile(revs []*revocation.Revocation) error {
+	current, err := revocation.ReadFile(r.file)
+	if err != nil {
+		return err
+	}
+
+	list := map[string]*revocation.Revocation{}
+	for _, rev := range current {
+		list[rev.Key()] = rev
+	}
+
+	for _, rev := range revs {
+		_, found := list[rev.Key()]
+
+		switch {
+		case r.remove && !found:
+			return fmt.Errorf("%s is not revoked in %s", rev, r.file)
+		case r.remove:
+			delete(list, rev.Key())
+		case !found:
+			list[rev.Key()] = rev
+		}
+	}
+
+	var updated []*revocation.Revocation
+	for _, rev := range list {
+		updated = append(updated, rev)
+	}
+
+	return revocation.WriteFile(r.file, updated)
+}
+
+func (r *tJWTRevokeCommand) updateBucket(revs []*revocation.Revocation) error {
+	// the broker only enforces the bucket in the choria account, organization accounts can not host their own
+	token, _ := c.SignerToken()
+	if token != "" {
+		claims, err := tokens.ParseClientIDTokenUnverified(token)
+		if err == nil && claims.OrganizationUnit != "" && claims.OrganizationUnit != "choria" {
+			return fmt.Errorf("revocations can only be managed by users in the choria organization, the token is for organization %s", claims.OrganizationUnit)
+		}
+	}
+
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, c.CallerID(), c.Logger("revocation"))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	bucket, err := revocation.Bucket(conn.Nats(), !r.remove, r.replicas)
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revs {
+		if r.remove {
+			err = revocation.Remove(bucket, rev.Kind, rev.Value)
+		} else {
+			err = revocation.Store(bucket, rev)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *tJWTRevokeCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	revs, err := r.revocations()
+	if err != nil {
+		return err
+	}
+
+	if r.file != "" {
+		err = r.updateFile(revs)
+	} else {
+		err = r.updateBucket(revs)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, rev := range revs {
+		if r.remove {
+			fmt.Printf("Removed revocation of %s\n", rev)
+		} else {
+			fmt.Printf("Revoked %s\n", rev)
+		}
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &tJWTRevokeCommand{})
+}
@@ -46,7 +46,7 @@ func (s *jWTCreateServerCommand) Setup() (err error) {
 		s.cmd.Arg("public-key", "Ed25519 public key to embed in the token").Required().StringVar(&s.pk)
 		s.cmd.Arg("signing-key", "Path to a private key used to sign the JWT").Required().ExistingFileVar(&s.signingKey)
 		s.cmd.Flag("collectives", "Allow the server to access certain collectives").Default(collective).StringsVar(&s.collectives)
-		s.cmd.Flag("org", "Adds the user to a specific organization").Default("choria").StringVar(&s.org)
+		s.cmd.Flag("org", "Adds the server to a specific organization, brokers hosting multiple organizations place it in that organization's account").Default("choria").StringVar(&s.org)
 		s.cmd.Flag("subjects", "Additional subjects this node may publish to").StringsVar(&s.subjects)
 		s.cmd.Flag("submission", "Enable the node to publish to Choria Streams using Choria Submission").UnNegatableBoolVar(&s.submission)
 		s.cmd.Flag("stream-user", "Allow the node to access Choria Streams").UnNegatableBoolVar(&s.streamUser)
@@ -70,6 +70,9 @@ func (v *tJWTViewCommand) validateServerToken(token string) error {
 	}
 
 	fmt.Printf("             Identity: %s\n", claims.ChoriaIdentity)
+	if claims.ID != "" {
+		fmt.Printf("             Token ID: %s\n", claims.ID)
+	}
 	if claims.ExpiresAt != nil && time.Now().After(claims.ExpiresAt.Time) {
 		fmt.Printf("           Expires At: %s (expired %s ago)\n", claims.ExpiresAt.Time, iu.RenderDuration(time.Since(claims.ExpiresAt.Time)))
 	} else if claims.ExpiresAt != nil {
@@ -198,6 +201,9 @@ func (v *tJWTViewCommand) validateClientToken(token string) error {
 	}
 
 	fmt.Printf("          Caller ID: %s\n", claims.CallerID)
+	if claims.ID != "" {
+		fmt.Printf("           Token ID: %s\n", claims.ID)
+	}
 	if claims.OrganizationUnit != "" {
 		fmt.Printf("  Organization Unit: %s\n", claims.OrganizationUnit)
 	}
@@ -232,6 +238,9 @@ func (v *tJWTViewCommand) validateClientToken(token string) error {
 		if claims.Permissions.SystemUser {
 			fmt.Println("      Can access the Broker system account")
 		}
+		for _, kv := range claims.Permissions.KV {
+			fmt.Printf("      Has %s\n", kv)
+		}
 	}
 
 	if len(claims.UserProperties) > 0 {
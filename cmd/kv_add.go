@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -6,6 +6,7 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -22,6 +23,8 @@ type kvAddCommand struct {
 	replicas      uint
 	maxValueSize  int32
 	maxBucketSize int64
+	mirror        string
+	sources       []string
 }
 
 func (k *kvAddCommand) Setup() error {
@@ -33,6 +36,8 @@ func (k *kvAddCommand) Setup() error {
 		k.cmd.Flag("replicas", "How many data replicas to store").Default("1").UintVar(&k.replicas)
 		k.cmd.Flag("max-value-size", "Maximum size of any value in the bucket").Default("10240").Int32Var(&k.maxValueSize)
 		k.cmd.Flag("max-bucket-size", "Maximum size for the entire bucket").Int64Var(&k.maxBucketSize)
+		k.cmd.Flag("mirror", "Creates a read only mirror of the bucket with the same name in another JetStream domain").PlaceHolder("DOMAIN").StringVar(&k.mirror)
+		k.cmd.Flag("source", "Sources data from the bucket with the same name in other JetStream domains").PlaceHolder("DOMAIN").StringsVar(&k.sources)
 	}
 
 	return nil
@@ -45,11 +50,23 @@ func (k *kvAddCommand) Configure() error {
 func (k *kvAddCommand) Run(wg *sync.WaitGroup) error {
 	defer wg.Done()
 
-	store, err := c.KV(ctx, nil, k.name, true, kv.WithTTL(k.ttl),
+	opts := []kv.Option{
+		kv.WithTTL(k.ttl),
 		kv.WithHistory(k.history),
 		kv.WithReplicas(int(k.replicas)),
 		kv.WithMaxBucketSize(k.maxBucketSize),
-		kv.WithMaxValueSize(k.maxValueSize))
+		kv.WithMaxValueSize(k.maxValueSize),
+	}
+
+	if k.mirror != "" {
+		opts = append(opts, kv.WithMirror(&kv.Source{Bucket: k.name, Domain: k.mirror}))
+	}
+
+	for _, domain := range k.sources {
+		opts = append(opts, kv.WithSource(&kv.Source{Bucket: k.name, Domain: domain}))
+	}
+
+	store, err := c.KV(ctx, nil, k.name, true, opts...)
 	if err != nil {
 		return err
 	}
@@ -68,10 +85,28 @@ func (k *kvAddCommand) Run(wg *sync.WaitGroup) error {
 	fmt.Printf("             TTL: %v\n", status.TTL())
 	fmt.Printf(" Max Bucket Size: %d\n", nfo.Config.MaxBytes)
 	fmt.Printf("  Max Value Size: %d\n", nfo.Config.MaxMsgSize)
+	if nfo.Config.Mirror != nil {
+		fmt.Printf("          Mirror: %s\n", kvSourceName(nfo.Config.Mirror.Name, nfo.Config.Mirror.External))
+	}
+	for _, source := range nfo.Config.Sources {
+		fmt.Printf("          Source: %s\n", kvSourceName(source.Name, source.External))
+	}
 
 	return nil
 }
 
+// kvSourceName shows a stream source as BUCKET@DOMAIN
+func kvSourceName(stream string, external *nats.ExternalStream) string {
+	name := strings.TrimPrefix(stream, "KV_")
+	if external == nil {
+		return name
+	}
+
+	domain := strings.TrimSuffix(strings.TrimPrefix(external.APIPrefix, "$JS."), ".API")
+
+	return fmt.Sprintf("%s@%s", name, domain)
+}
+
 func init() {
 	cli.commands = append(cli.commands, &kvAddCommand{})
 }
@@ -1,13 +1,16 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/choria-io/go-choria/providers/kv"
 	"github.com/nats-io/jsm.go"
 	"github.com/nats-io/nats.go"
 )
@@ -15,8 +18,9 @@ import (
 type kvBackupCommand struct {
 	command
 
-	name   string
-	target string
+	name        string
+	target      string
+	incremental bool
 }
 
 func (k *kvBackupCommand) Setup() error {
@@ -24,6 +28,7 @@ func (k *kvBackupCommand) Setup() error {
 		k.cmd = kv.Cmd().Command("backup", "Backs up a bucket to a directory")
 		k.cmd.Arg("bucket", "The bucket name").Required().StringVar(&k.name)
 		k.cmd.Arg("target", "Directory to create the backup in").Required().StringVar(&k.target)
+		k.cmd.Flag("incremental", "Creates or updates an incremental backup supporting point in time restores").UnNegatableBoolVar(&k.incremental)
 	}
 
 	return nil
@@ -36,6 +41,30 @@ func (k *kvBackupCommand) Configure() error {
 func (k *kvBackupCommand) Run(wg *sync.WaitGroup) error {
 	defer wg.Done()
 
+	if !k.incremental {
+		return k.snapshotBackup()
+	}
+
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, fmt.Sprintf("kv %s", c.CallerID()), c.Logger("kv"))
+	if err != nil {
+		return err
+	}
+
+	segment, err := kv.Backup(ctx, conn.Nats(), k.name, k.target)
+	if errors.Is(err, kv.ErrBackupNoChanges) {
+		fmt.Printf("No changes to bucket %s since the previous backup in %s\n", k.name, k.target)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backed up %d entries of bucket %s in %s covering revisions %d to %d (%s to %s)\n", segment.Entries, k.name, k.target, segment.FirstSeq, segment.LastSeq, segment.Start.Local().Format(time.RFC3339), segment.End.Local().Format(time.RFC3339))
+
+	return nil
+}
+
+func (k *kvBackupCommand) snapshotBackup() error {
 	store, conn, err := c.KVWithConn(ctx, nil, k.name, false)
 	if err != nil {
 		return err
@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -9,13 +9,16 @@ import (
 	"io"
 	"os"
 	"sync"
+
+	"github.com/choria-io/go-choria/providers/kv"
 )
 
 type kvPutCommand struct {
 	command
-	name string
-	key  string
-	val  string
+	name  string
+	key   string
+	val   string
+	force bool
 }
 
 func (k *kvPutCommand) Setup() error {
@@ -24,6 +27,7 @@ func (k *kvPutCommand) Setup() error {
 		k.cmd.Arg("bucket", "The bucket name").Required().StringVar(&k.name)
 		k.cmd.Arg("key", "The key to delete").Required().StringVar(&k.key)
 		k.cmd.Arg("val", "The value to store, - for STDIN").Required().StringVar(&k.val)
+		k.cmd.Flag("force", "Store the value even if it does not match the bucket schema").UnNegatableBoolVar(&k.force)
 	}
 
 	return nil
@@ -49,6 +53,13 @@ func (k *kvPutCommand) Run(wg *sync.WaitGroup) error {
 		}
 	}
 
+	if !k.force {
+		err = kv.ValidateValue(store, k.key, val)
+		if err != nil {
+			return err
+		}
+	}
+
 	_, err = store.Put(k.key, val)
 	if err != nil {
 		return err
@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -9,7 +9,9 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
+	"github.com/choria-io/go-choria/providers/kv"
 	"github.com/nats-io/jsm.go"
 	"github.com/tidwall/gjson"
 )
@@ -17,13 +19,21 @@ import (
 type kvRestoreCommand struct {
 	command
 
-	source string
+	source   string
+	bucket   string
+	revision uint64
+	until    string
+	verify   bool
 }
 
 func (k *kvRestoreCommand) Setup() error {
 	if kv, ok := cmdWithFullCommand("kv"); ok {
 		k.cmd = kv.Cmd().Command("restore", "Restores a backup from a directory")
 		k.cmd.Arg("source", "Directory holding the backup").Required().ExistingDirVar(&k.source)
+		k.cmd.Flag("bucket", "Restores into a bucket with a different name").StringVar(&k.bucket)
+		k.cmd.Flag("revision", "Restores the bucket up to and including this revision").Uint64Var(&k.revision)
+		k.cmd.Flag("time", "Restores the bucket to its state at this RFC3339 time").StringVar(&k.until)
+		k.cmd.Flag("verify", "Only verifies the integrity of the backup").UnNegatableBoolVar(&k.verify)
 	}
 
 	return nil
@@ -36,6 +46,81 @@ func (k *kvRestoreCommand) Configure() error {
 func (k *kvRestoreCommand) Run(wg *sync.WaitGroup) error {
 	defer wg.Done()
 
+	_, err := os.Stat(filepath.Join(k.source, kv.BackupManifestFile))
+	if os.IsNotExist(err) {
+		if k.bucket != "" || k.revision > 0 || k.until != "" || k.verify {
+			return fmt.Errorf("%s holds a Choria Streams snapshot that can only be restored in full", k.source)
+		}
+
+		return k.restoreSnapshot()
+	}
+
+	if k.verify {
+		return k.verifyBackup()
+	}
+
+	var opts []kv.RestoreOption
+	if k.bucket != "" {
+		opts = append(opts, kv.RestoreIntoBucket(k.bucket))
+	}
+	if k.revision > 0 {
+		opts = append(opts, kv.RestoreToRevision(k.revision))
+	}
+	if k.until != "" {
+		until, err := time.Parse(time.RFC3339, k.until)
+		if err != nil {
+			return fmt.Errorf("invalid time: %s", err)
+		}
+		opts = append(opts, kv.RestoreToTime(until))
+	}
+
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, fmt.Sprintf("kv %s", c.CallerID()), c.Logger("kv"))
+	if err != nil {
+		return err
+	}
+
+	res, err := kv.RestoreBackup(ctx, conn.Nats(), k.source, opts...)
+	if err != nil {
+		return err
+	}
+
+	if res.Entries == 0 {
+		fmt.Printf("Created empty bucket %s, no entries matched the restore criteria\n", res.Bucket)
+		return nil
+	}
+
+	fmt.Printf("Restored %d entries from %s into bucket %s up to revision %d (%s)\n", res.Entries, k.source, res.Bucket, res.LastSeq, res.End.Local().Format(time.RFC3339))
+
+	return nil
+}
+
+func (k *kvRestoreCommand) verifyBackup() error {
+	manifest, err := kv.VerifyBackup(k.source)
+	if err != nil {
+		return err
+	}
+
+	entries := 0
+	for _, segment := range manifest.Segments {
+		entries += segment.Entries
+	}
+
+	fmt.Printf("Verified backup of bucket %s holding %d entries in %d segments\n", manifest.Bucket, entries, len(manifest.Segments))
+
+	for i, segment := range manifest.Segments {
+		fmt.Printf("  %s: revisions %d to %d (%s to %s) created %s", segment.File, segment.FirstSeq, segment.LastSeq, segment.Start.Local().Format(time.RFC3339), segment.End.Local().Format(time.RFC3339), segment.Created.Local().Format(time.RFC3339))
+		if i == 0 {
+			fmt.Print(" full")
+		} else {
+			fmt.Print(" incremental")
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func (k *kvRestoreCommand) restoreSnapshot() error {
 	bj, err := os.ReadFile(filepath.Join(k.source, "backup.json"))
 	if err != nil {
 		return fmt.Errorf("could not read backup configuration: %s", err)
@@ -0,0 +1,35 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+)
+
+type kvSchemaCommand struct {
+	command
+}
+
+func (k *kvSchemaCommand) Setup() error {
+	if kv, ok := cmdWithFullCommand("kv"); ok {
+		k.cmd = kv.Cmd().Command("schema", "Manage JSON schemas values are validated against")
+	}
+
+	return nil
+}
+
+func (k *kvSchemaCommand) Configure() error {
+	return nil
+}
+
+func (k *kvSchemaCommand) Run(wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &kvSchemaCommand{})
+}
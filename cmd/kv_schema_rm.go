@@ -0,0 +1,77 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+type kvSchemaRmCommand struct {
+	command
+	name  string
+	keys  string
+	force bool
+}
+
+func (k *kvSchemaRmCommand) Setup() error {
+	if schema, ok := cmdWithFullCommand("kv schema"); ok {
+		k.cmd = schema.Cmd().Command("rm", "Removes the schema for a key pattern").Alias("del")
+		k.cmd.Arg("bucket", "The bucket name").Required().StringVar(&k.name)
+		k.cmd.Arg("keys", "The key pattern the schema applies to").Required().StringVar(&k.keys)
+		k.cmd.Flag("force", "Force remove without prompting").Short('f').UnNegatableBoolVar(&k.force)
+	}
+
+	return nil
+}
+
+func (k *kvSchemaRmCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (k *kvSchemaRmCommand) Run(wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	store, err := c.KV(ctx, nil, k.name, false)
+	if err != nil {
+		return err
+	}
+
+	schemas, rev, err := kv.LoadSchemas(store)
+	if err != nil {
+		return err
+	}
+
+	if !schemas.Remove(k.keys) {
+		return fmt.Errorf("no schema found for keys %s", k.keys)
+	}
+
+	if !k.force {
+		ok, err := util.PromptForConfirmation("Really remove the schema for %s from bucket %s", k.keys, k.name)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Skipping")
+			return nil
+		}
+	}
+
+	err = kv.StoreSchemas(store, schemas, rev)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Removed the schema for keys %s\n", k.keys)
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &kvSchemaRmCommand{})
+}
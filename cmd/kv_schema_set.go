@@ -0,0 +1,82 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+type kvSchemaSetCommand struct {
+	command
+	name        string
+	keys        string
+	file        string
+	description string
+}
+
+func (k *kvSchemaSetCommand) Setup() error {
+	if schema, ok := cmdWithFullCommand("kv schema"); ok {
+		k.cmd = schema.Cmd().Command("set", "Sets the schema for keys matching a pattern").Alias("add")
+		k.cmd.Arg("bucket", "The bucket name").Required().StringVar(&k.name)
+		k.cmd.Arg("keys", "The keys to validate, supports * and > wildcards").Required().StringVar(&k.keys)
+		k.cmd.Arg("schema", "File holding the JSON schema, - for STDIN").Required().StringVar(&k.file)
+		k.cmd.Flag("description", "Description of the data stored in these keys").StringVar(&k.description)
+	}
+
+	return nil
+}
+
+func (k *kvSchemaSetCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (k *kvSchemaSetCommand) Run(wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	var schema []byte
+	var err error
+
+	if k.file == "-" {
+		schema, err = io.ReadAll(os.Stdin)
+	} else {
+		schema, err = os.ReadFile(k.file)
+	}
+	if err != nil {
+		return err
+	}
+
+	store, err := c.KV(ctx, nil, k.name, false)
+	if err != nil {
+		return err
+	}
+
+	schemas, rev, err := kv.LoadSchemas(store)
+	if err != nil {
+		return err
+	}
+
+	err = schemas.Set(k.keys, k.description, schema)
+	if err != nil {
+		return err
+	}
+
+	err = kv.StoreSchemas(store, schemas, rev)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Values stored in keys matching %s will be validated against the schema\n", k.keys)
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &kvSchemaSetCommand{})
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+type kvSchemaViewCommand struct {
+	command
+	name string
+	key  string
+	json bool
+}
+
+func (k *kvSchemaViewCommand) Setup() error {
+	if schema, ok := cmdWithFullCommand("kv schema"); ok {
+		k.cmd = schema.Cmd().Command("view", "Views the schemas stored in a bucket").Alias("show").Alias("ls")
+		k.cmd.Arg("bucket", "The bucket name").Required().StringVar(&k.name)
+		k.cmd.Arg("key", "Shows only the schema that applies to this key").StringVar(&k.key)
+		k.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&k.json)
+	}
+
+	return nil
+}
+
+func (k *kvSchemaViewCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (k *kvSchemaViewCommand) Run(wg *sync.WaitGroup) error {
+	defer wg.Done()
+
+	store, err := c.KV(ctx, nil, k.name, false)
+	if err != nil {
+		return err
+	}
+
+	schemas, _, err := kv.LoadSchemas(store)
+	if err != nil {
+		return err
+	}
+
+	if k.key != "" {
+		found := schemas.Find(k.key)
+		schemas = &kv.Schemas{Schemas: []*kv.KeySchema{}}
+		if found != nil {
+			schemas.Schemas = append(schemas.Schemas, found)
+		}
+	}
+
+	if k.json {
+		if schemas.Schemas == nil {
+			schemas.Schemas = []*kv.KeySchema{}
+		}
+
+		j, err := json.MarshalIndent(schemas, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+
+		return nil
+	}
+
+	if len(schemas.Schemas) == 0 {
+		fmt.Println("No schemas found")
+		return nil
+	}
+
+	for _, schema := range schemas.Schemas {
+		fmt.Printf("Keys: %s\n", schema.Keys)
+		if schema.Description != "" {
+			fmt.Printf("Description: %s\n", schema.Description)
+		}
+
+		out := bytes.Buffer{}
+		err = json.Indent(&out, schema.Schema, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println()
+		fmt.Println(out.String())
+		fmt.Println()
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &kvSchemaViewCommand{})
+}
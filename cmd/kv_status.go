@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -7,6 +7,7 @@ package cmd
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/nats-io/nats.go"
 )
@@ -53,9 +54,38 @@ func (k *kvStatusCommand) Run(wg *sync.WaitGroup) error {
 	fmt.Printf("   Max Value Size: %d\n", nfo.Config.MaxMsgSize)
 	fmt.Printf(" Storage Replicas: %d\n", nfo.Config.Replicas)
 
+	if nfo.Mirror != nil {
+		fmt.Println()
+		fmt.Printf("  Mirror: %s\n", kvSourceName(nfo.Mirror.Name, nfo.Mirror.External))
+		k.showSource(nfo.Mirror)
+	}
+
+	if len(nfo.Sources) > 0 {
+		fmt.Println()
+		for i, source := range nfo.Sources {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("  Source: %s\n", kvSourceName(source.Name, source.External))
+			k.showSource(source)
+		}
+	}
+
 	return nil
 }
 
+func (k *kvStatusCommand) showSource(s *nats.StreamSourceInfo) {
+	fmt.Printf("     Lag: %d\n", s.Lag)
+	if s.Active > 0 {
+		fmt.Printf("    Seen: %v ago\n", s.Active.Round(time.Millisecond))
+	} else {
+		fmt.Printf("    Seen: never\n")
+	}
+	if s.Error != nil {
+		fmt.Printf("   Error: %s\n", s.Error.Description)
+	}
+}
+
 func init() {
 	cli.commands = append(cli.commands, &kvStatusCommand{})
 }
@@ -7,6 +7,8 @@ package cmd
 import (
 	"fmt"
 	"sync"
+
+	"github.com/choria-io/go-choria/providers/kv"
 )
 
 type kvUpdateCommand struct {
@@ -15,6 +17,7 @@ type kvUpdateCommand struct {
 	key   string
 	value string
 	rev   uint64
+	force bool
 }
 
 func (k *kvUpdateCommand) Setup() error {
@@ -24,6 +27,7 @@ func (k *kvUpdateCommand) Setup() error {
 		k.cmd.Arg("key", "The key to fetch").Required().StringVar(&k.key)
 		k.cmd.Arg("value", "The value to store, when empty reads STDIN").StringVar(&k.value)
 		k.cmd.Arg("revision", "The revision of the previous value in the bucket").Uint64Var(&k.rev)
+		k.cmd.Flag("force", "Store the value even if it does not match the bucket schema").UnNegatableBoolVar(&k.force)
 	}
 
 	return nil
@@ -41,6 +45,13 @@ func (k *kvUpdateCommand) Run(wg *sync.WaitGroup) error {
 		return err
 	}
 
+	if !k.force {
+		err = kv.ValidateValue(store, k.key, []byte(k.value))
+		if err != nil {
+			return err
+		}
+	}
+
 	rev, err := store.Update(k.key, []byte(k.value), k.rev)
 	if err != nil {
 		return err
@@ -0,0 +1,182 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/choria-io/go-choria/client/choria_utilclient"
+	"github.com/choria-io/go-choria/client/discovery"
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+type mControlCommand struct {
+	command
+	action  string
+	fo      *discovery.StandardOptions
+	machine string
+	json    bool
+	verbose bool
+}
+
+func (m *mControlCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		switch m.action {
+		case "pause":
+			m.cmd = machine.Cmd().Command("pause", "Pauses an autonomous agent across the fleet")
+		case "resume":
+			m.cmd = machine.Cmd().Command("resume", "Resumes a paused autonomous agent across the fleet")
+		case "reload":
+			m.cmd = machine.Cmd().Command("reload", "Reloads an autonomous agent from disk across the fleet")
+		default:
+			return fmt.Errorf("unknown machine control action %q", m.action)
+		}
+
+		m.cmd.Arg("machine", "The name of the autonomous agent").Required().StringVar(&m.machine)
+
+		m.fo = discovery.NewStandardOptions()
+		m.fo.AddFilterFlags(m.cmd)
+		m.fo.AddSelectionFlags(m.cmd)
+
+		m.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&m.json)
+		m.cmd.Flag("verbose", "Show verbose output").Short('v').UnNegatableBoolVar(&m.verbose)
+	}
+
+	return nil
+}
+
+func (m *mControlCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (m *mControlCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	log := logrus.NewEntry(c.Logger("machine").Logger)
+
+	cu, err := machineUtilClient(m.fo, m.machine, log)
+	if err != nil {
+		return err
+	}
+
+	// the three actions share inputs and outputs, we only need the common result behaviors
+	type output interface {
+		Success() bool
+		ResultDetails() *choria_utilclient.ResultDetails
+	}
+
+	var outputs []output
+	var render func(format choria_utilclient.RenderFormat) error
+	var stats choria_utilclient.Stats
+	var verb string
+
+	switch m.action {
+	case "pause":
+		verb = "Paused"
+		res, err := cu.MachinePause().Name(m.machine).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range res.AllOutputs() {
+			outputs = append(outputs, o)
+		}
+		stats = res.Stats()
+		render = func(f choria_utilclient.RenderFormat) error {
+			return res.RenderResults(os.Stdout, f, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+		}
+
+	case "resume":
+		verb = "Resumed"
+		res, err := cu.MachineResume().Name(m.machine).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range res.AllOutputs() {
+			outputs = append(outputs, o)
+		}
+		stats = res.Stats()
+		render = func(f choria_utilclient.RenderFormat) error {
+			return res.RenderResults(os.Stdout, f, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+		}
+
+	case "reload":
+		verb = "Reloaded"
+		res, err := cu.MachineReload().Name(m.machine).Do(ctx)
+		if err != nil {
+			return err
+		}
+		for _, o := range res.AllOutputs() {
+			outputs = append(outputs, o)
+		}
+		stats = res.Stats()
+		render = func(f choria_utilclient.RenderFormat) error {
+			return res.RenderResults(os.Stdout, f, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+		}
+	}
+
+	if m.json {
+		return render(choria_utilclient.JSONFormat)
+	}
+
+	if stats.ResponsesCount() == 0 {
+		return fmt.Errorf("no responses received")
+	}
+
+	affected := 0
+	table := util.NewMarkdownTable("Name", "Message")
+	failed := 0
+
+	for _, o := range outputs {
+		if o.ResultDetails().OK() && o.Success() {
+			affected++
+			continue
+		}
+
+		failed++
+		table.Append([]string{o.ResultDetails().Sender(), o.ResultDetails().StatusMessage()})
+	}
+
+	if failed > 0 {
+		table.Render()
+		fmt.Println()
+	}
+
+	fmt.Printf("%s autonomous agent %s on %d nodes\n", verb, m.machine, affected)
+	fmt.Println()
+
+	return render(choria_utilclient.TXTFooter)
+}
+
+// machineUtilClient creates a choria_util client that only discovers nodes hosting a specific machine
+func machineUtilClient(fo *discovery.StandardOptions, machine string, log *logrus.Entry) (*choria_utilclient.ChoriaUtilClient, error) {
+	fo.SetDefaultsFromChoria(c)
+
+	if machine != "" {
+		filter := fmt.Sprintf("machine_state(%q).name == %q", machine, machine)
+		if fo.CompoundFilter != "" {
+			filter = fmt.Sprintf("(%s) && %s", fo.CompoundFilter, filter)
+		}
+		fo.CompoundFilter = filter
+	}
+
+	return choria_utilclient.New(c,
+		choria_utilclient.Logger(log),
+		choria_utilclient.Progress(),
+		choria_utilclient.Discovery(&choria_utilclient.MetaNS{
+			Options:               fo,
+			Agent:                 "choria_util",
+			DisablePipedDiscovery: false,
+		}),
+	)
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mControlCommand{action: "pause"})
+	cli.commands = append(cli.commands, &mControlCommand{action: "resume"})
+	cli.commands = append(cli.commands, &mControlCommand{action: "reload"})
+}
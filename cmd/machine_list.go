@@ -0,0 +1,138 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent"
+	"github.com/choria-io/go-choria/client/choria_utilclient"
+	"github.com/choria-io/go-choria/client/discovery"
+	"github.com/choria-io/go-choria/internal/util"
+)
+
+type mListCommand struct {
+	command
+	fo      *discovery.StandardOptions
+	machine string
+	json    bool
+	verbose bool
+}
+
+type mListEntry struct {
+	Identity string `json:"identity"`
+	aagent.MachineState
+}
+
+func (m *mListCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		m.cmd = machine.Cmd().Command("list", "Lists autonomous agents running across the fleet").Alias("ls")
+		m.cmd.Arg("machine", "Limit the list to a specific autonomous agent").StringVar(&m.machine)
+
+		m.fo = discovery.NewStandardOptions()
+		m.fo.AddFilterFlags(m.cmd)
+		m.fo.AddSelectionFlags(m.cmd)
+
+		m.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&m.json)
+		m.cmd.Flag("verbose", "Show verbose output").Short('v').UnNegatableBoolVar(&m.verbose)
+	}
+
+	return nil
+}
+
+func (m *mListCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (m *mListCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	log := logrus.NewEntry(c.Logger("machine").Logger)
+
+	cu, err := machineUtilClient(m.fo, m.machine, log)
+	if err != nil {
+		return err
+	}
+
+	res, err := cu.MachineStates().Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	var entries []mListEntry
+
+	res.EachOutput(func(r *choria_utilclient.MachineStatesOutput) {
+		if !r.ResultDetails().OK() {
+			log.Errorf("Could not retrieve machine states from %s: %s", r.ResultDetails().Sender(), r.ResultDetails().StatusMessage())
+			return
+		}
+
+		states := struct {
+			States map[string]aagent.MachineState `json:"states"`
+		}{}
+
+		err := r.ParseMachineStatesOutput(&states)
+		if err != nil {
+			log.Errorf("Could not parse output from %s: %s", r.ResultDetails().Sender(), err)
+			return
+		}
+
+		for _, s := range states.States {
+			if m.machine != "" && s.Name != m.machine {
+				continue
+			}
+
+			entries = append(entries, mListEntry{Identity: r.ResultDetails().Sender(), MachineState: s})
+		}
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Name == entries[j].Name {
+			return entries[i].Identity < entries[j].Identity
+		}
+
+		return entries[i].Name < entries[j].Name
+	})
+
+	if m.json {
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No autonomous agents found")
+	} else {
+		table := util.NewMarkdownTable("Identity", "Name", "Version", "State", "Paused", "Started")
+		for _, e := range entries {
+			started := "never"
+			if e.StartTimeUTC > 0 {
+				started = time.Unix(e.StartTimeUTC, 0).Format(time.RFC3339)
+			}
+
+			table.Append([]string{e.Identity, e.Name, e.Version, e.State, fmt.Sprintf("%t", e.Paused), started})
+		}
+		table.Render()
+	}
+
+	fmt.Println()
+
+	return res.RenderResults(os.Stdout, choria_utilclient.TXTFooter, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mListCommand{})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/choria-io/go-choria/client/choria_utilclient"
+	"github.com/choria-io/go-choria/client/discovery"
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/sirupsen/logrus"
+)
+
+type mSetDataCommand struct {
+	command
+	fo      *discovery.StandardOptions
+	machine string
+	key     string
+	value   string
+	json    bool
+	verbose bool
+}
+
+func (m *mSetDataCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		m.cmd = machine.Cmd().Command("set-data", "Stores a data item in an autonomous agent across the fleet")
+		m.cmd.Arg("machine", "The name of the autonomous agent").Required().StringVar(&m.machine)
+		m.cmd.Arg("key", "The data item to store").Required().StringVar(&m.key)
+		m.cmd.Arg("value", "The value to store, JSON values are stored as their parsed type").Required().StringVar(&m.value)
+
+		m.fo = discovery.NewStandardOptions()
+		m.fo.AddFilterFlags(m.cmd)
+		m.fo.AddSelectionFlags(m.cmd)
+
+		m.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&m.json)
+		m.cmd.Flag("verbose", "Show verbose output").Short('v').UnNegatableBoolVar(&m.verbose)
+	}
+
+	return nil
+}
+
+func (m *mSetDataCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (m *mSetDataCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	log := logrus.NewEntry(c.Logger("machine").Logger)
+
+	cu, err := machineUtilClient(m.fo, m.machine, log)
+	if err != nil {
+		return err
+	}
+
+	res, err := cu.MachineDataPut(m.key, m.value).Name(m.machine).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if m.json {
+		return res.RenderResults(os.Stdout, choria_utilclient.JSONFormat, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+	}
+
+	if res.Stats().ResponsesCount() == 0 {
+		return fmt.Errorf("no responses received")
+	}
+
+	stored := 0
+	failed := 0
+	table := util.NewMarkdownTable("Name", "Message")
+
+	res.EachOutput(func(r *choria_utilclient.MachineDataPutOutput) {
+		if r.ResultDetails().OK() && r.Success() {
+			stored++
+			return
+		}
+
+		failed++
+		table.Append([]string{r.ResultDetails().Sender(), r.ResultDetails().StatusMessage()})
+	})
+
+	if failed > 0 {
+		table.Render()
+		fmt.Println()
+	}
+
+	fmt.Printf("Stored %s in autonomous agent %s on %d nodes\n", m.key, m.machine, stored)
+	fmt.Println()
+
+	return res.RenderResults(os.Stdout, choria_utilclient.TXTFooter, choria_utilclient.DisplayDDL, m.verbose, false, c.Config.Color, log)
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSetDataCommand{})
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	machines "github.com/choria-io/go-choria/aagent/watchers/machineswatcher"
+)
+
+type mSpecCommand struct {
+	command
+}
+
+func (s *mSpecCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		s.cmd = machine.Cmd().Command("spec", "Manage specifications for the machines watcher")
+	}
+
+	return nil
+}
+
+func (s *mSpecCommand) Configure() error {
+	return nil
+}
+
+func (s *mSpecCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	return nil
+}
+
+// packMachineSpec reads a JSON list of machines from file and packs it, signing when key is set
+func packMachineSpec(file string, key string, force bool) (*machines.Specification, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if key == "" && !force {
+		return nil, fmt.Errorf("no ed25519 private key given, use --force to pack without signing")
+	}
+
+	return machines.PackSpecification(data, key)
+}
+
+// verifyMachineArchives downloads and verifies the archives of all machines, returns the number of failures
+func verifyMachineArchives(ctx context.Context, managed []*machines.ManagedMachine, insecure bool, timeout time.Duration) int {
+	client := &http.Client{Timeout: timeout}
+	if insecure {
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	failed := 0
+	for _, m := range managed {
+		if m.IsTemplated() {
+			fmt.Printf("%s %s: templated source or checksums can only be verified on nodes\n", color.YellowString("SKIP"), m.Name)
+			continue
+		}
+
+		err := m.VerifyArchive(ctx, client)
+		if err != nil {
+			failed++
+			fmt.Printf("%s %s: %s\n", color.RedString("FAIL"), m.Name, err)
+			continue
+		}
+
+		fmt.Printf("%s %s: %s\n", color.GreenString("PASS"), m.Name, m.Source)
+	}
+
+	return failed
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSpecCommand{})
+}
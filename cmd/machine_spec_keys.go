@@ -0,0 +1,59 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	machines "github.com/choria-io/go-choria/aagent/watchers/machineswatcher"
+)
+
+type mSpecKeysCommand struct {
+	command
+	json bool
+}
+
+func (k *mSpecKeysCommand) Setup() (err error) {
+	if spec, ok := cmdWithFullCommand("machine spec"); ok {
+		k.cmd = spec.Cmd().Command("keys", "Creates an ed25519 key pair for signing specifications")
+		k.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&k.json)
+	}
+
+	return nil
+}
+
+func (k *mSpecKeysCommand) Configure() error {
+	return nil
+}
+
+func (k *mSpecKeysCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	pub, pri, err := machines.NewKeyPair()
+	if err != nil {
+		return err
+	}
+
+	if k.json {
+		out, err := json.MarshalIndent(map[string]string{"public_key": pub, "private_key": pri}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+
+		return nil
+	}
+
+	fmt.Printf(" Public Key: %s\n", pub)
+	fmt.Printf("Private Key: %s\n", pri)
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSpecKeysCommand{})
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+type mSpecPackCommand struct {
+	command
+	machines string
+	key      string
+	output   string
+	force    bool
+}
+
+func (p *mSpecPackCommand) Setup() (err error) {
+	if spec, ok := cmdWithFullCommand("machine spec"); ok {
+		p.cmd = spec.Cmd().Command("pack", "Packs and signs a specification")
+		p.cmd.Arg("machines", "A file holding JSON data describing machines to manage").Required().ExistingFileVar(&p.machines)
+		p.cmd.Arg("key", "The ed25519 private key to sign with").Envar("KEY").StringVar(&p.key)
+		p.cmd.Flag("output", "Write the specification to a file").PlaceHolder("FILE").StringVar(&p.output)
+		p.cmd.Flag("force", "Pack without signing when no ed25519 key is given").UnNegatableBoolVar(&p.force)
+	}
+
+	return nil
+}
+
+func (p *mSpecPackCommand) Configure() error {
+	return nil
+}
+
+func (p *mSpecPackCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	spec, err := packMachineSpec(p.machines, p.key, p.force)
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	if p.output != "" {
+		return os.WriteFile(p.output, j, 0644)
+	}
+
+	fmt.Println(string(j))
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSpecPackCommand{})
+}
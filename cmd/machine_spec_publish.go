@@ -0,0 +1,94 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type mSpecPublishCommand struct {
+	command
+	machines     string
+	key          string
+	bucket       string
+	item         string
+	force        bool
+	skipArchives bool
+	insecure     bool
+	timeout      time.Duration
+}
+
+func (p *mSpecPublishCommand) Setup() (err error) {
+	if spec, ok := cmdWithFullCommand("machine spec"); ok {
+		p.cmd = spec.Cmd().Command("publish", "Packs, signs, verifies and stores a specification in the Key-Value store")
+		p.cmd.Arg("machines", "A file holding JSON data describing machines to manage").Required().ExistingFileVar(&p.machines)
+		p.cmd.Arg("key", "The ed25519 private key to sign with").Envar("KEY").StringVar(&p.key)
+		p.cmd.Flag("bucket", "The Key-Value bucket to store the specification in").Default("MACHINES").StringVar(&p.bucket)
+		p.cmd.Flag("item", "The key to store the specification in").Default("machines").StringVar(&p.item)
+		p.cmd.Flag("force", "Publish without signing when no ed25519 key is given").UnNegatableBoolVar(&p.force)
+		p.cmd.Flag("skip-archives", "Do not download and verify the machine archives").UnNegatableBoolVar(&p.skipArchives)
+		p.cmd.Flag("insecure", "Do not verify TLS certificates when downloading archives").UnNegatableBoolVar(&p.insecure)
+		p.cmd.Flag("timeout", "Timeout for downloading each archive").Default("1m").DurationVar(&p.timeout)
+	}
+
+	return nil
+}
+
+func (p *mSpecPublishCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (p *mSpecPublishCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	spec, err := packMachineSpec(p.machines, p.key, p.force)
+	if err != nil {
+		return err
+	}
+
+	managed, err := spec.ManagedMachines()
+	if err != nil {
+		return err
+	}
+
+	if !p.skipArchives {
+		failed := verifyMachineArchives(ctx, managed, p.insecure, p.timeout)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d archives failed verification, not publishing", failed, len(managed))
+		}
+		fmt.Println()
+	}
+
+	j, err := json.Marshal(spec)
+	if err != nil {
+		return err
+	}
+
+	store, err := c.KV(ctx, nil, p.bucket, false)
+	if err != nil {
+		return err
+	}
+
+	rev, err := store.Put(p.item, j)
+	if err != nil {
+		return err
+	}
+
+	signed := "unsigned"
+	if spec.Signature != "" {
+		signed = "signed"
+	}
+
+	fmt.Printf("Published %s specification for %d machines to %s > %s revision %d\n", signed, len(managed), p.bucket, p.item, rev)
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSpecPublishCommand{})
+}
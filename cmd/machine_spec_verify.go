@@ -0,0 +1,105 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/fatih/color"
+
+	machines "github.com/choria-io/go-choria/aagent/watchers/machineswatcher"
+)
+
+type mSpecVerifyCommand struct {
+	command
+	file         string
+	publicKey    string
+	skipArchives bool
+	insecure     bool
+	timeout      time.Duration
+}
+
+func (v *mSpecVerifyCommand) Setup() (err error) {
+	if spec, ok := cmdWithFullCommand("machine spec"); ok {
+		v.cmd = spec.Cmd().Command("verify", "Verifies a specification and the archives it references")
+		v.cmd.Arg("file", "A packed specification or a file holding JSON data describing machines to manage").Required().ExistingFileVar(&v.file)
+		v.cmd.Arg("key", "The ed25519 public key to verify the signature with").Envar("PUBLIC_KEY").StringVar(&v.publicKey)
+		v.cmd.Flag("skip-archives", "Do not download and verify the machine archives").UnNegatableBoolVar(&v.skipArchives)
+		v.cmd.Flag("insecure", "Do not verify TLS certificates when downloading archives").UnNegatableBoolVar(&v.insecure)
+		v.cmd.Flag("timeout", "Timeout for downloading each archive").Default("1m").DurationVar(&v.timeout)
+	}
+
+	return nil
+}
+
+func (v *mSpecVerifyCommand) Configure() error {
+	return nil
+}
+
+func (v *mSpecVerifyCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	data, err := os.ReadFile(v.file)
+	if err != nil {
+		return err
+	}
+
+	var managed []*machines.ManagedMachine
+
+	// a list of machines is unpacked, anything else has to be a packed specification
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		if v.publicKey != "" {
+			return fmt.Errorf("%s is not a packed specification, cannot verify its signature", v.file)
+		}
+
+		managed, err = machines.ParseManagedMachines(data)
+		if err != nil {
+			return err
+		}
+	} else {
+		var spec machines.Specification
+		err = json.Unmarshal(data, &spec)
+		if err != nil {
+			return fmt.Errorf("invalid specification: %s", err)
+		}
+
+		if v.publicKey != "" {
+			err = spec.VerifySignature(v.publicKey)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s signature verified using %s\n", color.GreenString("PASS"), v.publicKey)
+		} else if spec.Signature != "" {
+			fmt.Printf("%s signature not verified, no public key given\n", color.YellowString("SKIP"))
+		}
+
+		managed, err = spec.ManagedMachines()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("%s %d machines are valid\n", color.GreenString("PASS"), len(managed))
+
+	if v.skipArchives {
+		return nil
+	}
+
+	failed := verifyMachineArchives(ctx, managed, v.insecure, v.timeout)
+	if failed > 0 {
+		return fmt.Errorf("%d of %d archives failed verification", failed, len(managed))
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mSpecVerifyCommand{})
+}
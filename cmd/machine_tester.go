@@ -0,0 +1,118 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/fatih/color"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent/tester"
+)
+
+type mTestCommand struct {
+	command
+	sourceDir string
+	testsFile string
+	json      bool
+	verbose   bool
+}
+
+func (t *mTestCommand) Setup() (err error) {
+	if machine, ok := cmdWithFullCommand("machine"); ok {
+		t.cmd = machine.Cmd().Command("test", "Tests an autonomous agent using scripted watcher outcomes")
+		t.cmd.Arg("source", "Directory containing the machine definition").Required().ExistingDirVar(&t.sourceDir)
+		t.cmd.Arg("tests", "YAML file describing the tests to run").Required().ExistingFileVar(&t.testsFile)
+		t.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&t.json)
+		t.cmd.Flag("verbose", "Show the transitions taken by passing tests").Short('v').UnNegatableBoolVar(&t.verbose)
+	}
+
+	return nil
+}
+
+func (t *mTestCommand) Configure() error {
+	return nil
+}
+
+func (t *mTestCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	suite, err := tester.Load(t.testsFile)
+	if err != nil {
+		return err
+	}
+
+	logger := logrus.New()
+	if debug {
+		logger.SetLevel(logrus.DebugLevel)
+	} else {
+		logger.SetLevel(logrus.ErrorLevel)
+	}
+
+	runner, err := tester.New(t.sourceDir, suite, logrus.NewEntry(logger))
+	if err != nil {
+		return err
+	}
+
+	results, err := runner.Run()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, res := range results {
+		if !res.Passed() {
+			failed++
+		}
+	}
+
+	if t.json {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		t.showResults(suite, results)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d tests failed", failed, len(results))
+	}
+
+	return nil
+}
+
+func (t *mTestCommand) showResults(suite *tester.Suite, results []*tester.Result) {
+	if suite.Name != "" {
+		fmt.Printf("Testing %s\n\n", suite.Name)
+	}
+
+	for _, res := range results {
+		if res.Passed() {
+			fmt.Printf("%s %s\n", color.GreenString("PASS"), res.Name)
+		} else {
+			fmt.Printf("%s %s\n", color.RedString("FAIL"), res.Name)
+			for _, f := range res.Failures {
+				fmt.Printf("       %s\n", f)
+			}
+		}
+
+		if t.verbose || !res.Passed() {
+			for i, tr := range res.Transitions {
+				fmt.Printf("       %s => %s\n", tr, res.States[i])
+			}
+		}
+	}
+
+	fmt.Println()
+}
+
+func init() {
+	cli.commands = append(cli.commands, &mTestCommand{})
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+
+	scoutcmd "github.com/choria-io/go-choria/scout/cmd"
+	"github.com/sirupsen/logrus"
+)
+
+type sHistoryCommand struct {
+	identity string
+	checks   []string
+	json     bool
+	verbose  bool
+
+	command
+}
+
+func (s *sHistoryCommand) Setup() (err error) {
+	if scout, ok := cmdWithFullCommand("scout"); ok {
+		s.cmd = scout.Cmd().Command("history", "Retrieve check history from an agent")
+		s.cmd.Arg("identity", "Node to retrieve data from").Required().StringVar(&s.identity)
+		s.cmd.Arg("checks", "Checks to retrieve history for").StringsVar(&s.checks)
+		s.cmd.Flag("json", "JSON format output").UnNegatableBoolVar(&s.json)
+		s.cmd.Flag("verbose", "Show verbose output").Short('v').UnNegatableBoolVar(&s.verbose)
+	}
+
+	return nil
+}
+
+func (s *sHistoryCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (s *sHistoryCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	history, err := scoutcmd.NewHistoryCommand(c, s.identity, s.checks, s.json, debug || s.verbose, c.Config.Color, logrus.NewEntry(c.Logger("scout").Logger))
+	if err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	return history.Run(ctx, wg)
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sHistoryCommand{})
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/choria-io/go-choria/scout/notify"
+)
+
+type sNotifyCommand struct {
+	file string
+
+	command
+}
+
+func (n *sNotifyCommand) Setup() (err error) {
+	if scout, ok := cmdWithFullCommand("scout"); ok {
+		n.cmd = scout.Cmd().Command("notify", "Routes Scout check states to notification services")
+		n.cmd.Arg("routes", "Notification routing configuration file").Required().ExistingFileVar(&n.file)
+	}
+
+	return nil
+}
+
+func (n *sNotifyCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (n *sNotifyCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	log := c.Logger("scout_notify")
+
+	cfg, err := notify.LoadConfig(n.file)
+	if err != nil {
+		return err
+	}
+
+	svc, err := notify.New(cfg, os.Stdout, log)
+	if err != nil {
+		return err
+	}
+
+	conn, err := c.NewConnector(ctx, c.MiddlewareServers, c.Certname(), log)
+	if err != nil {
+		return fmt.Errorf("cannot connect: %s", err)
+	}
+	defer conn.Close()
+
+	return svc.Run(ctx, conn.Nats())
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sNotifyCommand{})
+}
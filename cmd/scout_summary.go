@@ -0,0 +1,65 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/client/discovery"
+	scoutcmd "github.com/choria-io/go-choria/scout/cmd"
+)
+
+type sSummaryCommand struct {
+	fo       *discovery.StandardOptions
+	json     bool
+	watch    bool
+	interval time.Duration
+	top      int
+	verbose  bool
+
+	command
+}
+
+func (s *sSummaryCommand) Setup() (err error) {
+	if scout, ok := cmdWithFullCommand("scout"); ok {
+		s.cmd = scout.Cmd().Command("summary", "Summarize check states across a fleet")
+
+		s.fo = discovery.NewStandardOptions()
+		s.fo.AddFilterFlags(s.cmd)
+		s.fo.AddSelectionFlags(s.cmd)
+
+		s.cmd.Flag("json", "JSON format output").UnNegatableBoolVar(&s.json)
+		s.cmd.Flag("watch", "Keep the summary updated using Scout events").UnNegatableBoolVar(&s.watch)
+		s.cmd.Flag("interval", "How often to refresh the summary in watch mode").Default("5s").DurationVar(&s.interval)
+		s.cmd.Flag("top", "Number of worst offending nodes to show").Default("10").IntVar(&s.top)
+		s.cmd.Flag("verbose", "Show verbose output").Short('v').UnNegatableBoolVar(&s.verbose)
+	}
+
+	return nil
+}
+
+func (s *sSummaryCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (s *sSummaryCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	s.fo.SetDefaultsFromChoria(c)
+	summary, err := scoutcmd.NewSummaryCommand(s.fo, c, s.json, s.watch, s.interval, s.top, debug || s.verbose, c.Config.Color, logrus.NewEntry(c.Logger("scout").Logger))
+	if err != nil {
+		return err
+	}
+
+	wg.Add(1)
+	return summary.Run(ctx, wg)
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sSummaryCommand{})
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"sync"
+)
+
+type sWindowsCommand struct {
+	command
+}
+
+func (w *sWindowsCommand) Setup() (err error) {
+	if scout, ok := cmdWithFullCommand("scout"); ok {
+		w.cmd = scout.Cmd().Command("windows", "Manage scheduled maintenance windows").Alias("window")
+	}
+
+	return nil
+}
+
+func (w *sWindowsCommand) Configure() error {
+	return nil
+}
+
+func (w *sWindowsCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sWindowsCommand{})
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/scout/maintenance"
+)
+
+type sWindowsAddCommand struct {
+	command
+	id          string
+	description string
+	identities  []string
+	checks      []string
+	start       string
+	end         string
+	length      time.Duration
+	schedule    string
+	duration    time.Duration
+}
+
+func (w *sWindowsAddCommand) Setup() (err error) {
+	if windows, ok := cmdWithFullCommand("scout windows"); ok {
+		w.cmd = windows.Cmd().Command("add", "Schedules a maintenance window")
+		w.cmd.Arg("id", "Unique ID for the window").Required().StringVar(&w.id)
+		w.cmd.Flag("description", "Reason for the maintenance").StringVar(&w.description)
+		w.cmd.Flag("identity", "Regular expression matching node identities, empty matches all nodes").StringsVar(&w.identities)
+		w.cmd.Flag("check", "Regular expression matching check names, empty matches all checks").StringsVar(&w.checks)
+		w.cmd.Flag("start", "Start time for once-off windows in RFC3339 format, defaults to now").StringVar(&w.start)
+		w.cmd.Flag("end", "End time for once-off windows in RFC3339 format").StringVar(&w.end)
+		w.cmd.Flag("for", "Length of once-off windows as an alternative to --end").DurationVar(&w.length)
+		w.cmd.Flag("schedule", "Cron schedule for recurring windows").StringVar(&w.schedule)
+		w.cmd.Flag("duration", "Length of each recurring window").DurationVar(&w.duration)
+	}
+
+	return nil
+}
+
+func (w *sWindowsAddCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (w *sWindowsAddCommand) window() (*maintenance.Window, error) {
+	window := &maintenance.Window{
+		ID:          w.id,
+		Description: w.description,
+		Identities:  w.identities,
+		Checks:      w.checks,
+		Creator:     c.CallerID(),
+	}
+
+	if w.schedule != "" {
+		if w.start != "" || w.end != "" || w.length > 0 {
+			return nil, fmt.Errorf("recurring windows do not accept --start, --end or --for")
+		}
+
+		window.Schedule = w.schedule
+		window.Duration = w.duration.String()
+
+		return window, window.Validate()
+	}
+
+	window.Start = time.Now().UTC()
+	if w.start != "" {
+		start, err := time.Parse(time.RFC3339, w.start)
+		if err != nil {
+			return nil, fmt.Errorf("invalid start: %s", err)
+		}
+		window.Start = start.UTC()
+	}
+
+	switch {
+	case w.end != "" && w.length > 0:
+		return nil, fmt.Errorf("only one of --end or --for can be given")
+
+	case w.end != "":
+		end, err := time.Parse(time.RFC3339, w.end)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end: %s", err)
+		}
+		window.End = end.UTC()
+
+	case w.length > 0:
+		window.End = window.Start.Add(w.length)
+
+	default:
+		return nil, fmt.Errorf("once-off windows require --end or --for, recurring windows require --schedule and --duration")
+	}
+
+	return window, window.Validate()
+}
+
+func (w *sWindowsAddCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	window, err := w.window()
+	if err != nil {
+		return err
+	}
+
+	store, err := c.KV(ctx, nil, maintenance.Bucket, true)
+	if err != nil {
+		return err
+	}
+
+	err = maintenance.Save(store, window)
+	if err != nil {
+		return err
+	}
+
+	start, end := window.Next(time.Now())
+	fmt.Printf("Scheduled maintenance window %s from %s until %s\n", window.ID, start.Local().Format(time.RFC1123), end.Local().Format(time.RFC1123))
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sWindowsAddCommand{})
+}
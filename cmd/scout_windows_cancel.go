@@ -0,0 +1,73 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/scout/maintenance"
+)
+
+type sWindowsCancelCommand struct {
+	command
+	ids   []string
+	force bool
+}
+
+func (w *sWindowsCancelCommand) Setup() (err error) {
+	if windows, ok := cmdWithFullCommand("scout windows"); ok {
+		w.cmd = windows.Cmd().Command("cancel", "Cancels maintenance windows").Alias("rm")
+		w.cmd.Arg("id", "The windows to cancel").Required().StringsVar(&w.ids)
+		w.cmd.Flag("force", "Force cancel without prompting").Short('f').UnNegatableBoolVar(&w.force)
+	}
+
+	return nil
+}
+
+func (w *sWindowsCancelCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (w *sWindowsCancelCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	store, err := c.KV(ctx, nil, maintenance.Bucket, false)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range w.ids {
+		_, err = store.Get(id)
+		if err != nil {
+			return fmt.Errorf("could not load window %s: %s", id, err)
+		}
+
+		if !w.force {
+			ok, err := util.PromptForConfirmation("Really cancel maintenance window %s", id)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Skipping")
+				continue
+			}
+		}
+
+		err = store.Delete(id)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Cancelled maintenance window %s, checks will resume within a minute\n", id)
+	}
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sWindowsCancelCommand{})
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/internal/util"
+	"github.com/choria-io/go-choria/scout/maintenance"
+)
+
+type sWindowsListCommand struct {
+	command
+	json bool
+	all  bool
+}
+
+func (w *sWindowsListCommand) Setup() (err error) {
+	if windows, ok := cmdWithFullCommand("scout windows"); ok {
+		w.cmd = windows.Cmd().Command("list", "Lists maintenance windows").Alias("ls")
+		w.cmd.Flag("json", "Produce JSON output").Short('j').UnNegatableBoolVar(&w.json)
+		w.cmd.Flag("all", "Include windows that already ended").UnNegatableBoolVar(&w.all)
+	}
+
+	return nil
+}
+
+func (w *sWindowsListCommand) Configure() error {
+	return commonConfigure()
+}
+
+func (w *sWindowsListCommand) Run(wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	store, err := c.KV(ctx, nil, maintenance.Bucket, false)
+	if err != nil {
+		return err
+	}
+
+	windows, errs, err := maintenance.Load(store)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var shown []*maintenance.Window
+	for _, window := range windows {
+		if !w.all && window.Expired(now) {
+			continue
+		}
+		shown = append(shown, window)
+	}
+
+	if w.json {
+		if shown == nil {
+			shown = []*maintenance.Window{}
+		}
+
+		j, err := json.MarshalIndent(shown, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(j))
+
+		return nil
+	}
+
+	for _, err := range errs {
+		fmt.Printf("WARNING: %s\n", err)
+	}
+
+	if len(shown) == 0 {
+		fmt.Println("No maintenance windows found")
+		return nil
+	}
+
+	table := util.NewMarkdownTable("ID", "Schedule", "Active", "Next Start", "Next End", "Identities", "Checks", "Description")
+	for _, window := range shown {
+		schedule := "once"
+		if window.Schedule != "" {
+			schedule = fmt.Sprintf("%s for %s", window.Schedule, window.Duration)
+		}
+
+		start, end := window.Next(now)
+		startS, endS := "", ""
+		if !start.IsZero() {
+			startS = start.Local().Format(time.RFC1123)
+			endS = end.Local().Format(time.RFC1123)
+		}
+
+		table.Append([]string{
+			window.ID,
+			schedule,
+			fmt.Sprintf("%t", window.Active(now)),
+			startS,
+			endS,
+			strings.Join(window.Identities, ", "),
+			strings.Join(window.Checks, ", "),
+			window.Description,
+		})
+	}
+	table.Render()
+
+	return nil
+}
+
+func init() {
+	cli.commands = append(cli.commands, &sWindowsListCommand{})
+}
@@ -64,6 +64,7 @@ type ChoriaPluginConfig struct {
 	NetworkClientTokenSignerFile       string        `confkey:"plugin.choria.network.client_signer_cert" type:"path_string"`                                       // Path to the public certificate used by the AAA Service to sign client JWT tokens. This enables users with signed JWTs to use unverified TLS to connect
 	NetworkServerTokenSignerFile       string        `confkey:"plugin.choria.network.server_signer_cert" type:"path_string"`                                       // Path to the public certificate used by the Provisioner Service to sign server JWT tokens. This enables servers with signed JWTs to use unverified TLS to connect
 	NetworkDenyServers                 bool          `confkey:"plugin.choria.network.deny_server_connections"`                                                     // Set ACLs denying server connections to this broker
+	NetworkOrganizations               []string      `confkey:"plugin.choria.network.organizations" type:"comma_split"`                                            // Organizations hosted in isolated accounts, JWT tokens are placed in the account for their organization and tokens for other organizations are denied. Each is configured using plugin.choria.network.organization.ORG.collectives to limit the collectives its servers may join, .streams set to false to disable its Choria Streams and .exports and .service_exports holding ORG:SUBJECT lists of subjects shared with other organizations
 	NetworkTLSTimeout                  int           `confkey:"plugin.choria.network.tls_timeout" default:"2"`                                                     // Time to allow for TLS connections to establish, increase on slow or very large networks
 	NetworkClientAdvertiseName         string        `confkey:"plugin.choria.network.public_url"`                                                                  // Name:Port to advertise to clients, useful when fronted by a proxy
 	NetworkStreamStore                 string        `confkey:"plugin.choria.network.stream.store" type:"path_string"`                                             // Enables Streaming data persistence stored in this path
@@ -113,6 +114,8 @@ type ChoriaPluginConfig struct {
 	ClientAnonTLS                bool     `confkey:"plugin.security.client_anon_tls" default:"false"`                                                               // Use anonymous TLS to the Choria brokers from a client, also disables security provider verification - only when a remote signer is set
 	ServerTokenFile              string   `confkey:"plugin.choria.security.server.token_file" type:"path_string"`                                                   // The server token file to use for authentication, defaults to serer.jwt in the same location as server.conf
 	ServerTokenSeedFile          string   `confkey:"plugin.choria.security.server.seed_file" type:"path_string"`                                                    // The server token seed to use for authentication, defaults to server.seed in the same location as server.conf
+	RevocationFile               string   `confkey:"plugin.choria.security.revocation.file" type:"path_string"`                                                     // Path to a JSON file of revoked JWT token IDs, callers, identities and public keys, reloaded when it changes. Brokers enforce all kinds while servers only reject requests from revoked callers and identities
+	RevocationKV                 bool     `confkey:"plugin.choria.security.revocation.kv" default:"false"`                                                          // Load revoked JWT token IDs, callers, identities and public keys from the CHORIA_REVOCATIONS Choria Streams bucket, only choria organization administrators can update the bucket and servers in other organizations read it through the broker. Brokers enforce all kinds while servers only reject requests from revoked callers and identities
 
 	FileSecurityCertificate string `confkey:"plugin.security.file.certificate" type:"path_string"` // When using file security provider, the path to the public certificate
 	FileSecurityKey         string `confkey:"plugin.security.file.key" type:"path_string"`         // When using file security provider, the path to the private key
@@ -142,6 +145,7 @@ type ChoriaPluginConfig struct {
 	ScoutOverrides        string `confkey:"plugin.scout.overrides" type:"path_string"`                      // Path to a file holding overrides for Scout checks
 	ScoutTags             string `confkey:"plugin.scout.tags" type:"path_string"`                           // Path to a file holding tags for a Scout entity
 	ScoutAgentDisabled    bool   `confkey:"plugin.scout.agent_disabled"`                                    // Disables the scout agent
+	ScoutPrometheus       bool   `confkey:"plugin.scout.prometheus"`                                        // Exposes Scout check states on the Prometheus statistics endpoint, requires plugin.choria.stats_port
 
 	RequireClientFilter bool `confkey:"plugin.choria.require_client_filter" default:"false"` // If a client filter should always be required, only used in Go clients
 
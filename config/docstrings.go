@@ -76,6 +76,7 @@ var docStrings = map[string]string{
 	"plugin.choria.network.client_signer_cert":                 "Path to the public certificate used by the AAA Service to sign client JWT tokens. This enables users with signed JWTs to use unverified TLS to connect",
 	"plugin.choria.network.server_signer_cert":                 "Path to the public certificate used by the Provisioner Service to sign server JWT tokens. This enables servers with signed JWTs to use unverified TLS to connect",
 	"plugin.choria.network.deny_server_connections":            "Set ACLs denying server connections to this broker",
+	"plugin.choria.network.organizations":                      "Organizations hosted in isolated accounts, JWT tokens are placed in the account for their organization and tokens for other organizations are denied. Each is configured using plugin.choria.network.organization.ORG.collectives to limit the collectives its servers may join, .streams set to false to disable its Choria Streams and .exports and .service_exports holding ORG:SUBJECT lists of subjects shared with other organizations",
 	"plugin.choria.network.tls_timeout":                        "Time to allow for TLS connections to establish, increase on slow or very large networks",
 	"plugin.choria.network.public_url":                         "Name:Port to advertise to clients, useful when fronted by a proxy",
 	"plugin.choria.network.stream.store":                       "Enables Streaming data persistence stored in this path",
@@ -119,6 +120,8 @@ var docStrings = map[string]string{
 	"plugin.security.client_anon_tls":                          "Use anonymous TLS to the Choria brokers from a client, also disables security provider verification - only when a remote signer is set",
 	"plugin.choria.security.server.token_file":                 "The server token file to use for authentication, defaults to serer.jwt in the same location as server.conf",
 	"plugin.choria.security.server.seed_file":                  "The server token seed to use for authentication, defaults to server.seed in the same location as server.conf",
+	"plugin.choria.security.revocation.file":                   "Path to a JSON file of revoked JWT token IDs, callers, identities and public keys, reloaded when it changes. Brokers enforce all kinds while servers only reject requests from revoked callers and identities",
+	"plugin.choria.security.revocation.kv":                     "Load revoked JWT token IDs, callers, identities and public keys from the CHORIA_REVOCATIONS Choria Streams bucket, only choria organization administrators can update the bucket and servers in other organizations read it through the broker. Brokers enforce all kinds while servers only reject requests from revoked callers and identities",
 	"plugin.security.file.certificate":                         "When using file security provider, the path to the public certificate",
 	"plugin.security.file.key":                                 "When using file security provider, the path to the private key",
 	"plugin.security.file.ca":                                  "When using file security provider, the path to the Certificate Authority public certificate",
@@ -140,6 +143,7 @@ var docStrings = map[string]string{
 	"plugin.scout.overrides":                                   "Path to a file holding overrides for Scout checks",
 	"plugin.scout.tags":                                        "Path to a file holding tags for a Scout entity",
 	"plugin.scout.agent_disabled":                              "Disables the scout agent",
+	"plugin.scout.prometheus":                                  "Exposes Scout check states on the Prometheus statistics endpoint, requires plugin.choria.stats_port",
 	"plugin.choria.require_client_filter":                      "If a client filter should always be required, only used in Go clients",
 	"plugin.choria.services.registry.store":                    "Directory where the Registry service finds DDLs to read",
 	"plugin.choria.services.registry.cache":                    "Directory where the Registry client stores DDLs found in the registry",
@@ -6,7 +6,9 @@ import (
 	"crypto/rsa"
 	"crypto/tls"
 	"encoding/hex"
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"testing"
@@ -16,7 +18,10 @@ import (
 	"github.com/choria-io/go-choria/choria"
 	"github.com/choria-io/go-choria/integration/testbroker"
 	"github.com/choria-io/go-choria/integration/testutil"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/choria-io/go-choria/tokens"
+	"github.com/nats-io/jsm.go"
+	jsmgov "github.com/nats-io/jsm.go/governor"
 	"github.com/nats-io/nats.go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -310,6 +315,98 @@ var _ = Describe("Authentication", func() {
 		})
 	})
 
+	Describe("Governor Users", func() {
+		var (
+			edPrivateKey   ed25519.PrivateKey
+			edPublicKey    ed25519.PublicKey
+			clientSignerPK *rsa.PrivateKey
+		)
+
+		connect := func(perms *tokens.ClientPermissions) *nats.Conn {
+			jwt, err := testutil.CreateSignedServerJWT(clientSignerPK, edPublicKey, map[string]any{
+				"purpose":     tokens.ClientIDPurpose,
+				"callerid":    "up=ginkgo",
+				"public_key":  hex.EncodeToString(edPublicKey),
+				"permissions": perms,
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			nc, err := nats.Connect("nats://localhost:4222",
+				nats.Secure(&tls.Config{InsecureSkipVerify: true}),
+				nats.CustomInboxPrefix("c1.reply.e33bf0376d4accbb4a8fd24b2f840b2e"),
+				nats.Token(jwt),
+				nats.UserJWT(func() (string, error) {
+					return jwt, nil
+				}, func(n []byte) ([]byte, error) {
+					return choria.Ed25519Sign(edPrivateKey, n)
+				}),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			return nc
+		}
+
+		BeforeEach(func() {
+			td := GinkgoT().TempDir()
+			cfg, err := os.ReadFile("testdata/anontls.conf")
+			Expect(err).ToNot(HaveOccurred())
+			cfg = append(cfg, []byte(fmt.Sprintf("plugin.choria.network.stream.store = %s\nplugin.choria.network.stream.leader_election_replicas = 1\nplugin.choria.network.stream.event_replicas = 1\nplugin.choria.network.stream.machine_replicas = 1\nplugin.choria.network.stream.advisory_replicas = 1\n", td))...)
+			Expect(os.WriteFile(filepath.Join(td, "streams.conf"), cfg, 0600)).To(Succeed())
+
+			_, err = testbroker.StartNetworkBrokerWithConfigFile(ctx, &wg, filepath.Join(td, "streams.conf"), logger)
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(logbuff, 10).Should(gbytes.Say("Created stream KV_CHORIA_GOVERNOR_QUEUE"))
+
+			edPublicKey, edPrivateKey, err = choria.Ed25519KeyPair()
+			Expect(err).ToNot(HaveOccurred())
+			clientSignerPK, err = testutil.LoadRSAKey("../../ca/client-signer-key.pem")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("Should obtain weighted leases and use the wait queue", func() {
+			admin := connect(&tokens.ClientPermissions{StreamsAdmin: true})
+			defer admin.Close()
+			amgr, err := jsm.New(admin)
+			Expect(err).ToNot(HaveOccurred())
+			gm, err := jsmgov.NewJSGovernorManager("GINKGO", 2, time.Minute, 1, amgr, true, jsmgov.WithSubject("c1.governor.GINKGO"))
+			Expect(err).ToNot(HaveOccurred())
+
+			nc := connect(&tokens.ClientPermissions{StreamsUser: true, Governor: true})
+			defer nc.Close()
+			mgr, err := jsm.New(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			queue, err := governor.WaitQueue(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			gov, err := governor.New("GINKGO", mgr, governor.WithSubject("c1.governor.GINKGO"), governor.WithWeight(2))
+			Expect(err).ToNot(HaveOccurred())
+			lease, err := gov.Acquire(ctx, "ginkgo", 5*time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gm.Active()).To(Equal(uint64(2)))
+
+			waiters, err := governor.Waiters(queue, "GINKGO")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(waiters).To(BeEmpty())
+
+			Expect(lease.Release()).To(Succeed())
+			Expect(gm.Active()).To(Equal(uint64(0)))
+			Expect(logbuff.Contents()).ToNot(ContainSubstring("Publish Violation"))
+		})
+
+		It("Should not allow other users to access the wait queue", func() {
+			nc := connect(&tokens.ClientPermissions{StreamsUser: true})
+			defer nc.Close()
+
+			queue, err := governor.WaitQueue(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = queue.Put("GINKGO.x", []byte("{}"))
+			Expect(err).To(HaveOccurred())
+			Eventually(logbuff, 1).Should(gbytes.Say("Publish Violation - User .+up=ginkgo.+, Subject .+KV.CHORIA_GOVERNOR_QUEUE.GINKGO.x"))
+		})
+	})
+
 	Describe("In-process connections", func() {
 		var broker *network.Server
 		var err error
@@ -15,6 +15,7 @@ import (
 	"github.com/choria-io/go-choria/build"
 	"github.com/choria-io/go-choria/protocol"
 	election "github.com/choria-io/go-choria/providers/election/streams"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/choria-io/go-choria/providers/kv"
 	"github.com/choria-io/go-choria/srvcache"
 	"github.com/nats-io/nats.go"
@@ -78,6 +79,8 @@ type Framework interface {
 	NetworkBrokerPeers() (servers srvcache.Servers, err error)
 	NewElection(ctx context.Context, conn Connector, name string, imported bool, opts ...election.Option) (Election, error)
 	NewElectionWithConn(ctx context.Context, conn Connector, name string, imported bool, opts ...election.Option) (Election, Connector, error)
+	NewGovernor(ctx context.Context, conn Connector, name string, opts ...governor.Option) (*governor.Governor, error)
+	NewGovernorWithConn(ctx context.Context, conn Connector, name string, opts ...governor.Option) (*governor.Governor, Connector, error)
 	OverrideCertname() string
 	PQLQuery(query string) ([]byte, error)
 	PQLQueryCertNames(query string) ([]string, error)
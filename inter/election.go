@@ -1,4 +1,4 @@
-// Copyright (c) 2017-2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2017-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -14,4 +14,8 @@ type Election interface {
 	Stop()
 	// IsLeader determines if we are currently the leader
 	IsLeader() bool
+	// Token is the fencing token of the current leadership term, 0 when not the leader
+	Token() uint64
+	// StepDown gives up leadership allowing another candidate to become leader
+	StepDown() error
 }
@@ -18,6 +18,7 @@ import (
 	inter "github.com/choria-io/go-choria/inter"
 	protocol "github.com/choria-io/go-choria/protocol"
 	election "github.com/choria-io/go-choria/providers/election/streams"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	kv "github.com/choria-io/go-choria/providers/kv"
 	srvcache "github.com/choria-io/go-choria/srvcache"
 	gomock "github.com/golang/mock/gomock"
@@ -921,6 +922,47 @@ func (mr *MockFrameworkMockRecorder) NewElectionWithConn(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewElectionWithConn", reflect.TypeOf((*MockFramework)(nil).NewElectionWithConn), varargs...)
 }
 
+// NewGovernor mocks base method.
+func (m *MockFramework) NewGovernor(arg0 context.Context, arg1 inter.Connector, arg2 string, arg3 ...governor.Option) (*governor.Governor, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "NewGovernor", varargs...)
+	ret0, _ := ret[0].(*governor.Governor)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewGovernor indicates an expected call of NewGovernor.
+func (mr *MockFrameworkMockRecorder) NewGovernor(arg0, arg1, arg2 any, arg3 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewGovernor", reflect.TypeOf((*MockFramework)(nil).NewGovernor), varargs...)
+}
+
+// NewGovernorWithConn mocks base method.
+func (m *MockFramework) NewGovernorWithConn(arg0 context.Context, arg1 inter.Connector, arg2 string, arg3 ...governor.Option) (*governor.Governor, inter.Connector, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{arg0, arg1, arg2}
+	for _, a := range arg3 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "NewGovernorWithConn", varargs...)
+	ret0, _ := ret[0].(*governor.Governor)
+	ret1, _ := ret[1].(inter.Connector)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// NewGovernorWithConn indicates an expected call of NewGovernorWithConn.
+func (mr *MockFrameworkMockRecorder) NewGovernorWithConn(arg0, arg1, arg2 any, arg3 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{arg0, arg1, arg2}, arg3...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewGovernorWithConn", reflect.TypeOf((*MockFramework)(nil).NewGovernorWithConn), varargs...)
+}
+
 // NewMessage mocks base method.
 func (m *MockFramework) NewMessage(arg0, arg1, arg2, arg3 string, arg4 inter.Message) (inter.Message, error) {
 	m.ctrl.T.Helper()
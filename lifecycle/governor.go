@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -7,6 +7,7 @@ package lifecycle
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 // GovernorEvent is a io.choria.lifecycle.v1.governor event
@@ -17,6 +18,9 @@ type GovernorEvent struct {
 	Governor  string            `json:"governor"`
 	Sequence  uint64            `json:"sequence"`
 	EventType GovernorEventType `json:"event_type"`
+
+	// Duration is the seconds spent waiting for enter and timeout events and the seconds the slot was held for exit events
+	Duration float64 `json:"duration,omitempty"`
 }
 
 type GovernorEventType string
@@ -30,6 +34,8 @@ const (
 	GovernorTimeoutEvent GovernorEventType = "timeouts"
 	// GovernorEvictEvent is when a slot is evicted using a admin API
 	GovernorEvictEvent GovernorEventType = "eviction"
+	// GovernorWaitingEvent is when a slot could not be obtained immediately and the process joined the wait queue
+	GovernorWaitingEvent GovernorEventType = "waiting"
 )
 
 func init() {
@@ -56,7 +62,7 @@ func newGovernorEvent(opts ...Option) *GovernorEvent {
 
 func (g *GovernorEvent) SetEventType(stage GovernorEventType) error {
 	switch stage {
-	case GovernorEnterEvent, GovernorExitEvent, GovernorTimeoutEvent, GovernorEvictEvent:
+	case GovernorEnterEvent, GovernorExitEvent, GovernorTimeoutEvent, GovernorEvictEvent, GovernorWaitingEvent:
 		g.EventType = stage
 	default:
 		return fmt.Errorf("invalid stage")
@@ -73,32 +79,43 @@ func (g *GovernorEvent) SetGovernor(name string) {
 	g.Governor = name
 }
 
+func (g *GovernorEvent) SetDuration(d time.Duration) {
+	g.Duration = d.Seconds()
+}
+
 func (g *GovernorEvent) String() string {
 	switch g.EventType {
 	case GovernorExitEvent:
 		if g.Sequence > 0 {
 			return fmt.Sprintf("[governor] %s: vacated slot %d on %s", g.Ident, g.Sequence, g.Governor)
-		} else {
-			return fmt.Sprintf("[governor] %s: vacated %s", g.Ident, g.Governor)
 		}
 
+		return fmt.Sprintf("[governor] %s: vacated %s", g.Ident, g.Governor)
+
 	case GovernorEnterEvent:
 		if g.Sequence > 0 {
 			return fmt.Sprintf("[governor] %s: obtained slot %d on %s", g.Ident, g.Sequence, g.Governor)
-		} else {
-			return fmt.Sprintf("[governor] %s: obtained slot on %s", g.Ident, g.Governor)
 		}
 
+		return fmt.Sprintf("[governor] %s: obtained slot on %s", g.Ident, g.Governor)
+
 	case GovernorTimeoutEvent:
+		if g.Duration > 0 {
+			return fmt.Sprintf("[governor] %s: failed to obtain a slot on %s after %v", g.Ident, g.Governor, time.Duration(g.Duration*float64(time.Second)).Round(time.Millisecond))
+		}
+
 		return fmt.Sprintf("[governor] %s: failed to obtain a slot on %s", g.Ident, g.Governor)
 
+	case GovernorWaitingEvent:
+		return fmt.Sprintf("[governor] %s: waiting for a slot on %s", g.Ident, g.Governor)
+
 	case GovernorEvictEvent:
 		if g.Sequence > 0 {
 			return fmt.Sprintf("[governor] %s: evicted from slot %d on %s", g.Ident, g.Sequence, g.Governor)
-		} else {
-			return fmt.Sprintf("[governor] %s: evicted from %s", g.Ident, g.Governor)
 		}
 
+		return fmt.Sprintf("[governor] %s: evicted from %s", g.Ident, g.Governor)
+
 	default:
 		return fmt.Sprintf("[governor] %s: unknown stage on Governor %s", g.Ident, g.Governor)
 	}
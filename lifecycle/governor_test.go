@@ -5,6 +5,8 @@
 package lifecycle
 
 import (
+	"time"
+
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -47,6 +49,10 @@ var _ = Describe("GovernorEvent", func() {
 			Expect(e.String()).To(Equal("[governor] ginkgo.example.net: vacated slot 10 on PUPPET"))
 			e.EventType = GovernorTimeoutEvent
 			Expect(e.String()).To(Equal("[governor] ginkgo.example.net: failed to obtain a slot on PUPPET"))
+			e.SetDuration(90 * time.Second)
+			Expect(e.String()).To(Equal("[governor] ginkgo.example.net: failed to obtain a slot on PUPPET after 1m30s"))
+			e.EventType = GovernorWaitingEvent
+			Expect(e.String()).To(Equal("[governor] ginkgo.example.net: waiting for a slot on PUPPET"))
 		})
 	})
 })
@@ -6,6 +6,7 @@ package lifecycle
 
 import (
 	"errors"
+	"time"
 )
 
 // Option configures events
@@ -26,6 +27,7 @@ type GovernedEvent interface {
 	SetGovernor(name string)
 	SetSequence(seq uint64)
 	SetEventType(stage GovernorEventType) error
+	SetDuration(d time.Duration)
 }
 
 // Component set the component for events
@@ -108,3 +110,16 @@ func GovernorSequence(seq uint64) Option {
 		return nil
 	}
 }
+
+// GovernorDuration sets the time spent waiting for or holding a Governor slot
+func GovernorDuration(d time.Duration) Option {
+	return func(e any) error {
+		event, ok := e.(GovernedEvent)
+		if !ok {
+			return errors.New("cannot set governor, event is not a Governor event")
+		}
+
+		event.SetDuration(d)
+		return nil
+	}
+}
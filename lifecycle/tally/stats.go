@@ -1,4 +1,4 @@
-// Copyright (c) 2020-2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2020-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -12,6 +12,9 @@ import (
 
 var registerStats = true
 
+// governorBuckets range from 1 second to 4 hours
+var governorBuckets = []float64{1, 5, 15, 30, 60, 120, 300, 600, 1800, 3600, 7200, 14400}
+
 func (r *Recorder) createStats() {
 	r.okEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
 		Name: fmt.Sprintf("%s_good_events", r.options.StatPrefix),
@@ -53,6 +56,18 @@ func (r *Recorder) createStats() {
 		Help: "Choria Governor events",
 	}, []string{"component", "governor", "event"})
 
+	r.governorWaitTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    fmt.Sprintf("%s_governor_wait_time_seconds", r.options.StatPrefix),
+		Help:    "Time spent waiting to obtain a Choria Governor slot",
+		Buckets: governorBuckets,
+	}, []string{"component", "governor", "event"})
+
+	r.governorHoldTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    fmt.Sprintf("%s_governor_hold_time_seconds", r.options.StatPrefix),
+		Help:    "Time a Choria Governor slot was held for",
+		Buckets: governorBuckets,
+	}, []string{"component", "governor"})
+
 	if registerStats {
 		prometheus.MustRegister(r.okEvents)
 		prometheus.MustRegister(r.badEvents)
@@ -62,5 +77,7 @@ func (r *Recorder) createStats() {
 		prometheus.MustRegister(r.transitionEvent)
 		prometheus.MustRegister(r.nodesExpired)
 		prometheus.MustRegister(r.governorEvents)
+		prometheus.MustRegister(r.governorWaitTime)
+		prometheus.MustRegister(r.governorHoldTime)
 	}
 }
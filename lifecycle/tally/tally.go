@@ -33,13 +33,15 @@ type Recorder struct {
 	observed map[string]*observations
 
 	// lifecycle
-	okEvents       *prometheus.CounterVec
-	badEvents      *prometheus.CounterVec
-	versionsTally  *prometheus.GaugeVec
-	processTime    *prometheus.SummaryVec
-	eventTypes     *prometheus.CounterVec
-	nodesExpired   *prometheus.CounterVec
-	governorEvents *prometheus.CounterVec
+	okEvents         *prometheus.CounterVec
+	badEvents        *prometheus.CounterVec
+	versionsTally    *prometheus.GaugeVec
+	processTime      *prometheus.SummaryVec
+	eventTypes       *prometheus.CounterVec
+	nodesExpired     *prometheus.CounterVec
+	governorEvents   *prometheus.CounterVec
+	governorWaitTime *prometheus.HistogramVec
+	governorHoldTime *prometheus.HistogramVec
 
 	// transitions
 	transitionEvent *prometheus.CounterVec
@@ -178,6 +180,15 @@ func (r *Recorder) processGovernor(e lifecycle.Event) error {
 
 	r.governorEvents.WithLabelValues(governor.Component(), governor.Governor, string(governor.EventType)).Inc()
 
+	if governor.Duration > 0 {
+		switch governor.EventType {
+		case lifecycle.GovernorEnterEvent, lifecycle.GovernorTimeoutEvent:
+			r.governorWaitTime.WithLabelValues(governor.Component(), governor.Governor, string(governor.EventType)).Observe(governor.Duration)
+		case lifecycle.GovernorExitEvent:
+			r.governorHoldTime.WithLabelValues(governor.Component(), governor.Governor).Observe(governor.Duration)
+		}
+	}
+
 	return nil
 }
 
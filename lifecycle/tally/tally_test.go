@@ -148,6 +148,23 @@ var _ = Describe("Tally", func() {
 				Expect(getPromCountValue(recorder.governorEvents, "ginkgo", "GINKGO", "enter")).To(Equal(1.0))
 				Expect(getPromCountValue(recorder.governorEvents, "ginkgo", "GINKGO", "exit")).To(Equal(1.0))
 			})
+
+			It("Should record wait and hold times", func() {
+				event, err := lifecycle.New(lifecycle.Governor, lifecycle.Component("ginkgo"), lifecycle.GovernorName("GINKGO"), lifecycle.GovernorType(lifecycle.GovernorWaitingEvent))
+				Expect(err).ToNot(HaveOccurred())
+				recorder.process(event)
+				Expect(getPromCountValue(recorder.governorEvents, "ginkgo", "GINKGO", "waiting")).To(Equal(1.0))
+
+				event, err = lifecycle.New(lifecycle.Governor, lifecycle.Component("ginkgo"), lifecycle.GovernorName("GINKGO"), lifecycle.GovernorType(lifecycle.GovernorEnterEvent), lifecycle.GovernorDuration(10*time.Second))
+				Expect(err).ToNot(HaveOccurred())
+				recorder.process(event)
+				Expect(getPromHistogramCount(recorder.governorWaitTime, "ginkgo", "GINKGO", "enter")).To(Equal(uint64(1)))
+
+				event, err = lifecycle.New(lifecycle.Governor, lifecycle.Component("ginkgo"), lifecycle.GovernorName("GINKGO"), lifecycle.GovernorType(lifecycle.GovernorExitEvent), lifecycle.GovernorDuration(time.Minute))
+				Expect(err).ToNot(HaveOccurred())
+				recorder.process(event)
+				Expect(getPromHistogramCount(recorder.governorHoldTime, "ginkgo", "GINKGO")).To(Equal(uint64(1)))
+			})
 		})
 
 		Describe("Alive Events", func() {
@@ -216,6 +233,20 @@ func getPromCountValue(ctr *prometheus.CounterVec, labels ...string) float64 {
 	return pb.GetCounter().GetValue()
 }
 
+func getPromHistogramCount(hist *prometheus.HistogramVec, labels ...string) uint64 {
+	pb := &dto.Metric{}
+	m, err := hist.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		return 0
+	}
+
+	if m.(prometheus.Metric).Write(pb) != nil {
+		return 0
+	}
+
+	return pb.GetHistogram().GetSampleCount()
+}
+
 func getPromGaugeValue(ctr *prometheus.GaugeVec, labels ...string) float64 {
 	pb := &dto.Metric{}
 	m, err := ctr.GetMetricWithLabelValues(labels...)
@@ -6,6 +6,7 @@ package choriautil
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"runtime"
@@ -79,6 +80,23 @@ type machineTransitionReply struct {
 	Success bool `json:"success"`
 }
 
+type machineRequest struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	ID      string `json:"instance"`
+	Path    string `json:"path"`
+}
+
+type machineDataPutRequest struct {
+	machineRequest
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+type machineSuccessReply struct {
+	Success bool `json:"success"`
+}
+
 type machineStateRequest struct {
 	Name string `json:"name"`
 	ID   string `json:"instance"`
@@ -109,6 +127,10 @@ func New(mgr server.AgentManager) (*mcorpc.Agent, error) {
 	agent.MustRegisterAction("machine_states", machineStatesAction)
 	agent.MustRegisterAction("machine_state", machineStateAction)
 	agent.MustRegisterAction("machine_transition", machineTransitionAction)
+	agent.MustRegisterAction("machine_pause", machinePauseAction)
+	agent.MustRegisterAction("machine_resume", machineResumeAction)
+	agent.MustRegisterAction("machine_reload", machineReloadAction)
+	agent.MustRegisterAction("machine_data_put", machineDataPutAction)
 
 	return agent, nil
 }
@@ -196,6 +218,99 @@ func machineTransitionAction(ctx context.Context, req *mcorpc.Request, reply *mc
 	reply.Data = machineTransitionReply{Success: err == nil}
 }
 
+func machinePauseAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	i := machineRequest{}
+	if !mcorpc.ParseRequestData(&i, req, reply) {
+		return
+	}
+
+	if !machineRequestHasCriteria(i, reply) {
+		return
+	}
+
+	err := agent.ServerInfoSource.MachinePause(i.Name, i.Version, i.Path, i.ID)
+	if err != nil {
+		reply.Statuscode = mcorpc.Aborted
+		reply.Statusmsg = fmt.Sprintf("Could not pause machine: %s", err)
+	}
+
+	reply.Data = machineSuccessReply{Success: err == nil}
+}
+
+func machineResumeAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	i := machineRequest{}
+	if !mcorpc.ParseRequestData(&i, req, reply) {
+		return
+	}
+
+	if !machineRequestHasCriteria(i, reply) {
+		return
+	}
+
+	err := agent.ServerInfoSource.MachineResume(i.Name, i.Version, i.Path, i.ID)
+	if err != nil {
+		reply.Statuscode = mcorpc.Aborted
+		reply.Statusmsg = fmt.Sprintf("Could not resume machine: %s", err)
+	}
+
+	reply.Data = machineSuccessReply{Success: err == nil}
+}
+
+func machineReloadAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	i := machineRequest{}
+	if !mcorpc.ParseRequestData(&i, req, reply) {
+		return
+	}
+
+	if !machineRequestHasCriteria(i, reply) {
+		return
+	}
+
+	err := agent.ServerInfoSource.MachineReload(i.Name, i.Version, i.Path, i.ID)
+	if err != nil {
+		reply.Statuscode = mcorpc.Aborted
+		reply.Statusmsg = fmt.Sprintf("Could not reload machine: %s", err)
+	}
+
+	reply.Data = machineSuccessReply{Success: err == nil}
+}
+
+func machineDataPutAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
+	i := machineDataPutRequest{}
+	if !mcorpc.ParseRequestData(&i, req, reply) {
+		return
+	}
+
+	if !machineRequestHasCriteria(i.machineRequest, reply) {
+		return
+	}
+
+	// values that are valid JSON are stored as their parsed type, everything else as strings
+	var value any
+	err := json.Unmarshal([]byte(i.Value), &value)
+	if err != nil {
+		value = i.Value
+	}
+
+	err = agent.ServerInfoSource.MachineDataPut(i.Name, i.Version, i.Path, i.ID, i.Key, value)
+	if err != nil {
+		reply.Statuscode = mcorpc.Aborted
+		reply.Statusmsg = fmt.Sprintf("Could not store data item %s: %s", i.Key, err)
+	}
+
+	reply.Data = machineSuccessReply{Success: err == nil}
+}
+
+func machineRequestHasCriteria(i machineRequest, reply *mcorpc.Reply) bool {
+	if i.Name == "" && i.Path == "" && i.ID == "" {
+		reply.Statuscode = mcorpc.Aborted
+		reply.Statusmsg = "No search criteria given"
+		return false
+	}
+
+	return true
+}
+
 func machineStatesAction(ctx context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, conn inter.ConnectorInfo) {
 	states, err := agent.ServerInfoSource.MachinesStatus()
 	if err != nil {
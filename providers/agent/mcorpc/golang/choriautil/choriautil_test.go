@@ -0,0 +1,175 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package choriautil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/choria-io/go-choria/choria"
+	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+	"github.com/choria-io/go-choria/server/agents"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func Test(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "ChoriaUtil/Agent")
+}
+
+var _ = Describe("ChoriaUtil/Agent", func() {
+	var (
+		mockctl *gomock.Controller
+		si      *MockServerInfoSource
+		agent   *mcorpc.Agent
+		reply   *mcorpc.Reply
+		ctx     context.Context
+	)
+
+	request := func(data string) *mcorpc.Request {
+		return &mcorpc.Request{
+			Data:      json.RawMessage(data),
+			RequestID: "uniq_req_id",
+			CallerID:  "choria=rip.mcollective",
+			SenderID:  "go.test",
+		}
+	}
+
+	BeforeEach(func() {
+		mockctl = gomock.NewController(GinkgoT())
+
+		cfg := config.NewConfigForTests()
+		cfg.DisableTLS = true
+		cfg.InitiatedByServer = true
+		cfg.LogLevel = "warn"
+
+		fw, err := choria.NewWithConfig(cfg)
+		Expect(err).ToNot(HaveOccurred())
+
+		si = NewMockServerInfoSource(mockctl)
+		am := agents.New(make(chan inter.ConnectorMessage), fw, nil, si, logrus.WithFields(logrus.Fields{"test": "1"}))
+		agent, err = New(am)
+		Expect(err).ToNot(HaveOccurred())
+		agent.SetServerInfo(si)
+		logrus.SetLevel(logrus.FatalLevel)
+
+		reply = &mcorpc.Reply{}
+		ctx = context.Background()
+	})
+
+	AfterEach(func() {
+		mockctl.Finish()
+	})
+
+	Describe("New", func() {
+		It("Should create all the actions", func() {
+			Expect(agent.ActionNames()).To(Equal([]string{"info", "machine_data_put", "machine_pause", "machine_reload", "machine_resume", "machine_state", "machine_states", "machine_transition"}))
+		})
+	})
+
+	Describe("machinePauseAction", func() {
+		It("Should require criteria", func() {
+			machinePauseAction(ctx, request(`{}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("No search criteria given"))
+		})
+
+		It("Should pause the machine", func() {
+			si.EXPECT().MachinePause("ginkgo", "1.0.0", "", "").Return(nil)
+			machinePauseAction(ctx, request(`{"name":"ginkgo","version":"1.0.0"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: true}))
+		})
+
+		It("Should handle failures", func() {
+			si.EXPECT().MachinePause("ginkgo", "", "", "").Return(fmt.Errorf("not found"))
+			machinePauseAction(ctx, request(`{"name":"ginkgo"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Could not pause machine: not found"))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: false}))
+		})
+	})
+
+	Describe("machineResumeAction", func() {
+		It("Should require criteria", func() {
+			machineResumeAction(ctx, request(`{}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("No search criteria given"))
+		})
+
+		It("Should resume the machine", func() {
+			si.EXPECT().MachineResume("", "", "", "123").Return(nil)
+			machineResumeAction(ctx, request(`{"instance":"123"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: true}))
+		})
+
+		It("Should handle failures", func() {
+			si.EXPECT().MachineResume("ginkgo", "", "", "").Return(fmt.Errorf("not found"))
+			machineResumeAction(ctx, request(`{"name":"ginkgo"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Could not resume machine: not found"))
+		})
+	})
+
+	Describe("machineReloadAction", func() {
+		It("Should require criteria", func() {
+			machineReloadAction(ctx, request(`{}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("No search criteria given"))
+		})
+
+		It("Should reload the machine", func() {
+			si.EXPECT().MachineReload("", "", "/etc/choria/machines/ginkgo", "").Return(nil)
+			machineReloadAction(ctx, request(`{"path":"/etc/choria/machines/ginkgo"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: true}))
+		})
+
+		It("Should handle failures", func() {
+			si.EXPECT().MachineReload("ginkgo", "", "", "").Return(fmt.Errorf("cannot reload compiled in machine ginkgo"))
+			machineReloadAction(ctx, request(`{"name":"ginkgo"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Could not reload machine: cannot reload compiled in machine ginkgo"))
+		})
+	})
+
+	Describe("machineDataPutAction", func() {
+		It("Should require criteria", func() {
+			machineDataPutAction(ctx, request(`{"key":"x","value":"1"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("No search criteria given"))
+		})
+
+		It("Should store JSON values as their parsed type", func() {
+			si.EXPECT().MachineDataPut("ginkgo", "", "", "", "threshold", map[string]any{"warn": float64(10)}).Return(nil)
+			machineDataPutAction(ctx, request(`{"name":"ginkgo","key":"threshold","value":"{\"warn\":10}"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: true}))
+		})
+
+		It("Should store other values as strings", func() {
+			si.EXPECT().MachineDataPut("ginkgo", "", "", "", "owner", "ops team").Return(nil)
+			machineDataPutAction(ctx, request(`{"name":"ginkgo","key":"owner","value":"ops team"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.OK))
+		})
+
+		It("Should handle failures", func() {
+			si.EXPECT().MachineDataPut("ginkgo", "", "", "", "owner", "ops").Return(fmt.Errorf("not found"))
+			machineDataPutAction(ctx, request(`{"name":"ginkgo","key":"owner","value":"ops"}`), reply, agent, nil)
+			Expect(reply.Statuscode).To(Equal(mcorpc.Aborted))
+			Expect(reply.Statusmsg).To(Equal("Could not store data item owner: not found"))
+			Expect(reply.Data).To(Equal(machineSuccessReply{Success: false}))
+		})
+	})
+})
@@ -14,12 +14,15 @@
 package mcorpc
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/choria-io/go-choria/config"
+	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/protocol"
+	governor "github.com/choria-io/go-choria/providers/governor/streams"
 	"github.com/choria-io/go-choria/srvcache"
 	"github.com/choria-io/go-choria/validator"
 )
@@ -36,6 +39,12 @@ type ChoriaFramework interface {
 	Certname() string
 }
 
+// GovernorProvider is implemented by frameworks that can create Choria Governors, agents that need
+// leases should check if their ChoriaFramework implements it
+type GovernorProvider interface {
+	NewGovernor(ctx context.Context, conn inter.Connector, name string, opts ...governor.Option) (*governor.Governor, error)
+}
+
 // StatusCode is a reply status as defined by MCollective SimpleRPC - integers 0 to 5
 //
 // See the constants OK, RPCAborted, UnknownRPCAction, MissingRPCData, InvalidRPCData and UnknownRPCError
@@ -183,6 +183,62 @@ func (mr *MockServerInfoSourceMockRecorder) LastProcessedMessage() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastProcessedMessage", reflect.TypeOf((*MockServerInfoSource)(nil).LastProcessedMessage))
 }
 
+// MachineDataPut mocks base method.
+func (m *MockServerInfoSource) MachineDataPut(arg0, arg1, arg2, arg3, arg4 string, arg5 any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineDataPut", arg0, arg1, arg2, arg3, arg4, arg5)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineDataPut indicates an expected call of MachineDataPut.
+func (mr *MockServerInfoSourceMockRecorder) MachineDataPut(arg0, arg1, arg2, arg3, arg4, arg5 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineDataPut", reflect.TypeOf((*MockServerInfoSource)(nil).MachineDataPut), arg0, arg1, arg2, arg3, arg4, arg5)
+}
+
+// MachinePause mocks base method.
+func (m *MockServerInfoSource) MachinePause(arg0, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachinePause", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachinePause indicates an expected call of MachinePause.
+func (mr *MockServerInfoSourceMockRecorder) MachinePause(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachinePause", reflect.TypeOf((*MockServerInfoSource)(nil).MachinePause), arg0, arg1, arg2, arg3)
+}
+
+// MachineReload mocks base method.
+func (m *MockServerInfoSource) MachineReload(arg0, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineReload", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineReload indicates an expected call of MachineReload.
+func (mr *MockServerInfoSourceMockRecorder) MachineReload(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineReload", reflect.TypeOf((*MockServerInfoSource)(nil).MachineReload), arg0, arg1, arg2, arg3)
+}
+
+// MachineResume mocks base method.
+func (m *MockServerInfoSource) MachineResume(arg0, arg1, arg2, arg3 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineResume", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineResume indicates an expected call of MachineResume.
+func (mr *MockServerInfoSourceMockRecorder) MachineResume(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineResume", reflect.TypeOf((*MockServerInfoSource)(nil).MachineResume), arg0, arg1, arg2, arg3)
+}
+
 // MachineTransition mocks base method.
 func (m *MockServerInfoSource) MachineTransition(arg0, arg1, arg2, arg3, arg4 string) error {
 	m.ctrl.T.Helper()
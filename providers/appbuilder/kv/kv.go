@@ -17,6 +17,7 @@ import (
 	"github.com/choria-io/go-choria/choria"
 	"github.com/choria-io/go-choria/config"
 	"github.com/choria-io/go-choria/internal/util"
+	kvp "github.com/choria-io/go-choria/providers/kv"
 	"github.com/nats-io/nats.go"
 	"github.com/sirupsen/logrus"
 )
@@ -27,6 +28,7 @@ type Command struct {
 	Key        string             `json:"key"`
 	Value      string             `json:"value"`
 	RenderJSON bool               `json:"json"`
+	Force      bool               `json:"force"`
 	Transform  *builder.Transform `json:"transform"`
 
 	builder.GenericCommand
@@ -127,6 +129,10 @@ func (r *KV) CreateCommand(app builder.KingpinCommand) (*fisk.CmdClause, error)
 		r.cmd.Flag("json", "Renders results in JSON format").BoolVar(&r.def.RenderJSON)
 	}
 
+	if r.def.Action == "put" && !r.def.Force {
+		r.cmd.Flag("force", "Store the value even if it does not match the bucket schema").BoolVar(&r.def.Force)
+	}
+
 	return r.cmd, nil
 }
 
@@ -175,6 +181,13 @@ func (r *KV) putAction(kv nats.KeyValue) error {
 		return err
 	}
 
+	if !r.def.Force {
+		err = kvp.ValidateValue(kv, key, []byte(v))
+		if err != nil {
+			return err
+		}
+	}
+
 	rev, err := kv.PutString(key, v)
 	if err != nil {
 		return err
@@ -10,6 +10,7 @@ import (
 	"math"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/nats-io/nats.go"
@@ -50,12 +51,19 @@ type election struct {
 	opts  *options
 	state State
 
+	// token is the fencing token of the current term once leadership was notified, accessed atomically
+	token uint64
+	// termToken is the fencing token of the current term, published in token after the handover grace
+	termToken uint64
+
 	ctx        context.Context
 	cancel     context.CancelFunc
 	started    bool
 	lastSeq    uint64
 	tries      int
 	notifyNext bool
+	termStart  time.Time
+	holdUntil  time.Time
 
 	mu sync.Mutex
 }
@@ -129,6 +137,11 @@ func (e *election) debugf(format string, a ...any) {
 }
 
 func (e *election) campaignForLeadership() error {
+	if time.Now().Before(e.holdUntil) {
+		e.debugf("Not campaigning after stepping down")
+		return nil
+	}
+
 	campaignsCounter.WithLabelValues(e.opts.key, e.opts.name, stateNames[CandidateState]).Inc()
 
 	seq, err := e.opts.bucket.Create(e.opts.key, []byte(e.opts.name))
@@ -141,7 +154,10 @@ func (e *election) campaignForLeadership() error {
 	e.state = LeaderState
 	e.tries = 0
 	e.notifyNext = true // sets state that would notify about win on next campaign
+	e.termStart = time.Now()
+	e.termToken = seq
 	leaderGauge.WithLabelValues(e.opts.key, e.opts.name).Set(1)
+	leadershipChangesCounter.WithLabelValues(e.opts.key, e.opts.name, stateNames[LeaderState]).Inc()
 
 	return nil
 }
@@ -152,11 +168,10 @@ func (e *election) maintainLeadership() error {
 	seq, err := e.opts.bucket.Update(e.opts.key, []byte(e.opts.name), e.lastSeq)
 	if err != nil {
 		e.debugf("key update failed, moving to candidate state: %v", err)
+		e.endTerm()
 		e.state = CandidateState
 		e.lastSeq = math.MaxUint64
 
-		leaderGauge.WithLabelValues(e.opts.key, e.opts.name).Set(0)
-
 		if e.opts.lostCb != nil {
 			e.opts.lostCb()
 		}
@@ -165,9 +180,10 @@ func (e *election) maintainLeadership() error {
 	}
 	e.lastSeq = seq
 
-	// we wait till the next campaign to notify that we are leader to give others a chance to stand down
-	if e.notifyNext {
+	// we wait till the next campaign after the handover grace period to notify that we are leader to give others a chance to stand down
+	if e.notifyNext && time.Since(e.termStart) >= e.opts.grace {
 		e.notifyNext = false
+		atomic.StoreUint64(&e.token, e.termToken)
 		if e.opts.wonCb != nil {
 			ctxSleep(e.ctx, 200*time.Millisecond)
 			e.opts.wonCb()
@@ -177,6 +193,19 @@ func (e *election) maintainLeadership() error {
 	return nil
 }
 
+// endTerm records the end of a leadership term, must be called with the lock held
+func (e *election) endTerm() {
+	if e.state != LeaderState {
+		return
+	}
+
+	e.termToken = 0
+	atomic.StoreUint64(&e.token, 0)
+	leaderGauge.WithLabelValues(e.opts.key, e.opts.name).Set(0)
+	leadershipChangesCounter.WithLabelValues(e.opts.key, e.opts.name, stateNames[CandidateState]).Inc()
+	termTime.WithLabelValues(e.opts.key, e.opts.name).Observe(time.Since(e.termStart).Seconds())
+}
+
 func (e *election) try() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -257,6 +286,7 @@ func (e *election) stop() {
 	e.mu.Lock()
 	e.started = false
 	e.cancel()
+	e.endTerm()
 	e.state = CandidateState
 	e.lastSeq = math.MaxUint64
 	e.mu.Unlock()
@@ -273,6 +303,15 @@ func (e *election) Start(ctx context.Context) error {
 	e.started = true
 	e.mu.Unlock()
 
+	if e.opts.changeCb != nil {
+		go func() {
+			err := Observe(e.ctx, e.opts.bucket, e.opts.key, e.opts.changeCb)
+			if err != nil {
+				e.debugf("Observing leadership changes failed: %v", err)
+			}
+		}()
+	}
+
 	wg := &sync.WaitGroup{}
 	wg.Add(1)
 
@@ -310,6 +349,46 @@ func (e *election) IsLeader() bool {
 	return e.state == LeaderState && !e.notifyNext
 }
 
+// Token is a fencing token for the current leadership term, 0 when not the leader.
+//
+// Like IsLeader() the token is only available once leadership was notified after the handover grace period.
+//
+// The token is the revision of the election key when leadership was won, tokens therefore increase
+// with every new term and work done by the leader can be tagged with it so that work by earlier
+// leaders can be detected and rejected
+func (e *election) Token() uint64 {
+	return atomic.LoadUint64(&e.token)
+}
+
+// StepDown gives up leadership, allowing other candidates to take over. No campaigns will be done for
+// one bucket TTL allowing another candidate to win the election
+func (e *election) StepDown() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.state != LeaderState {
+		return fmt.Errorf("not the leader")
+	}
+
+	err := e.opts.bucket.Delete(e.opts.key, nats.LastRevision(e.lastSeq))
+	if err != nil {
+		return fmt.Errorf("could not step down: %v", err)
+	}
+
+	e.debugf("Stepped down from leadership")
+	e.endTerm()
+	e.state = CandidateState
+	e.lastSeq = math.MaxUint64
+	e.notifyNext = false
+	e.holdUntil = time.Now().Add(e.opts.ttl)
+
+	if e.opts.lostCb != nil {
+		e.opts.lostCb()
+	}
+
+	return nil
+}
+
 func (e *election) State() State {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -225,6 +225,92 @@ var _ = Describe("Choria KV Leader Election", func() {
 			}
 		})
 	})
+
+	Describe("StepDown", func() {
+		It("Should issue fencing tokens and allow stepping down", func() {
+			skipValidate = true
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			won := make(chan struct{}, 2)
+			lost := make(chan struct{}, 2)
+			elect, err := NewElection("member 1", "stepdown", kv, OnWon(func() { won <- struct{}{} }), OnLost(func() { lost <- struct{}{} }))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(elect.StepDown()).To(MatchError("not the leader"))
+
+			go elect.Start(ctx)
+
+			Eventually(won, 8*time.Second).Should(Receive())
+			token := elect.Token()
+			Expect(token).To(BeNumerically(">", 0))
+
+			entry, err := kv.Get("stepdown")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.Revision()).To(BeNumerically(">=", token))
+
+			Expect(elect.StepDown()).To(Succeed())
+			Expect(lost).To(Receive())
+			Expect(elect.Token()).To(Equal(uint64(0)))
+			Expect(elect.IsLeader()).To(BeFalse())
+
+			_, err = kv.Get("stepdown")
+			Expect(err).To(MatchError(nats.ErrKeyNotFound))
+
+			Eventually(won, 8*time.Second).Should(Receive())
+			Expect(elect.Token()).To(BeNumerically(">", token))
+		})
+
+		It("Should only issue tokens once leadership is notified", func() {
+			skipValidate = true
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			elect, err := NewElection("member 1", "grace", kv, WithHandoverGrace(time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+
+			go elect.Start(ctx)
+
+			Eventually(elect.State, 8*time.Second).Should(Equal(LeaderState))
+			Consistently(elect.IsLeader, time.Second).Should(BeFalse())
+			Expect(elect.Token()).To(Equal(uint64(0)))
+		})
+	})
+
+	Describe("Observe", func() {
+		It("Should report leadership changes", func() {
+			_, err := kv.Put("existing", []byte("member 1"))
+			Expect(err).ToNot(HaveOccurred())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			changes := make(chan Leadership, 10)
+			go Observe(ctx, kv, "", func(l Leadership) { changes <- l })
+
+			var change Leadership
+			Eventually(changes).Should(Receive(&change))
+			Expect(change.Election).To(Equal("existing"))
+			Expect(change.Leader).To(Equal("member 1"))
+			Expect(change.Previous).To(BeEmpty())
+
+			// campaigns by the same leader are not changes
+			_, err = kv.Put("existing", []byte("member 1"))
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.Put("existing", []byte("member 2"))
+			Expect(err).ToNot(HaveOccurred())
+			Eventually(changes).Should(Receive(&change))
+			Expect(change.Leader).To(Equal("member 2"))
+			Expect(change.Previous).To(Equal("member 1"))
+
+			Expect(kv.Delete("existing")).To(Succeed())
+			Eventually(changes).Should(Receive(&change))
+			Expect(change.Leader).To(BeEmpty())
+			Expect(change.Previous).To(Equal("member 2"))
+			Consistently(changes, 200*time.Millisecond).ShouldNot(Receive())
+		})
+	})
 })
 
 func startJSServer(t GinkgoTInterface) (*server.Server, *nats.Conn) {
@@ -0,0 +1,84 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package election
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Leadership is the leader of an election as observed in the election bucket
+type Leadership struct {
+	// Election is the name of the election
+	Election string `json:"election"`
+	// Leader is the name of the current leader, empty when the leader was evicted
+	Leader string `json:"leader"`
+	// Previous is the name of the leader before this change, empty when not known
+	Previous string `json:"previous,omitempty"`
+	// Since is when the leader took over, for leaders found when observation starts this is the time of its most recent campaign
+	Since time.Time `json:"since"`
+	// Revision is the revision of the election bucket key at the time of the change
+	Revision uint64 `json:"revision"`
+}
+
+// Observe watches the election bucket and calls cb whenever the leader of an election changes, an empty
+// election observes all elections in the bucket. The current leaders are reported when observation starts.
+//
+// Leaders that stop campaigning without being evicted are only noticed once a new leader is elected.
+// Blocks until ctx is done.
+func Observe(ctx context.Context, bucket nats.KeyValue, election string, cb func(Leadership)) error {
+	key := election
+	if key == "" {
+		key = ">"
+	}
+
+	watch, err := bucket.Watch(key)
+	if err != nil {
+		return fmt.Errorf("cannot watch election %s: %s", key, err)
+	}
+	defer watch.Stop()
+
+	leaders := make(map[string]string)
+
+	for {
+		select {
+		case entry, ok := <-watch.Updates():
+			if !ok {
+				return fmt.Errorf("election watch stopped")
+			}
+
+			// marks the end of the initial values
+			if entry == nil {
+				continue
+			}
+
+			change := Leadership{
+				Election: entry.Key(),
+				Since:    entry.Created(),
+				Revision: entry.Revision(),
+			}
+
+			if entry.Operation() == nats.KeyValuePut {
+				change.Leader = string(entry.Value())
+			}
+
+			previous, known := leaders[change.Election]
+			if previous == change.Leader && (known || change.Leader == "") {
+				continue
+			}
+
+			leaders[change.Election] = change.Leader
+			change.Previous = previous
+
+			cb(change)
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
@@ -19,9 +19,11 @@ type options struct {
 	bucket     nats.KeyValue
 	ttl        time.Duration
 	cInterval  time.Duration
+	grace      time.Duration
 	wonCb      func()
 	lostCb     func()
 	campaignCb func(s State)
+	changeCb   func(l Leadership)
 	bo         Backoff
 	debug      func(format string, a ...any)
 }
@@ -46,6 +48,18 @@ func OnCampaign(cb func(s State)) Option {
 	return func(o *options) { o.campaignCb = cb }
 }
 
+// WithHandoverGrace delays notifying a new leader that it won until at least grace has passed since winning,
+// allowing a previous leader time to notice it lost leadership and to stop. The notification happens on
+// the first campaign after the grace period
+func WithHandoverGrace(grace time.Duration) Option {
+	return func(o *options) { o.grace = grace }
+}
+
+// OnLeaderChange is called whenever the leader of the election changes, including when other members become leader
+func OnLeaderChange(cb func(l Leadership)) Option {
+	return func(o *options) { o.changeCb = cb }
+}
+
 // WithDebug sets a function to do debug logging with
 func WithDebug(cb func(format string, a ...any)) Option {
 	return func(o *options) { o.debug = cb }
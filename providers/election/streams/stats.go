@@ -1,4 +1,4 @@
-// Copyright (c) 2017-2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2017-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -23,10 +23,23 @@ var (
 		Name: "choria_election_interval_seconds",
 		Help: "The number of seconds between campaigns",
 	}, []string{"election", "identity"})
+
+	leadershipChangesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "choria_election_leadership_changes",
+		Help: "The number of times a specific instance gained or lost leadership",
+	}, []string{"election", "identity", "state"})
+
+	termTime = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "choria_election_term_seconds",
+		Help:    "How long a specific instance was the leader for",
+		Buckets: []float64{10, 30, 60, 300, 900, 1800, 3600, 14400, 43200, 86400, 604800},
+	}, []string{"election", "identity"})
 )
 
 func init() {
 	prometheus.MustRegister(campaignsCounter)
 	prometheus.MustRegister(leaderGauge)
 	prometheus.MustRegister(campaignIntervalGauge)
+	prometheus.MustRegister(leadershipChangesCounter)
+	prometheus.MustRegister(termTime)
 }
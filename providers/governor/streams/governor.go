@@ -0,0 +1,372 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package governor controls the concurrency of a network wide process using Choria Governors
+//
+// This is compatible with the Governors created by the jsm.go governor package and the
+// choria governor CLI but adds weighted leases that consume more than one slot in the
+// Governor and priority classes where waiters of a higher class obtain leases first.
+//
+// Waiters record themselves in the CHORIA_GOVERNOR_QUEUE bucket while campaigning, this
+// is used to order waiters and to show who is waiting for a lease. Within a priority class
+// waiters obtain leases in the order they started waiting.
+//
+// Long running services should use Acquire() which renews the lease in the background and
+// notifies when it is lost, Start() is suited to short lived processes like those started
+// by choria governor run.
+package governor
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nuid"
+)
+
+const (
+	// DefaultInterval default sleep between tries, set with WithInterval()
+	DefaultInterval = 250 * time.Millisecond
+
+	// LeaseHeader is the header holding the unique lease ID on every entry in the governor
+	LeaseHeader = "Choria-Governor-Lease"
+	// WeightHeader is the header holding the weight of the lease
+	WeightHeader = "Choria-Governor-Weight"
+	// PriorityHeader is the header holding the priority class of the lease
+	PriorityHeader = "Choria-Governor-Priority"
+)
+
+// Finisher signals that work is completed releasing the slots on the governor
+type Finisher func() error
+
+// Backoff controls the interval of checks
+type Backoff interface {
+	// Duration returns the time to sleep for the nth invocation
+	Duration(n int) time.Duration
+}
+
+// Logger is a custom logger
+type Logger interface {
+	Debugf(format string, a ...any)
+	Infof(format string, a ...any)
+	Warnf(format string, a ...any)
+	Errorf(format string, a ...any)
+}
+
+// Governor obtains weighted and prioritized leases on a Choria Governor
+type Governor struct {
+	name    string
+	stream  string
+	opts    *options
+	mgr     *jsm.Manager
+	nc      *nats.Conn
+	running bool
+
+	mu sync.Mutex
+}
+
+// New creates a new governor client for the governor name
+func New(name string, mgr *jsm.Manager, opts ...Option) (*Governor, error) {
+	g := &Governor{
+		name:   name,
+		stream: StreamName(name),
+		mgr:    mgr,
+		nc:     mgr.NatsConn(),
+		opts: &options{
+			subject:  fmt.Sprintf("$GOVERNOR.campaign.%s", name),
+			interval: DefaultInterval,
+			weight:   1,
+			priority: NormalPriority,
+		},
+	}
+
+	for _, opt := range opts {
+		opt(g.opts)
+	}
+
+	if g.opts.weight < 1 {
+		return nil, fmt.Errorf("weight should be 1 or more")
+	}
+
+	if _, ok := priorityNames[g.opts.priority]; !ok {
+		return nil, fmt.Errorf("invalid priority %d", g.opts.priority)
+	}
+
+	return g, nil
+}
+
+// StreamName is the name of the stream holding the entries for a governor
+func StreamName(governor string) string {
+	return fmt.Sprintf("GOVERNOR_%s", governor)
+}
+
+// Start attempts to get a lease in the Governor, gives up on context, call Finisher to signal end of work.
+// The sequence returned is the first entry in the governor taken by the lease
+func (g *Governor) Start(ctx context.Context, name string) (Finisher, uint64, error) {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return nil, 0, fmt.Errorf("already running")
+	}
+	g.running = true
+	g.mu.Unlock()
+
+	seqs, _, err := g.campaign(ctx, name)
+	if err != nil {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+
+		return nil, 0, err
+	}
+
+	return g.finisher(seqs), seqs[0], nil
+}
+
+// campaign obtains a lease returning the sequences it holds and the lease ID
+func (g *Governor) campaign(ctx context.Context, name string) ([]uint64, string, error) {
+	if g.opts.weight > 1 {
+		str, err := g.mgr.LoadStream(g.stream)
+		if err != nil {
+			return nil, "", fmt.Errorf("could not load governor %s: %s", g.name, err)
+		}
+
+		if int64(g.opts.weight) > str.MaxMsgs() {
+			return nil, "", fmt.Errorf("weight %d exceeds the capacity %d of governor %s", g.opts.weight, str.MaxMsgs(), g.name)
+		}
+	}
+
+	waiter := &Waiter{
+		ID:       nuid.Next(),
+		Name:     name,
+		Weight:   g.opts.weight,
+		Priority: g.opts.priority,
+		Since:    time.Now().UTC(),
+	}
+
+	var queue nats.KeyValue
+	if !g.opts.noQueue {
+		var err error
+		queue, err = WaitQueue(g.nc)
+		if err != nil {
+			g.warnf("Could not access the governor wait queue, campaigning without priority: %s", err)
+		} else {
+			defer queue.Delete(waiter.key(g.name))
+		}
+	}
+
+	g.debugf("Starting to campaign for a lease with weight %d and priority %s on %s using %s", g.opts.weight, g.opts.priority, g.name, g.opts.subject)
+
+	tries := 0
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			delay := g.opts.interval
+			if g.opts.bo != nil {
+				delay = g.opts.bo.Duration(tries)
+			}
+
+			seqs, err := g.try(ctx, queue, waiter, delay)
+			if err == nil {
+				g.infof("Got a lease with weight %d on %s with sequence %d", g.opts.weight, g.name, seqs[0])
+				return seqs, waiter.ID, nil
+			}
+
+			if tries == 0 && g.opts.waiting != nil {
+				g.opts.waiting()
+			}
+
+			tries++
+			timer.Reset(delay)
+
+		case <-ctx.Done():
+			g.infof("Stopping campaigns against %s due to context timeout after %d tries", g.name, tries)
+			return nil, "", ctx.Err()
+		}
+	}
+}
+
+func (g *Governor) try(ctx context.Context, queue nats.KeyValue, waiter *Waiter, delay time.Duration) ([]uint64, error) {
+	if queue != nil {
+		waiter.Expires = time.Now().UTC().Add(queueTTL(delay))
+		err := waiter.save(queue, g.name)
+		if err != nil {
+			g.warnf("Could not record waiter in the governor wait queue: %s", err)
+		}
+
+		waiters, err := Waiters(queue, g.name)
+		if err != nil {
+			g.warnf("Could not load the governor wait queue: %s", err)
+		}
+
+		blocker := blockedBy(waiters, waiter, time.Now(), g.freeSlots(waiters, waiter))
+		if blocker != nil {
+			g.debugf("Deferring to %s waiter %s on %s", blocker.Priority, blocker.Name, g.name)
+			return nil, fmt.Errorf("deferring to %s", blocker.Name)
+		}
+	}
+
+	return g.acquire(ctx, waiter.Name, waiter.ID)
+}
+
+// freeSlots is the number of unused slots in the governor, it is only looked up when earlier waiters of the same priority are queued
+func (g *Governor) freeSlots(waiters []*Waiter, waiter *Waiter) int {
+	queued := false
+	for _, o := range waiters {
+		if o.ID != waiter.ID && o.Priority == waiter.Priority && o.ahead(waiter) {
+			queued = true
+			break
+		}
+	}
+
+	if !queued {
+		return waiter.Weight
+	}
+
+	str, err := g.mgr.LoadStream(g.stream)
+	if err != nil {
+		g.warnf("Could not load governor %s to determine free slots: %s", g.name, err)
+		return 0
+	}
+
+	nfo, err := str.Information()
+	if err != nil {
+		g.warnf("Could not load governor %s state to determine free slots: %s", g.name, err)
+		return 0
+	}
+
+	free := str.MaxMsgs() - int64(nfo.State.Msgs)
+	if free < 0 {
+		return 0
+	}
+
+	return int(free)
+}
+
+// acquire publishes weight entries into the governor, releasing all if any fails
+func (g *Governor) acquire(ctx context.Context, name string, lease string) ([]uint64, error) {
+	var seqs []uint64
+
+	for i := 0; i < g.opts.weight; i++ {
+		seq, err := g.publish(ctx, name, lease)
+		if err != nil {
+			if len(seqs) > 0 {
+				g.debugf("Releasing %d partially obtained slots on %s", len(seqs), g.name)
+				g.release(seqs)
+			}
+
+			return nil, err
+		}
+
+		seqs = append(seqs, seq)
+	}
+
+	return seqs, nil
+}
+
+func (g *Governor) publish(ctx context.Context, name string, lease string) (uint64, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+
+	msg := nats.NewMsg(g.opts.subject)
+	msg.Data = []byte(name)
+	msg.Header.Set(LeaseHeader, lease)
+	msg.Header.Set(WeightHeader, strconv.Itoa(g.opts.weight))
+	msg.Header.Set(PriorityHeader, g.opts.priority.String())
+
+	g.debugf("Publishing to %s", g.opts.subject)
+	m, err := g.nc.RequestMsgWithContext(ctx, msg)
+	if err != nil {
+		g.errorf("Publishing to governor %s via %s failed: %s", g.name, g.opts.subject, err)
+		return 0, err
+	}
+
+	res, err := jsm.ParsePubAck(m)
+	if err != nil {
+		if !jsm.IsNatsError(err, 10077) {
+			g.errorf("Invalid pub ack: %s", err)
+		}
+
+		return 0, err
+	}
+
+	return res.Sequence, nil
+}
+
+func (g *Governor) release(seqs []uint64) error {
+	var lastErr error
+
+	for _, seq := range seqs {
+		err := g.mgr.DeleteStreamMessage(g.stream, seq, true)
+		if err != nil {
+			g.errorf("Could not remove sequence %d from %s: %s", seq, g.name, err)
+			lastErr = fmt.Errorf("could not remove seq %d: %s", seq, err)
+		}
+	}
+
+	return lastErr
+}
+
+func (g *Governor) finisher(seqs []uint64) Finisher {
+	return func() error {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		if !g.running {
+			return nil
+		}
+
+		g.running = false
+
+		if g.opts.noLeave {
+			g.infof("Not evicting self from %s based on configuration directive", g.name)
+			return nil
+		}
+
+		g.infof("Removing self from %s sequences %v", g.name, seqs)
+
+		return g.release(seqs)
+	}
+}
+
+// queueTTL is how long a waiter entry is valid for when the next campaign is after delay
+func queueTTL(delay time.Duration) time.Duration {
+	ttl := 3 * delay
+	if ttl < 10*time.Second {
+		ttl = 10 * time.Second
+	}
+
+	return ttl
+}
+
+func (g *Governor) debugf(format string, a ...any) {
+	if g.opts.log != nil {
+		g.opts.log.Debugf(format, a...)
+	}
+}
+
+func (g *Governor) infof(format string, a ...any) {
+	if g.opts.log != nil {
+		g.opts.log.Infof(format, a...)
+	}
+}
+
+func (g *Governor) warnf(format string, a ...any) {
+	if g.opts.log != nil {
+		g.opts.log.Warnf(format, a...)
+	}
+}
+
+func (g *Governor) errorf(format string, a ...any) {
+	if g.opts.log != nil {
+		g.opts.log.Errorf(format, a...)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package governor
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+	jsmgov "github.com/nats-io/jsm.go/governor"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestGovernor(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Providers/Governor/Streams")
+}
+
+var _ = Describe("Choria Governor", func() {
+	var (
+		srv *server.Server
+		nc  *nats.Conn
+		mgr *jsm.Manager
+		gm  jsmgov.Manager
+		err error
+	)
+
+	BeforeEach(func() {
+		srv, nc = startJSServer(GinkgoT())
+		mgr, err = jsm.New(nc)
+		Expect(err).ToNot(HaveOccurred())
+
+		gm, err = jsmgov.NewJSGovernorManager("TEST", 3, time.Minute, 1, mgr, true, jsmgov.WithSubject("choria.governor.TEST"))
+		Expect(err).ToNot(HaveOccurred())
+
+		js, err := nc.JetStream()
+		Expect(err).ToNot(HaveOccurred())
+		_, err = js.CreateKeyValue(&nats.KeyValueConfig{Bucket: QueueBucket, History: 1, TTL: 24 * time.Hour})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		nc.Close()
+		srv.Shutdown()
+		srv.WaitForShutdown()
+		if srv.StoreDir() != "" {
+			os.RemoveAll(srv.StoreDir())
+		}
+	})
+
+	newGov := func(opts ...Option) *Governor {
+		opts = append([]Option{WithSubject("choria.governor.TEST"), WithInterval(50 * time.Millisecond)}, opts...)
+		gov, err := New("TEST", mgr, opts...)
+		Expect(err).ToNot(HaveOccurred())
+
+		return gov
+	}
+
+	Describe("New", func() {
+		It("Should validate options", func() {
+			_, err := New("TEST", mgr, WithWeight(0))
+			Expect(err).To(MatchError("weight should be 1 or more"))
+
+			_, err = New("TEST", mgr, WithPriority(Priority(10)))
+			Expect(err).To(MatchError("invalid priority 10"))
+		})
+	})
+
+	Describe("Priorities", func() {
+		It("Should parse priorities", func() {
+			p, err := ParsePriority("")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(NormalPriority))
+
+			p, err = ParsePriority("URGENT")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal(UrgentPriority))
+			Expect(p.String()).To(Equal("urgent"))
+
+			_, err = ParsePriority("meh")
+			Expect(err).To(MatchError(`unknown priority "meh", valid priorities are low, normal, high, urgent`))
+		})
+	})
+
+	Describe("Weighted leases", func() {
+		It("Should consume multiple slots", func() {
+			fin, seq, err := newGov(WithWeight(2)).Start(context.Background(), "heavy")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(seq).To(Equal(uint64(1)))
+			Expect(gm.Active()).To(Equal(uint64(2)))
+
+			msg, err := gm.Stream().ReadMessage(2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(msg.Data)).To(Equal("heavy"))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			_, _, err = newGov(WithWeight(2)).Start(ctx, "other")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+
+			// partial leases are released
+			Expect(gm.Active()).To(Equal(uint64(2)))
+
+			Expect(fin()).To(Succeed())
+			Expect(gm.Active()).To(Equal(uint64(0)))
+		})
+
+		It("Should reject weights exceeding capacity", func() {
+			_, _, err := newGov(WithWeight(4)).Start(context.Background(), "huge")
+			Expect(err).To(MatchError("weight 4 exceeds the capacity 3 of governor TEST"))
+		})
+	})
+
+	Describe("Priority leases", func() {
+		It("Should defer to higher priority waiters", func() {
+			queue, err := WaitQueue(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			urgent := &Waiter{ID: "urgent", Name: "urgent", Weight: 1, Priority: UrgentPriority, Since: time.Now(), Expires: time.Now().Add(time.Minute)}
+			Expect(urgent.save(queue, "TEST")).To(Succeed())
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			_, _, err = newGov().Start(ctx, "normal")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(gm.Active()).To(Equal(uint64(0)))
+
+			waiters, err := Waiters(queue, "TEST")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(waiters).To(HaveLen(1))
+			Expect(waiters[0].ID).To(Equal("urgent"))
+
+			fin, _, err := newGov(WithPriority(UrgentPriority)).Start(context.Background(), "other urgent")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fin()).To(Succeed())
+
+			urgent.Expires = time.Now().Add(-time.Second)
+			Expect(urgent.save(queue, "TEST")).To(Succeed())
+			fin, _, err = newGov().Start(context.Background(), "normal")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fin()).To(Succeed())
+		})
+
+		It("Should order waiters", func() {
+			now := time.Now()
+			waiters := []*Waiter{
+				{ID: "1", Priority: LowPriority, Since: now.Add(-time.Hour)},
+				{ID: "2", Priority: NormalPriority, Since: now},
+				{ID: "3", Priority: NormalPriority, Since: now.Add(-time.Minute)},
+				{ID: "4", Priority: HighPriority, Since: now},
+			}
+
+			sortWaiters(waiters)
+			var ids []string
+			for _, w := range waiters {
+				ids = append(ids, w.ID)
+			}
+			Expect(ids).To(Equal([]string{"4", "3", "2", "1"}))
+		})
+	})
+
+	Describe("Leases", func() {
+		It("Should acquire and release leases", func() {
+			lease, err := newGov(WithWeight(2)).Acquire(context.Background(), "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(lease.Sequence()).To(Equal(uint64(1)))
+			Expect(gm.Active()).To(Equal(uint64(2)))
+
+			_, err = newGov(WithWeight(2)).Acquire(context.Background(), "other", 200*time.Millisecond)
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+
+			Expect(lease.Release()).To(Succeed())
+			Expect(lease.Context().Err()).To(HaveOccurred())
+			Expect(lease.Err()).ToNot(HaveOccurred())
+			Expect(gm.Active()).To(Equal(uint64(0)))
+		})
+
+		It("Should release the lease when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			lease, err := newGov().Acquire(ctx, "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gm.Active()).To(Equal(uint64(1)))
+
+			cancel()
+			Eventually(gm.Active).Should(Equal(uint64(0)))
+			Expect(lease.Err()).ToNot(HaveOccurred())
+		})
+
+		It("Should notify when the lease is lost", func() {
+			lost := make(chan error, 1)
+			lease, err := newGov(WithRenewInterval(time.Second), WithLeaseLostCallback(func(err error) { lost <- err })).Acquire(context.Background(), "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = gm.Evict(lease.Sequence())
+			Expect(err).ToNot(HaveOccurred())
+
+			var lerr error
+			Eventually(lost, 3*time.Second).Should(Receive(&lerr))
+			Expect(lerr).To(MatchError(ErrLeaseLost))
+			Expect(lease.Err()).To(MatchError(ErrLeaseLost))
+			Expect(lease.Context().Err()).To(HaveOccurred())
+		})
+
+		It("Should renew leases before they expire", func() {
+			gm, err = jsmgov.NewJSGovernorManager("TEST", 3, 2*time.Second, 1, mgr, true, jsmgov.WithSubject("choria.governor.TEST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			lease, err := newGov().Acquire(context.Background(), "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			first := lease.Sequence()
+
+			time.Sleep(3 * time.Second)
+			Expect(lease.Err()).ToNot(HaveOccurred())
+			Expect(lease.Sequence()).To(BeNumerically(">", first))
+			Expect(gm.Active()).To(Equal(uint64(1)))
+			Expect(lease.Release()).To(Succeed())
+		})
+	})
+
+	Describe("Lease renewal", func() {
+		It("Should publish replacements before removing entries", func() {
+			gm, err = jsmgov.NewJSGovernorManager("TEST", 3, 2*time.Second, 1, mgr, true, jsmgov.WithSubject("choria.governor.TEST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			type deletion struct {
+				seq  uint64
+				last uint64
+			}
+			deletions := make(chan deletion, 10)
+
+			// observes delete requests and the last sequence in the governor at that time
+			sub, err := nc.Subscribe("$JS.API.STREAM.MSG.DELETE.GOVERNOR_TEST", func(m *nats.Msg) {
+				var req struct {
+					Seq uint64 `json:"seq"`
+				}
+				json.Unmarshal(m.Data, &req)
+
+				nfo, err := gm.Stream().Information()
+				if err == nil {
+					deletions <- deletion{seq: req.Seq, last: nfo.State.LastSeq}
+				}
+			})
+			Expect(err).ToNot(HaveOccurred())
+			defer sub.Unsubscribe()
+
+			lease, err := newGov(WithRenewInterval(100*time.Millisecond)).Acquire(context.Background(), "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			first := lease.Sequence()
+
+			var d deletion
+			Eventually(deletions, 2*time.Second).Should(Receive(&d))
+			Expect(d.seq).To(Equal(first))
+			Expect(d.last).To(BeNumerically(">", first))
+
+			Expect(lease.Err()).ToNot(HaveOccurred())
+			Expect(lease.Sequence()).To(BeNumerically(">", first))
+			Expect(gm.Active()).To(Equal(uint64(1)))
+			Expect(lease.Release()).To(Succeed())
+		})
+
+		It("Should not lose slots to waiting campaigners in a full governor", func() {
+			gm, err = jsmgov.NewJSGovernorManager("TEST", 1, 4*time.Second, 1, mgr, true, jsmgov.WithSubject("choria.governor.TEST"))
+			Expect(err).ToNot(HaveOccurred())
+
+			queue, err := WaitQueue(nc)
+			Expect(err).ToNot(HaveOccurred())
+
+			lease, err := newGov(WithRenewInterval(250*time.Millisecond)).Acquire(context.Background(), "lease", time.Second)
+			Expect(err).ToNot(HaveOccurred())
+			first := lease.Sequence()
+
+			campaign := make(chan error, 1)
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+				defer cancel()
+				_, _, err := newGov(WithInterval(10*time.Millisecond)).Start(ctx, "other")
+				campaign <- err
+			}()
+
+			// while renewing the lease reserves its slot ahead of all waiters
+			Eventually(func() []*Waiter {
+				waiters, _ := Waiters(queue, "TEST")
+				return waiters
+			}, 4*time.Second, 10*time.Millisecond).Should(ContainElement(SatisfyAll(
+				HaveField("ID", lease.ID()),
+				HaveField("Priority", UrgentPriority),
+			)))
+
+			Eventually(campaign, 6*time.Second).Should(Receive(MatchError(context.DeadlineExceeded)))
+
+			Expect(lease.Err()).ToNot(HaveOccurred())
+			Expect(lease.Sequence()).To(BeNumerically(">", first))
+			Expect(gm.Active()).To(Equal(uint64(1)))
+
+			msg, err := gm.Stream().ReadMessage(lease.Sequence())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(msg.Data)).To(Equal("lease"))
+
+			Expect(lease.Release()).To(Succeed())
+		})
+	})
+
+	Describe("Wait queue fairness", func() {
+		It("Should only block on earlier waiters of the same priority when free slots are insufficient", func() {
+			now := time.Now()
+			first := &Waiter{ID: "1", Weight: 2, Priority: NormalPriority, Since: now.Add(-time.Minute), Expires: now.Add(time.Minute)}
+			second := &Waiter{ID: "2", Weight: 1, Priority: NormalPriority, Since: now, Expires: now.Add(time.Minute)}
+			low := &Waiter{ID: "3", Weight: 1, Priority: LowPriority, Since: now.Add(-time.Hour), Expires: now.Add(time.Minute)}
+			waiters := []*Waiter{first, second, low}
+			sortWaiters(waiters)
+
+			Expect(blockedBy(waiters, first, now, 2)).To(BeNil())
+			Expect(blockedBy(waiters, second, now, 3)).To(BeNil())
+			Expect(blockedBy(waiters, second, now, 2)).To(Equal(first))
+			Expect(blockedBy(waiters, low, now, 10)).To(Equal(first))
+
+			first.Expires = now.Add(-time.Second)
+			Expect(blockedBy(waiters, second, now, 1)).To(BeNil())
+		})
+
+		It("Should notify when waiting", func() {
+			fin, _, err := newGov(WithWeight(3)).Start(context.Background(), "all")
+			Expect(err).ToNot(HaveOccurred())
+
+			waiting := 0
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+			_, _, err = newGov(WithWaitingCallback(func() { waiting++ })).Start(ctx, "waiter")
+			Expect(err).To(MatchError(context.DeadlineExceeded))
+			Expect(waiting).To(Equal(1))
+
+			Expect(fin()).To(Succeed())
+		})
+	})
+})
+
+func startJSServer(t GinkgoTInterface) (*server.Server, *nats.Conn) {
+	t.Helper()
+
+	d, err := os.MkdirTemp("", "jstest")
+	if err != nil {
+		t.Fatalf("temp dir could not be made: %s", err)
+	}
+
+	opts := &server.Options{
+		JetStream: true,
+		StoreDir:  d,
+		Port:      -1,
+		Host:      "localhost",
+	}
+
+	s, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal("server start failed: ", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Error("nats server did not start")
+	}
+
+	nc, err := nats.Connect(s.ClientURL(), nats.UseOldRequestStyle())
+	if err != nil {
+		t.Fatalf("client start failed: %s", err)
+	}
+
+	return s, nc
+}
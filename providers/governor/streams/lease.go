@@ -0,0 +1,344 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package governor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/nats-io/jsm.go"
+)
+
+const (
+	// DefaultRenewInterval is how often leases are checked when the governor entries do not expire
+	DefaultRenewInterval = 30 * time.Second
+
+	// minRenewInterval is the smallest interval leases are checked at
+	minRenewInterval = time.Second
+
+	// renewSettleTime is how long leases wait after reserving slots in the wait queue before renewing entries in a full governor
+	renewSettleTime = time.Second
+)
+
+// ErrLeaseLost indicates a lease was evicted from the governor or could not be renewed
+var ErrLeaseLost = errors.New("governor lease lost")
+
+// Lease is a lease held on a Governor obtained using Acquire, it is renewed in the
+// background until released or lost
+type Lease struct {
+	gov    *Governor
+	id     string
+	seqs   []uint64
+	maxAge time.Duration
+	ctx    context.Context
+	cancel context.CancelFunc
+	err    error
+	done   bool
+
+	mu sync.Mutex
+}
+
+// Acquire obtains a lease on the governor giving up after timeout, the lease is held until Release is
+// called or ctx is done.
+//
+// Unlike Start entries in the governor are renewed in the background so that work can safely run for
+// longer than the governor expiry time. When the lease is evicted from the governor or cannot be renewed
+// the callback set using WithLeaseLostCallback is called and the context of the lease is cancelled, work
+// that should stop when the lease is lost should therefore use Context()
+func (g *Governor) Acquire(ctx context.Context, name string, timeout time.Duration) (*Lease, error) {
+	g.mu.Lock()
+	if g.running {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("already running")
+	}
+	g.running = true
+	g.mu.Unlock()
+
+	lease, err := g.acquireLease(ctx, name, timeout)
+	if err != nil {
+		g.mu.Lock()
+		g.running = false
+		g.mu.Unlock()
+
+		return nil, err
+	}
+
+	go lease.maintain()
+
+	return lease, nil
+}
+
+func (g *Governor) acquireLease(ctx context.Context, name string, timeout time.Duration) (*Lease, error) {
+	str, err := g.mgr.LoadStream(g.stream)
+	if err != nil {
+		return nil, fmt.Errorf("could not load governor %s: %s", g.name, err)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	seqs, id, err := g.campaign(tctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &Lease{
+		gov:    g,
+		id:     id,
+		seqs:   seqs,
+		maxAge: str.MaxAge(),
+	}
+	lease.ctx, lease.cancel = context.WithCancel(ctx)
+
+	return lease, nil
+}
+
+// ID is the unique ID of the lease recorded in the LeaseHeader of its governor entries
+func (l *Lease) ID() string {
+	return l.id
+}
+
+// Sequence is the first entry in the governor held by the lease, this changes as the lease is renewed
+func (l *Lease) Sequence() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.seqs) == 0 {
+		return 0
+	}
+
+	return l.seqs[0]
+}
+
+// Context is cancelled once the lease is released or lost
+func (l *Lease) Context() context.Context {
+	return l.ctx
+}
+
+// Err is the reason the lease was lost, nil while held or after being released
+func (l *Lease) Err() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.err
+}
+
+// Release gives up the lease, releasing its slots in the governor
+func (l *Lease) Release() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return l.err
+	}
+
+	return l.finish()
+}
+
+// finish releases the slots held by the lease and cancels its context, must be called with the lock held
+func (l *Lease) finish() error {
+	g := l.gov
+
+	l.done = true
+	l.cancel()
+
+	g.mu.Lock()
+	g.running = false
+	g.mu.Unlock()
+
+	if g.opts.noLeave {
+		g.infof("Not evicting self from %s based on configuration directive", g.name)
+		return nil
+	}
+
+	g.infof("Removing self from %s sequences %v", g.name, l.seqs)
+
+	return g.release(l.seqs)
+}
+
+func (l *Lease) renewInterval() time.Duration {
+	interval := l.gov.opts.renew
+	switch {
+	case interval > 0:
+	case l.maxAge > 0:
+		interval = l.maxAge / 4
+	default:
+		interval = DefaultRenewInterval
+	}
+
+	if interval < minRenewInterval {
+		interval = minRenewInterval
+	}
+
+	return interval
+}
+
+func (l *Lease) maintain() {
+	ticker := time.NewTicker(l.renewInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := l.renew()
+			if err != nil {
+				l.lost(err)
+				return
+			}
+
+		case <-l.ctx.Done():
+			l.Release()
+			return
+		}
+	}
+}
+
+// lost releases what remains of the lease and notifies the lost callback
+func (l *Lease) lost(err error) {
+	l.mu.Lock()
+	if l.done {
+		l.mu.Unlock()
+		return
+	}
+
+	l.gov.errorf("Lease %s on %s lost: %s", l.id, l.gov.name, err)
+	l.err = fmt.Errorf("%w: %s", ErrLeaseLost, err)
+	l.finish()
+	lerr := l.err
+	l.mu.Unlock()
+
+	if l.gov.opts.lost != nil {
+		l.gov.opts.lost(lerr)
+	}
+}
+
+// renew checks that all entries of the lease are still in the governor and replaces those older than half the expiry time,
+// replacements are published before the old entries are removed so the slots are never free for other campaigners
+func (l *Lease) renew() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.done {
+		return nil
+	}
+
+	g := l.gov
+	str, err := g.mgr.LoadStream(g.stream)
+	if err != nil {
+		g.warnf("Could not load governor %s to renew lease %s: %s", g.name, l.id, err)
+		return nil
+	}
+
+	var (
+		name string
+		full []int
+	)
+
+	for i, seq := range l.seqs {
+		msg, err := str.ReadMessage(seq)
+		if err != nil {
+			if jsm.IsNatsError(err, 10037) {
+				return fmt.Errorf("sequence %d was evicted", seq)
+			}
+
+			g.warnf("Could not read sequence %d from %s: %s", seq, g.name, err)
+			continue
+		}
+
+		if l.maxAge == 0 || time.Since(msg.Time) < l.maxAge/2 {
+			continue
+		}
+
+		name = string(msg.Data)
+
+		nseq, err := g.publish(l.ctx, name, l.id)
+		if err != nil {
+			if jsm.IsNatsError(err, 10077) {
+				full = append(full, i)
+				continue
+			}
+
+			g.warnf("Could not renew sequence %d on %s: %s", seq, g.name, err)
+			continue
+		}
+
+		err = g.mgr.DeleteStreamMessage(g.stream, seq, true)
+		if err != nil {
+			g.warnf("Could not remove sequence %d from %s while renewing: %s", seq, g.name, err)
+			g.release([]uint64{nseq})
+			continue
+		}
+
+		g.debugf("Renewed sequence %d on %s as %d", seq, g.name, nseq)
+		l.seqs[i] = nseq
+	}
+
+	if len(full) == 0 {
+		return nil
+	}
+
+	return l.replace(name, full)
+}
+
+// replace renews entries in a full governor by removing and publishing them again, while doing so the lease
+// is recorded in the wait queue ahead of all other waiters so that campaigners using the queue defer to it.
+// Campaigners that do not use the wait queue could obtain the slot, the lease is then lost.
+func (l *Lease) replace(name string, idx []int) error {
+	g := l.gov
+
+	if !g.opts.noQueue {
+		queue, err := WaitQueue(g.nc)
+		if err != nil {
+			g.warnf("Could not access the governor wait queue, renewing lease %s without a reservation: %s", l.id, err)
+		} else {
+			reservation := &Waiter{
+				ID:       l.id,
+				Name:     name,
+				Weight:   len(idx),
+				Priority: UrgentPriority,
+				Since:    time.Unix(0, 0).UTC(),
+				Expires:  time.Now().UTC().Add(renewSettleTime + queueTTL(0)),
+			}
+
+			err = reservation.save(queue, g.name)
+			if err != nil {
+				g.warnf("Could not reserve slots in the governor wait queue while renewing lease %s: %s", l.id, err)
+			} else {
+				defer queue.Delete(reservation.key(g.name))
+
+				// campaigners that read the queue before the reservation was made complete their attempts within the publish timeout
+				select {
+				case <-time.After(renewSettleTime):
+				case <-l.ctx.Done():
+					return nil
+				}
+			}
+		}
+	}
+
+	for _, i := range idx {
+		seq := l.seqs[i]
+
+		err := g.mgr.DeleteStreamMessage(g.stream, seq, true)
+		if err != nil {
+			g.warnf("Could not remove sequence %d from %s while renewing: %s", seq, g.name, err)
+			continue
+		}
+
+		nseq, err := g.publish(l.ctx, name, l.id)
+		if err != nil {
+			l.seqs = append(l.seqs[:i], l.seqs[i+1:]...)
+			return fmt.Errorf("could not renew sequence %d: %s", seq, err)
+		}
+
+		g.debugf("Renewed sequence %d on %s as %d", seq, g.name, nseq)
+		l.seqs[i] = nseq
+	}
+
+	return nil
+}
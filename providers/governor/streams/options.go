@@ -0,0 +1,81 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package governor
+
+import (
+	"time"
+)
+
+// Option configures the governor
+type Option func(o *options)
+
+type options struct {
+	subject  string
+	interval time.Duration
+	bo       Backoff
+	log      Logger
+	weight   int
+	priority Priority
+	noLeave  bool
+	noQueue  bool
+	waiting  func()
+	lost     func(error)
+	renew    time.Duration
+}
+
+// WithSubject configures a specific subject for the governor to act on
+func WithSubject(s string) Option {
+	return func(o *options) { o.subject = s }
+}
+
+// WithInterval sets the interval between tries
+func WithInterval(i time.Duration) Option {
+	return func(o *options) { o.interval = i }
+}
+
+// WithBackoff sets a backoff policy for gradually reducing try interval
+func WithBackoff(bo Backoff) Option {
+	return func(o *options) { o.bo = bo }
+}
+
+// WithLogger configures the logger to use, no logging when none is given
+func WithLogger(log Logger) Option {
+	return func(o *options) { o.log = log }
+}
+
+// WithWeight sets how many slots in the governor the lease consumes, defaults to 1
+func WithWeight(w int) Option {
+	return func(o *options) { o.weight = w }
+}
+
+// WithPriority sets the priority class of the lease, waiters of higher priority classes obtain leases first
+func WithPriority(p Priority) Option {
+	return func(o *options) { o.priority = p }
+}
+
+// WithoutLeavingOnCompletion prevents removal from the governor after execution
+func WithoutLeavingOnCompletion() Option {
+	return func(o *options) { o.noLeave = true }
+}
+
+// WithoutQueue does not record the waiter in the waiting queue, the lease is attempted regardless of other waiters
+func WithoutQueue() Option {
+	return func(o *options) { o.noQueue = true }
+}
+
+// WithWaitingCallback calls cb once when a lease could not be obtained on the first try and the process starts waiting
+func WithWaitingCallback(cb func()) Option {
+	return func(o *options) { o.waiting = cb }
+}
+
+// WithLeaseLostCallback calls cb when a lease obtained using Acquire is lost, not called when the lease is released
+func WithLeaseLostCallback(cb func(error)) Option {
+	return func(o *options) { o.lost = cb }
+}
+
+// WithRenewInterval sets how often leases obtained using Acquire are checked and renewed, defaults to a quarter of the governor expiry time
+func WithRenewInterval(i time.Duration) Option {
+	return func(o *options) { o.renew = i }
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package governor
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Priority is the priority class of a lease
+type Priority int
+
+const (
+	// LowPriority leases are only obtained when no other classes are waiting
+	LowPriority Priority = iota - 1
+	// NormalPriority is the default priority class
+	NormalPriority
+	// HighPriority leases are obtained before normal and low priority ones
+	HighPriority
+	// UrgentPriority leases are obtained before all others
+	UrgentPriority
+)
+
+var priorityNames = map[Priority]string{
+	LowPriority:    "low",
+	NormalPriority: "normal",
+	HighPriority:   "high",
+	UrgentPriority: "urgent",
+}
+
+// PriorityNames are the names of all known priority classes ordered from lowest to highest
+var PriorityNames = []string{"low", "normal", "high", "urgent"}
+
+// ParsePriority parses a priority class name, an empty name is NormalPriority
+func ParsePriority(p string) (Priority, error) {
+	if p == "" {
+		return NormalPriority, nil
+	}
+
+	for k, v := range priorityNames {
+		if strings.EqualFold(p, v) {
+			return k, nil
+		}
+	}
+
+	return NormalPriority, fmt.Errorf("unknown priority %q, valid priorities are %s", p, strings.Join(PriorityNames, ", "))
+}
+
+func (p Priority) String() string {
+	n, ok := priorityNames[p]
+	if !ok {
+		return fmt.Sprintf("priority(%d)", int(p))
+	}
+
+	return n
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package governor
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// QueueBucket is the Key-Value bucket where waiters for all governors are recorded
+const QueueBucket = "CHORIA_GOVERNOR_QUEUE"
+
+// Waiter is a process waiting to obtain a lease on a governor
+type Waiter struct {
+	ID       string    `json:"id"`
+	Name     string    `json:"name"`
+	Weight   int       `json:"weight"`
+	Priority Priority  `json:"priority"`
+	Since    time.Time `json:"since"`
+	Expires  time.Time `json:"expires"`
+}
+
+// Expired determines if the waiter stopped campaigning without removing itself from the queue
+func (w *Waiter) Expired(now time.Time) bool {
+	return now.After(w.Expires)
+}
+
+func (w *Waiter) key(governor string) string {
+	return fmt.Sprintf("%s.%s", governor, w.ID)
+}
+
+func (w *Waiter) save(kv nats.KeyValue, governor string) error {
+	j, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = kv.Put(w.key(governor), j)
+
+	return err
+}
+
+// WaitQueue accesses the bucket holding waiters, the bucket is provisioned by the Choria Broker
+func WaitQueue(nc *nats.Conn) (nats.KeyValue, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	return js.KeyValue(QueueBucket)
+}
+
+// Waiters retrieves all current waiters for a governor sorted by priority and wait time, expired waiters are not included
+func Waiters(kv nats.KeyValue, governor string) ([]*Waiter, error) {
+	watch, err := kv.Watch(fmt.Sprintf("%s.*", governor), nats.IgnoreDeletes())
+	if err != nil {
+		return nil, err
+	}
+	defer watch.Stop()
+
+	now := time.Now()
+	waiters := []*Waiter{}
+
+	for entry := range watch.Updates() {
+		if entry == nil {
+			break
+		}
+
+		w := &Waiter{}
+		err = json.Unmarshal(entry.Value(), w)
+		if err != nil || w.Expired(now) {
+			continue
+		}
+
+		waiters = append(waiters, w)
+	}
+
+	sortWaiters(waiters)
+
+	return waiters, nil
+}
+
+// sortWaiters orders waiters by highest priority and then by longest waiting
+func sortWaiters(waiters []*Waiter) {
+	sort.SliceStable(waiters, func(i, j int) bool {
+		return waiters[i].ahead(waiters[j])
+	})
+}
+
+// ahead determines if w is ahead of o in the queue
+func (w *Waiter) ahead(o *Waiter) bool {
+	if w.Priority != o.Priority {
+		return w.Priority > o.Priority
+	}
+
+	if !w.Since.Equal(o.Since) {
+		return w.Since.Before(o.Since)
+	}
+
+	return w.ID < o.ID
+}
+
+// blockedBy finds a waiter that should obtain a lease before w, waiters of a higher
+// priority always block while earlier waiters of the same priority block when together
+// with w they need more than the free slots in the governor
+func blockedBy(waiters []*Waiter, w *Waiter, now time.Time, free int) *Waiter {
+	needed := w.Weight
+
+	for _, o := range waiters {
+		if o.ID == w.ID || o.Expired(now) || !o.ahead(w) {
+			continue
+		}
+
+		if o.Priority > w.Priority {
+			return o
+		}
+
+		needed += o.Weight
+		if needed > free {
+			return o
+		}
+	}
+
+	return nil
+}
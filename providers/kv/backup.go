@@ -0,0 +1,498 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// BackupManifestFile is the file in a backup directory describing the backup
+const BackupManifestFile = "manifest.json"
+
+const (
+	kvOperationHeader = "KV-Operation"
+
+	// BackupPutOperation is a value being stored
+	BackupPutOperation = "PUT"
+	// BackupDeleteOperation is a key being deleted
+	BackupDeleteOperation = "DEL"
+	// BackupPurgeOperation is a key being purged
+	BackupPurgeOperation = "PURGE"
+)
+
+// ErrBackupNoChanges indicates that an incremental backup found no new data in the bucket
+var ErrBackupNoChanges = errors.New("no changes since the previous backup")
+
+// BackupManifest describes a backup made up of a full backup and any number of incremental backups
+type BackupManifest struct {
+	Bucket   string           `json:"bucket"`
+	Created  time.Time        `json:"created"`
+	Config   BackupConfig     `json:"config"`
+	Segments []*BackupSegment `json:"segments"`
+}
+
+// BackupConfig is the configuration of the bucket at the time of the first backup
+type BackupConfig struct {
+	Description   string        `json:"description,omitempty"`
+	History       uint8         `json:"history"`
+	TTL           time.Duration `json:"ttl"`
+	MaxValueSize  int32         `json:"max_value_size"`
+	MaxBucketSize int64         `json:"max_bucket_size"`
+	Replicas      int           `json:"replicas"`
+}
+
+// BackupSegment is a single full or incremental backup holding all bucket operations between two stream sequences
+type BackupSegment struct {
+	File     string    `json:"file"`
+	FirstSeq uint64    `json:"first_seq"`
+	LastSeq  uint64    `json:"last_seq"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Entries  int       `json:"entries"`
+	Created  time.Time `json:"created"`
+	SHA256   string    `json:"sha256"`
+}
+
+// BackupEntry is a single operation on a bucket
+type BackupEntry struct {
+	Seq       uint64    `json:"seq"`
+	Time      time.Time `json:"time"`
+	Key       string    `json:"key"`
+	Operation string    `json:"op"`
+	Value     []byte    `json:"value,omitempty"`
+}
+
+// RestoreOption configures a restore
+type RestoreOption func(*restoreOptions)
+
+type restoreOptions struct {
+	bucket   string
+	revision uint64
+	until    time.Time
+}
+
+// RestoreToRevision restores the bucket up to and including revision rev of the original bucket
+func RestoreToRevision(rev uint64) RestoreOption {
+	return func(o *restoreOptions) { o.revision = rev }
+}
+
+// RestoreToTime restores the bucket to the state it was in at time t
+func RestoreToTime(t time.Time) RestoreOption {
+	return func(o *restoreOptions) { o.until = t }
+}
+
+// RestoreIntoBucket restores the data into a bucket with a different name
+func RestoreIntoBucket(bucket string) RestoreOption {
+	return func(o *restoreOptions) { o.bucket = bucket }
+}
+
+// RestoreResult describes a completed restore
+type RestoreResult struct {
+	Bucket  string
+	Entries int
+	LastSeq uint64
+	End     time.Time
+}
+
+// Backup backs up a bucket into dir, when dir already holds a backup of the bucket only operations since that backup are stored
+func Backup(ctx context.Context, nc *nats.Conn, bucket string, dir string) (*BackupSegment, error) {
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := js.KeyValue(bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Choria Key-Value store %s: %s", bucket, err)
+	}
+
+	status, err := store.Status()
+	if err != nil {
+		return nil, err
+	}
+	nfo := status.(*nats.KeyValueBucketStatus).StreamInfo()
+
+	manifest, err := LoadBackup(dir)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		manifest = &BackupManifest{
+			Bucket:  bucket,
+			Created: time.Now().UTC(),
+			Config: BackupConfig{
+				Description:   nfo.Config.Description,
+				History:       uint8(nfo.Config.MaxMsgsPerSubject),
+				TTL:           nfo.Config.MaxAge,
+				MaxValueSize:  nfo.Config.MaxMsgSize,
+				MaxBucketSize: nfo.Config.MaxBytes,
+				Replicas:      nfo.Config.Replicas,
+			},
+		}
+
+		err = os.MkdirAll(dir, 0700)
+		if err != nil {
+			return nil, err
+		}
+
+	case err != nil:
+		return nil, err
+
+	case manifest.Bucket != bucket:
+		return nil, fmt.Errorf("%s holds a backup of bucket %s", dir, manifest.Bucket)
+	}
+
+	start := manifest.lastSeq() + 1
+	if nfo.State.LastSeq < manifest.lastSeq() {
+		return nil, fmt.Errorf("bucket %s is at revision %d while the previous backup ended at %d, it might have been recreated", bucket, nfo.State.LastSeq, manifest.lastSeq())
+	}
+	if nfo.State.Msgs == 0 || nfo.State.LastSeq < start {
+		return nil, ErrBackupNoChanges
+	}
+
+	segment := &BackupSegment{
+		File:     fmt.Sprintf("%06d.jsonl", len(manifest.Segments)+1),
+		FirstSeq: start,
+		LastSeq:  nfo.State.LastSeq,
+		Created:  time.Now().UTC(),
+	}
+
+	data, err := readBucket(ctx, js, bucket, segment)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	segment.SHA256 = hex.EncodeToString(sum[:])
+
+	err = os.WriteFile(filepath.Join(dir, segment.File), data, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest.Segments = append(manifest.Segments, segment)
+
+	err = manifest.save(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return segment, nil
+}
+
+// readBucket reads all operations between the first and last sequences of segment, updating the segment with details of the data read
+func readBucket(ctx context.Context, js nats.JetStreamContext, bucket string, segment *BackupSegment) ([]byte, error) {
+	prefix := fmt.Sprintf("$KV.%s.", bucket)
+
+	sub, err := js.SubscribeSync(prefix+">", nats.OrderedConsumer(), nats.StartSequence(segment.FirstSeq))
+	if err != nil {
+		return nil, err
+	}
+	defer sub.Unsubscribe()
+
+	buf := bytes.Buffer{}
+	enc := json.NewEncoder(&buf)
+
+	for {
+		msg, err := sub.NextMsgWithContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		meta, err := msg.Metadata()
+		if err != nil {
+			return nil, err
+		}
+
+		if meta.Sequence.Stream > segment.LastSeq {
+			break
+		}
+
+		entry := &BackupEntry{
+			Seq:       meta.Sequence.Stream,
+			Time:      meta.Timestamp.UTC(),
+			Key:       strings.TrimPrefix(msg.Subject, prefix),
+			Operation: BackupPutOperation,
+			Value:     msg.Data,
+		}
+
+		switch msg.Header.Get(kvOperationHeader) {
+		case BackupDeleteOperation:
+			entry.Operation = BackupDeleteOperation
+		case BackupPurgeOperation:
+			entry.Operation = BackupPurgeOperation
+		}
+
+		err = enc.Encode(entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if segment.Entries == 0 {
+			segment.Start = entry.Time
+		}
+		segment.End = entry.Time
+		segment.Entries++
+
+		if meta.Sequence.Stream == segment.LastSeq || meta.NumPending == 0 {
+			break
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// LoadBackup loads the manifest of a backup without verifying the data
+func LoadBackup(dir string) (*BackupManifest, error) {
+	mj, err := os.ReadFile(filepath.Join(dir, BackupManifestFile))
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &BackupManifest{}
+	err = json.Unmarshal(mj, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %s", err)
+	}
+
+	if manifest.Bucket == "" {
+		return nil, fmt.Errorf("invalid backup manifest: bucket name is not set")
+	}
+
+	return manifest, nil
+}
+
+// VerifyBackup verifies the integrity of all the data in a backup without requiring access to Choria Streams
+func VerifyBackup(dir string) (*BackupManifest, error) {
+	manifest, err := LoadBackup(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var last uint64
+	for _, segment := range manifest.Segments {
+		if segment.FirstSeq <= last {
+			return nil, fmt.Errorf("segment %s starts at sequence %d which overlaps the previous segment", segment.File, segment.FirstSeq)
+		}
+
+		err = segment.eachEntry(dir, func(e *BackupEntry) error { return nil })
+		if err != nil {
+			return nil, err
+		}
+
+		last = segment.LastSeq
+	}
+
+	return manifest, nil
+}
+
+// RestoreBackup verifies and restores a backup into a new bucket
+func RestoreBackup(ctx context.Context, nc *nats.Conn, dir string, opts ...RestoreOption) (*RestoreResult, error) {
+	manifest, err := VerifyBackup(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &restoreOptions{bucket: manifest.Bucket}
+	for _, o := range opts {
+		o(opt)
+	}
+
+	err = manifest.checkRestorePoint(dir, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = NewKV(nc, opt.bucket, false)
+	switch {
+	case err == nil:
+		return nil, fmt.Errorf("bucket %s already exists", opt.bucket)
+	case !errors.Is(err, nats.ErrBucketNotFound):
+		return nil, err
+	}
+
+	cfg := manifest.Config
+	store, err := NewKV(nc, opt.bucket, true, WithDescription(cfg.Description), WithHistory(cfg.History), WithTTL(cfg.TTL), WithMaxValueSize(cfg.MaxValueSize), WithMaxBucketSize(cfg.MaxBucketSize), WithReplicas(cfg.Replicas))
+	if err != nil {
+		return nil, err
+	}
+
+	res := &RestoreResult{Bucket: opt.bucket}
+	errDone := errors.New("done")
+
+	for _, segment := range manifest.Segments {
+		err = segment.eachEntry(dir, func(e *BackupEntry) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			if opt.revision > 0 && e.Seq > opt.revision {
+				return errDone
+			}
+
+			if !opt.until.IsZero() && e.Time.After(opt.until) {
+				return errDone
+			}
+
+			var err error
+			switch e.Operation {
+			case BackupDeleteOperation:
+				err = store.Delete(e.Key)
+			case BackupPurgeOperation:
+				err = store.Purge(e.Key)
+			default:
+				_, err = store.Put(e.Key, e.Value)
+			}
+			if err != nil {
+				return fmt.Errorf("could not restore revision %d: %s", e.Seq, err)
+			}
+
+			res.Entries++
+			res.LastSeq = e.Seq
+			res.End = e.Time
+
+			return nil
+		})
+		if errors.Is(err, errDone) {
+			break
+		}
+		if err != nil {
+			return res, err
+		}
+	}
+
+	return res, nil
+}
+
+// checkRestorePoint ensures the backup holds the full history needed to restore to the requested revision or time.
+//
+// The first segment holds only the history the bucket retained when it was made and incremental segments miss any
+// operations the bucket discarded between backups, restoring to a point before the end of the first segment or
+// inside such a gap would produce a bucket that never existed
+func (m *BackupManifest) checkRestorePoint(dir string, opt *restoreOptions) error {
+	if len(m.Segments) == 0 || (opt.revision == 0 && opt.until.IsZero()) {
+		return nil
+	}
+
+	first := m.Segments[0]
+	if opt.revision > 0 && opt.revision < first.LastSeq {
+		return fmt.Errorf("revision %d is before the end of the first backup at revision %d, the backup does not hold the full history before it", opt.revision, first.LastSeq)
+	}
+	if !opt.until.IsZero() && opt.until.Before(first.End) {
+		return fmt.Errorf("%s is before the end of the first backup at %s, the backup does not hold the full history before it", opt.until.Format(time.RFC3339), first.End.Format(time.RFC3339))
+	}
+
+	prevSeq := first.LastSeq
+	prevTime := first.End
+
+	for _, segment := range m.Segments[1:] {
+		err := segment.eachEntry(dir, func(e *BackupEntry) error {
+			if e.Seq > prevSeq+1 {
+				if opt.revision > prevSeq && opt.revision < e.Seq {
+					return fmt.Errorf("revision %d is in a gap in the backup history between revisions %d and %d", opt.revision, prevSeq, e.Seq)
+				}
+				if !opt.until.IsZero() && !opt.until.Before(prevTime) && opt.until.Before(e.Time) {
+					return fmt.Errorf("%s is in a gap in the backup history between revisions %d and %d", opt.until.Format(time.RFC3339), prevSeq, e.Seq)
+				}
+			}
+
+			prevSeq = e.Seq
+			prevTime = e.Time
+
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *BackupManifest) lastSeq() uint64 {
+	if len(m.Segments) == 0 {
+		return 0
+	}
+
+	return m.Segments[len(m.Segments)-1].LastSeq
+}
+
+func (m *BackupManifest) save(dir string) error {
+	j, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(dir, "manifest")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = tf.Write(j)
+	tf.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tf.Name(), filepath.Join(dir, BackupManifestFile))
+}
+
+// eachEntry verifies the segment checksum and calls cb for every entry in sequence order
+func (s *BackupSegment) eachEntry(dir string, cb func(*BackupEntry) error) error {
+	data, err := os.ReadFile(filepath.Join(dir, s.File))
+	if err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != s.SHA256 {
+		return fmt.Errorf("checksum mismatch for segment %s", s.File)
+	}
+
+	var last uint64
+	var count int
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		entry := &BackupEntry{}
+		err = json.Unmarshal(scanner.Bytes(), entry)
+		if err != nil {
+			return fmt.Errorf("invalid entry in segment %s: %s", s.File, err)
+		}
+
+		if entry.Seq <= last || entry.Seq < s.FirstSeq || entry.Seq > s.LastSeq {
+			return fmt.Errorf("entry with sequence %d in segment %s is out of order", entry.Seq, s.File)
+		}
+		last = entry.Seq
+		count++
+
+		err = cb(entry)
+		if err != nil {
+			return err
+		}
+	}
+	if scanner.Err() != nil {
+		return scanner.Err()
+	}
+
+	if count != s.Entries {
+		return fmt.Errorf("segment %s holds %d entries, expected %d", s.File, count, s.Entries)
+	}
+
+	return nil
+}
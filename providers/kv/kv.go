@@ -1,4 +1,4 @@
-// Copyright (c) 2021, R.I. Pienaar and the Choria Project contributors
+// Copyright (c) 2021-2022, R.I. Pienaar and the Choria Project contributors
 //
 // SPDX-License-Identifier: Apache-2.0
 
@@ -22,6 +22,12 @@ type options struct {
 	ttl           time.Duration
 	maxBucketSize int64
 	replicas      int
+	mirror        *Source
+	sources       []*Source
+}
+
+func WithDescription(d string) Option {
+	return func(o *options) { o.description = d }
 }
 
 func WithTTL(ttl time.Duration) Option {
@@ -81,7 +87,11 @@ func NewKV(nc *nats.Conn, name string, create bool, opts ...Option) (nats.KeyVal
 	}
 
 	if !create {
-		return nil, fmt.Errorf("failed to load Choria Key-Value store %s: %s", name, err)
+		return nil, fmt.Errorf("failed to load Choria Key-Value store %s: %w", name, err)
+	}
+
+	if opt.mirror != nil || len(opt.sources) > 0 {
+		return newReplicatedKV(nc, js, opt)
 	}
 
 	return js.CreateKeyValue(&nats.KeyValueConfig{
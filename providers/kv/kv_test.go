@@ -0,0 +1,314 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestKV(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Providers/KV")
+}
+
+var _ = Describe("Providers/KV", func() {
+	var (
+		srv *server.Server
+		nc  *nats.Conn
+	)
+
+	BeforeEach(func() {
+		srv, nc = startJSServer(GinkgoT())
+	})
+
+	AfterEach(func() {
+		nc.Close()
+		srv.Shutdown()
+		srv.WaitForShutdown()
+		if srv.StoreDir() != "" {
+			os.RemoveAll(srv.StoreDir())
+		}
+	})
+
+	Describe("NewKV", func() {
+		It("Should create buckets", func() {
+			_, err := NewKV(nc, "TEST", false)
+			Expect(err).To(HaveOccurred())
+
+			kv, err := NewKV(nc, "TEST", true, WithHistory(5))
+			Expect(err).ToNot(HaveOccurred())
+
+			status, err := kv.Status()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(status.History()).To(Equal(int64(5)))
+		})
+
+		It("Should validate replicated buckets", func() {
+			_, err := NewKV(nc, "TEST", true, WithMirror(&Source{Bucket: "TEST", Domain: "hub"}), WithSource(&Source{Bucket: "TEST", Domain: "hub"}))
+			Expect(err).To(MatchError("buckets can not have both a mirror and sources"))
+
+			_, err = NewKV(nc, "TEST", true, WithMirror(&Source{Bucket: "OTHER", Domain: "hub"}))
+			Expect(err).To(MatchError("can not replicate bucket OTHER@hub into TEST, replicated buckets must have the same name"))
+
+			_, err = NewKV(nc, "TEST", true, WithSource(&Source{Bucket: "TEST"}))
+			Expect(err).To(MatchError("can not replicate bucket TEST into itself, a domain is required"))
+
+			_, err = NewKV(nc, "TEST", true, WithSource(&Source{Bucket: "TEST", Domain: "hub"}))
+			Expect(err).To(MatchError(ContainSubstring("cannot access JetStream domain hub")))
+		})
+	})
+
+	Describe("Schemas", func() {
+		schema := []byte(`{"type":"object","required":["name"],"properties":{"name":{"type":"string"}}}`)
+
+		It("Should match keys", func() {
+			Expect(keyMatch("users.*", "users.bob")).To(BeTrue())
+			Expect(keyMatch("users.*", "users.bob.age")).To(BeFalse())
+			Expect(keyMatch("users.*", "users")).To(BeFalse())
+			Expect(keyMatch("users.>", "users.bob.age")).To(BeTrue())
+			Expect(keyMatch("users.>", "users")).To(BeFalse())
+			Expect(keyMatch("users.bob", "users.bob")).To(BeTrue())
+			Expect(keyMatch("users.bob", "users.jill")).To(BeFalse())
+		})
+
+		It("Should validate schemas being set", func() {
+			schemas := &Schemas{}
+			Expect(schemas.Set("", "", schema)).To(MatchError("invalid value: schema key pattern is required"))
+			Expect(schemas.Set("users.>.age", "", schema)).To(MatchError("invalid value: invalid key pattern users.>.age, > must be the last token"))
+			Expect(schemas.Set("users..age", "", schema)).To(MatchError("invalid value: invalid key pattern users..age"))
+			Expect(schemas.Set("users.*", "", []byte(`{"type":1}`))).To(MatchError(ContainSubstring("invalid value: invalid schema for users.*")))
+
+			Expect(schemas.Set("users.*", "", []byte(`{}`))).To(Succeed())
+			Expect(schemas.Set("users.*", "Users", schema)).To(Succeed())
+			Expect(schemas.Schemas).To(HaveLen(1))
+			Expect(schemas.Schemas[0].Description).To(Equal("Users"))
+			Expect(schemas.Remove("users.*")).To(BeTrue())
+			Expect(schemas.Remove("users.*")).To(BeFalse())
+		})
+
+		It("Should validate values", func() {
+			kv, err := NewKV(nc, "TEST", true)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(ValidateValue(kv, "users.bob", []byte("x"))).To(Succeed())
+
+			schemas, rev, err := LoadSchemas(kv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rev).To(Equal(uint64(0)))
+			Expect(schemas.Set("users.*", "", schema)).To(Succeed())
+			Expect(StoreSchemas(kv, schemas, rev)).To(Succeed())
+
+			err = ValidateValue(kv, "users.bob", []byte("x"))
+			Expect(err).To(MatchError("invalid value: value for key users.bob is not valid JSON as required by the schema for users.*"))
+			Expect(errors.Is(err, ErrInvalidValue)).To(BeTrue())
+
+			err = ValidateValue(kv, "users.bob", []byte(`{"name":1}`))
+			Expect(err).To(MatchError(ContainSubstring("value for key users.bob does not match the schema for users.*: name: Invalid type")))
+
+			Expect(ValidateValue(kv, "users.bob", []byte(`{"name":"bob"}`))).To(Succeed())
+			Expect(ValidateValue(kv, "groups.admin", []byte("x"))).To(Succeed())
+
+			Expect(ValidateValue(kv, SchemaKey, []byte(`{"schemas":[{"keys":"x..y","schema":{}}]}`))).To(MatchError("invalid value: invalid key pattern x..y"))
+
+			schemas, rev, err = LoadSchemas(kv)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rev).To(BeNumerically(">", 0))
+			Expect(schemas.Schemas).To(HaveLen(1))
+			Expect(StoreSchemas(kv, schemas, rev-1)).To(HaveOccurred())
+		})
+	})
+
+	Describe("Backups", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "kvbackup")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("Should support incremental backups and point in time restores", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			kv, err := NewKV(nc, "TEST", true, WithHistory(5))
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = Backup(ctx, nc, "TEST", dir)
+			Expect(err).To(MatchError(ErrBackupNoChanges))
+
+			_, err = kv.PutString("one", "1")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString("two", "2")
+			Expect(err).ToNot(HaveOccurred())
+
+			segment, err := Backup(ctx, nc, "TEST", dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(segment.FirstSeq).To(Equal(uint64(1)))
+			Expect(segment.LastSeq).To(Equal(uint64(2)))
+			Expect(segment.Entries).To(Equal(2))
+
+			_, err = Backup(ctx, nc, "TEST", dir)
+			Expect(err).To(MatchError(ErrBackupNoChanges))
+
+			_, err = kv.PutString("one", "1.1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(kv.Delete("two")).To(Succeed())
+
+			segment, err = Backup(ctx, nc, "TEST", dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(segment.FirstSeq).To(Equal(uint64(3)))
+			Expect(segment.LastSeq).To(Equal(uint64(4)))
+			Expect(segment.Entries).To(Equal(2))
+
+			manifest, err := VerifyBackup(dir)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest.Bucket).To(Equal("TEST"))
+			Expect(manifest.Config.History).To(Equal(uint8(5)))
+			Expect(manifest.Segments).To(HaveLen(2))
+
+			_, err = RestoreBackup(ctx, nc, dir)
+			Expect(err).To(MatchError("bucket TEST already exists"))
+
+			res, err := RestoreBackup(ctx, nc, dir, RestoreIntoBucket("PIT"), RestoreToRevision(3))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Entries).To(Equal(3))
+			Expect(res.LastSeq).To(Equal(uint64(3)))
+
+			pit, err := NewKV(nc, "PIT", false)
+			Expect(err).ToNot(HaveOccurred())
+			entry, err := pit.Get("one")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.Value()).To(Equal([]byte("1.1")))
+			entry, err = pit.Get("two")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(entry.Value()).To(Equal([]byte("2")))
+
+			res, err = RestoreBackup(ctx, nc, dir, RestoreIntoBucket("LATEST"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.Entries).To(Equal(4))
+
+			latest, err := NewKV(nc, "LATEST", false)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = latest.Get("two")
+			Expect(err).To(MatchError(nats.ErrKeyNotFound))
+		})
+
+		It("Should refuse to restore to points the backup does not hold the full history for", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			kv, err := NewKV(nc, "TEST", true)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString("one", "1")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString("two", "2")
+			Expect(err).ToNot(HaveOccurred())
+
+			first, err := Backup(ctx, nc, "TEST", dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			// with a history of 1 revision 3 is discarded by the bucket before the next backup
+			_, err = kv.PutString("one", "1.1")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString("one", "1.2")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = Backup(ctx, nc, "TEST", dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = RestoreBackup(ctx, nc, dir, RestoreIntoBucket("PIT"), RestoreToRevision(1))
+			Expect(err).To(MatchError("revision 1 is before the end of the first backup at revision 2, the backup does not hold the full history before it"))
+
+			_, err = RestoreBackup(ctx, nc, dir, RestoreIntoBucket("PIT"), RestoreToTime(first.End.Add(-time.Second)))
+			Expect(err).To(MatchError(ContainSubstring("is before the end of the first backup")))
+
+			_, err = RestoreBackup(ctx, nc, dir, RestoreIntoBucket("PIT"), RestoreToRevision(3))
+			Expect(err).To(MatchError("revision 3 is in a gap in the backup history between revisions 2 and 4"))
+
+			_, err = NewKV(nc, "PIT", false)
+			Expect(err).To(MatchError(nats.ErrBucketNotFound))
+
+			res, err := RestoreBackup(ctx, nc, dir, RestoreIntoBucket("PIT"), RestoreToRevision(4))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(res.LastSeq).To(Equal(uint64(4)))
+		})
+
+		It("Should detect corrupt backups", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+
+			kv, err := NewKV(nc, "TEST", true)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = kv.PutString("one", "1")
+			Expect(err).ToNot(HaveOccurred())
+
+			segment, err := Backup(ctx, nc, "TEST", dir)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = NewKV(nc, "OTHER", true)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = Backup(ctx, nc, "OTHER", dir)
+			Expect(err).To(MatchError(fmt.Sprintf("%s holds a backup of bucket TEST", dir)))
+
+			f, err := os.OpenFile(filepath.Join(dir, segment.File), os.O_APPEND|os.O_WRONLY, 0600)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = f.WriteString("{}\n")
+			Expect(err).ToNot(HaveOccurred())
+			f.Close()
+
+			_, err = VerifyBackup(dir)
+			Expect(err).To(MatchError("checksum mismatch for segment 000001.jsonl"))
+		})
+	})
+})
+
+func startJSServer(t GinkgoTInterface) (*server.Server, *nats.Conn) {
+	t.Helper()
+
+	d, err := os.MkdirTemp("", "jstest")
+	if err != nil {
+		t.Fatalf("temp dir could not be made: %s", err)
+	}
+
+	opts := &server.Options{
+		JetStream: true,
+		StoreDir:  d,
+		Port:      -1,
+		Host:      "localhost",
+	}
+
+	s, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal("server start failed: ", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Error("nats server did not start")
+	}
+
+	nc, err := nats.Connect(s.ClientURL(), nats.UseOldRequestStyle())
+	if err != nil {
+		t.Fatalf("client start failed: %s", err)
+	}
+
+	return s, nc
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Source is a bucket that is mirrored or sourced into another bucket.
+//
+// Keys are stored using the subjects of the source bucket so the source has to be a bucket of the
+// same name in another JetStream domain, typically one reached over a leafnode connection
+type Source struct {
+	// Bucket is the name of the bucket being replicated
+	Bucket string
+	// Domain is the JetStream domain hosting the bucket, empty for the local domain
+	Domain string
+}
+
+func (s *Source) String() string {
+	if s.Domain == "" {
+		return s.Bucket
+	}
+
+	return fmt.Sprintf("%s@%s", s.Bucket, s.Domain)
+}
+
+// StreamName is the name of the stream holding the bucket
+func (s *Source) StreamName() string {
+	return streamName(s.Bucket)
+}
+
+func (s *Source) streamSource() *nats.StreamSource {
+	src := &nats.StreamSource{Name: s.StreamName()}
+	if s.Domain != "" {
+		src.External = &nats.ExternalStream{APIPrefix: fmt.Sprintf("$JS.%s.API", s.Domain)}
+	}
+
+	return src
+}
+
+// validate ensures the domain of the source exists and holds the bucket
+func (s *Source) validate(nc *nats.Conn) error {
+	js, err := nc.JetStream(nats.Domain(s.Domain))
+	if err != nil {
+		return err
+	}
+
+	_, err = js.AccountInfo()
+	if err != nil {
+		return fmt.Errorf("cannot access JetStream domain %s: %s", s.Domain, err)
+	}
+
+	_, err = js.StreamInfo(s.StreamName())
+	if err != nil {
+		return fmt.Errorf("cannot find bucket %s: %s", s, err)
+	}
+
+	return nil
+}
+
+// WithMirror creates the bucket as a read only mirror of another bucket
+func WithMirror(m *Source) Option {
+	return func(o *options) { o.mirror = m }
+}
+
+// WithSource adds a bucket that will be sourced into the bucket, can be given multiple times
+func WithSource(s *Source) Option {
+	return func(o *options) { o.sources = append(o.sources, s) }
+}
+
+func streamName(bucket string) string {
+	return fmt.Sprintf("KV_%s", bucket)
+}
+
+// newReplicatedKV creates a bucket that mirrors or sources other buckets, the config matches that of nats.CreateKeyValue()
+func newReplicatedKV(nc *nats.Conn, js nats.JetStreamContext, opt *options) (nats.KeyValue, error) {
+	if opt.mirror != nil && len(opt.sources) > 0 {
+		return nil, fmt.Errorf("buckets can not have both a mirror and sources")
+	}
+
+	sources := opt.sources
+	if opt.mirror != nil {
+		sources = []*Source{opt.mirror}
+	}
+
+	for _, s := range sources {
+		if s.Bucket != opt.name {
+			return nil, fmt.Errorf("can not replicate bucket %s into %s, replicated buckets must have the same name", s, opt.name)
+		}
+
+		if s.Domain == "" {
+			return nil, fmt.Errorf("can not replicate bucket %s into itself, a domain is required", s)
+		}
+
+		err := s.validate(nc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	history := int64(1)
+	if opt.history > 0 {
+		if opt.history > nats.KeyValueMaxHistory {
+			return nil, nats.ErrHistoryToLarge
+		}
+		history = int64(opt.history)
+	}
+
+	replicas := opt.replicas
+	if replicas == 0 {
+		replicas = 1
+	}
+
+	maxBytes := opt.maxBucketSize
+	if maxBytes == 0 {
+		maxBytes = -1
+	}
+
+	maxMsgSize := opt.maxValSize
+	if maxMsgSize == 0 {
+		maxMsgSize = -1
+	}
+
+	duplicateWindow := 2 * time.Minute
+	if opt.ttl > 0 && opt.ttl < duplicateWindow {
+		duplicateWindow = opt.ttl
+	}
+
+	cfg := &nats.StreamConfig{
+		Name:              streamName(opt.name),
+		Description:       opt.description,
+		MaxMsgsPerSubject: history,
+		MaxBytes:          maxBytes,
+		MaxAge:            opt.ttl,
+		MaxMsgSize:        maxMsgSize,
+		Storage:           nats.FileStorage,
+		Replicas:          replicas,
+		AllowRollup:       true,
+		DenyDelete:        true,
+		Duplicates:        duplicateWindow,
+		MaxMsgs:           -1,
+		MaxConsumers:      -1,
+		AllowDirect:       true,
+		Discard:           nats.DiscardNew,
+	}
+
+	if opt.mirror != nil {
+		cfg.Mirror = opt.mirror.streamSource()
+		cfg.MirrorDirect = true
+	} else {
+		cfg.Subjects = []string{fmt.Sprintf("$KV.%s.>", opt.name)}
+		for _, s := range opt.sources {
+			cfg.Sources = append(cfg.Sources, s.streamSource())
+		}
+	}
+
+	_, err := js.AddStream(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return js.KeyValue(opt.name)
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kv
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// SchemaKey is the sidecar key in a bucket that holds the schemas values are validated against
+const SchemaKey = "_choria.schemas"
+
+// ErrInvalidValue indicates a value failed schema validation
+var ErrInvalidValue = errors.New("invalid value")
+
+// KeySchema is a JSON schema applied to all keys matching Keys
+type KeySchema struct {
+	// Keys is a key pattern where * matches a single token and > matches all remaining tokens
+	Keys string `json:"keys"`
+	// Description is an optional description of the data being stored
+	Description string `json:"description,omitempty"`
+	// Schema is the JSON schema values have to match
+	Schema json.RawMessage `json:"schema"`
+}
+
+// Schemas are the schemas stored in a bucket, the first schema matching a key is used
+type Schemas struct {
+	Schemas []*KeySchema `json:"schemas"`
+}
+
+// LoadSchemas loads the schemas stored in a bucket, a bucket without schemas has an empty set
+func LoadSchemas(kv nats.KeyValue) (*Schemas, uint64, error) {
+	entry, err := kv.Get(SchemaKey)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return &Schemas{}, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	schemas, err := parseSchemas(entry.Value())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return schemas, entry.Revision(), nil
+}
+
+// StoreSchemas saves schemas in the bucket, revision is the revision they were loaded at and 0 for new schemas
+func StoreSchemas(kv nats.KeyValue, schemas *Schemas, revision uint64) error {
+	j, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if revision == 0 {
+		_, err = kv.Create(SchemaKey, j)
+	} else {
+		_, err = kv.Update(SchemaKey, j, revision)
+	}
+
+	return err
+}
+
+// ValidateValue validates value against the schemas stored in the bucket
+func ValidateValue(kv nats.KeyValue, key string, value []byte) error {
+	schemas, _, err := LoadSchemas(kv)
+	if err != nil {
+		return fmt.Errorf("could not load schemas: %s", err)
+	}
+
+	return schemas.Validate(key, value)
+}
+
+func parseSchemas(data []byte) (*Schemas, error) {
+	schemas := &Schemas{}
+	err := json.Unmarshal(data, schemas)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schemas are not valid JSON: %s", ErrInvalidValue, err)
+	}
+
+	for _, s := range schemas.Schemas {
+		err = s.check()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return schemas, nil
+}
+
+// Set adds or replaces the schema for a key pattern
+func (s *Schemas) Set(keys string, description string, schema []byte) error {
+	ks := &KeySchema{Keys: keys, Description: description, Schema: schema}
+	err := ks.check()
+	if err != nil {
+		return err
+	}
+
+	for i, e := range s.Schemas {
+		if e.Keys == keys {
+			s.Schemas[i] = ks
+			return nil
+		}
+	}
+
+	s.Schemas = append(s.Schemas, ks)
+
+	return nil
+}
+
+// Remove removes the schema for a key pattern, false when no schema was found
+func (s *Schemas) Remove(keys string) bool {
+	for i, e := range s.Schemas {
+		if e.Keys == keys {
+			s.Schemas = append(s.Schemas[:i], s.Schemas[i+1:]...)
+			return true
+		}
+	}
+
+	return false
+}
+
+// Find finds the first schema matching key, nil when none match
+func (s *Schemas) Find(key string) *KeySchema {
+	for _, e := range s.Schemas {
+		if keyMatch(e.Keys, key) {
+			return e
+		}
+	}
+
+	return nil
+}
+
+// Validate validates value for key against the matching schema, keys without schemas are always valid
+func (s *Schemas) Validate(key string, value []byte) error {
+	if key == SchemaKey {
+		_, err := parseSchemas(value)
+		return err
+	}
+
+	schema := s.Find(key)
+	if schema == nil {
+		return nil
+	}
+
+	if !json.Valid(value) {
+		return fmt.Errorf("%w: value for key %s is not valid JSON as required by the schema for %s", ErrInvalidValue, key, schema.Keys)
+	}
+
+	result, err := gojsonschema.Validate(gojsonschema.NewBytesLoader(schema.Schema), gojsonschema.NewBytesLoader(value))
+	if err != nil {
+		return fmt.Errorf("could not perform schema validation: %s", err)
+	}
+
+	if result.Valid() {
+		return nil
+	}
+
+	var errs []string
+	for _, desc := range result.Errors() {
+		errs = append(errs, desc.String())
+	}
+
+	return fmt.Errorf("%w: value for key %s does not match the schema for %s: %s", ErrInvalidValue, key, schema.Keys, strings.Join(errs, ", "))
+}
+
+func (k *KeySchema) check() error {
+	if k.Keys == "" {
+		return fmt.Errorf("%w: schema key pattern is required", ErrInvalidValue)
+	}
+
+	tokens := strings.Split(k.Keys, ".")
+	for i, t := range tokens {
+		if t == "" {
+			return fmt.Errorf("%w: invalid key pattern %s", ErrInvalidValue, k.Keys)
+		}
+		if t == ">" && i != len(tokens)-1 {
+			return fmt.Errorf("%w: invalid key pattern %s, > must be the last token", ErrInvalidValue, k.Keys)
+		}
+	}
+
+	_, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(k.Schema))
+	if err != nil {
+		return fmt.Errorf("%w: invalid schema for %s: %s", ErrInvalidValue, k.Keys, err)
+	}
+
+	return nil
+}
+
+// keyMatch matches key against pattern using subject style wildcards
+func keyMatch(pattern string, key string) bool {
+	ptokens := strings.Split(pattern, ".")
+	ktokens := strings.Split(key, ".")
+
+	for i, p := range ptokens {
+		if p == ">" {
+			return len(ktokens) > i
+		}
+
+		if i >= len(ktokens) {
+			return false
+		}
+
+		if p != "*" && p != ktokens[i] {
+			return false
+		}
+	}
+
+	return len(ptokens) == len(ktokens)
+}
@@ -0,0 +1,281 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/backoff"
+	"github.com/choria-io/go-choria/providers/kv"
+)
+
+// BucketName is the Choria Streams Key-Value bucket holding revocations
+const BucketName = "CHORIA_REVOCATIONS"
+
+// ImportAPIPrefix is the JetStream API prefix the broker imports the revocations bucket API on for
+// organizations hosted in their own accounts
+const ImportAPIPrefix = "choria.streams"
+
+// StreamName is the stream backing the revocations bucket
+const StreamName = "KV_" + BucketName
+
+// Bucket loads the revocations bucket, creating it when create is true
+func Bucket(nc *nats.Conn, create bool, replicas int) (nats.KeyValue, error) {
+	if replicas < 1 {
+		replicas = 1
+	}
+
+	return kv.NewKV(nc, BucketName, create, kv.WithHistory(1), kv.WithReplicas(replicas), kv.WithDescription("Choria JWT Revocation List"))
+}
+
+// Store saves a revocation in the bucket
+func Store(bucket nats.KeyValue, r *Revocation) error {
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+
+	j, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	_, err = bucket.Put(r.Key(), j)
+
+	return err
+}
+
+// Remove removes a revocation from the bucket
+func Remove(bucket nats.KeyValue, kind Kind, value string) error {
+	k := key(kind, value)
+
+	_, err := bucket.Get(k)
+	if errors.Is(err, nats.ErrKeyNotFound) {
+		return fmt.Errorf("%s %s is not revoked", kind, value)
+	}
+	if err != nil {
+		return err
+	}
+
+	return bucket.Delete(k)
+}
+
+// LoadBucket loads all revocations stored in the bucket
+func LoadBucket(bucket nats.KeyValue) ([]*Revocation, error) {
+	keys, err := bucket.Keys()
+	if errors.Is(err, nats.ErrNoKeysFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []*Revocation
+	for _, k := range keys {
+		entry, err := bucket.Get(k)
+		if errors.Is(err, nats.ErrKeyNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r, err := parseEntry(entry.Value())
+		if err != nil {
+			return nil, fmt.Errorf("invalid revocation %s: %s", k, err)
+		}
+
+		revs = append(revs, r)
+	}
+
+	sortRevocations(revs)
+
+	return revs, nil
+}
+
+// WatchBucket keeps the list updated from the revocations bucket until ctx is canceled, waiting for
+// the bucket to be created if it does not exist yet, nc has to be connected to the choria account
+func (l *List) WatchBucket(ctx context.Context, wg *sync.WaitGroup, nc *nats.Conn, log *logrus.Entry) {
+	defer wg.Done()
+
+	l.watch(ctx, nc, false, log)
+}
+
+// WatchImportedBucket keeps the list updated from the revocations bucket the broker imports into organization
+// accounts on ImportAPIPrefix until ctx is canceled. Connections in organization accounts must only use this,
+// a bucket in their own account is not the one the broker enforces
+func (l *List) WatchImportedBucket(ctx context.Context, wg *sync.WaitGroup, nc *nats.Conn, log *logrus.Entry) {
+	defer wg.Done()
+
+	l.watch(ctx, nc, true, log)
+}
+
+func (l *List) watch(ctx context.Context, nc *nats.Conn, imported bool, log *logrus.Entry) {
+	for {
+		var bucket nats.KeyValue
+		var js nats.JetStreamContext
+
+		err := backoff.TwentySec.For(ctx, func(try int) error {
+			var err error
+
+			if imported {
+				js, err = nc.JetStream(nats.APIPrefix(ImportAPIPrefix))
+				if err == nil {
+					_, err = js.StreamInfo(StreamName)
+				}
+			} else {
+				js, err = nc.JetStream()
+				if err == nil {
+					bucket, err = js.KeyValue(BucketName)
+				}
+			}
+			if err != nil {
+				if try%10 == 1 {
+					log.Warnf("Could not load revocation bucket %s, will retry: %s", BucketName, err)
+				}
+				return err
+			}
+
+			return nil
+		})
+		if err != nil {
+			return
+		}
+
+		if imported {
+			err = l.watchImported(ctx, js, log)
+		} else {
+			err = l.watchBucket(ctx, bucket, log)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		log.Warnf("Revocation bucket watch failed, restarting: %v", err)
+		err = backoff.Default.TrySleep(ctx, 5)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// watchImported reads the bucket using a pull consumer, imported services can respond to requests
+// but push consumers can not deliver across accounts. The broker imports acknowledgements unchanged
+func (l *List) watchImported(ctx context.Context, js nats.JetStreamContext, log *logrus.Entry) error {
+	prefix := fmt.Sprintf("$KV.%s.", BucketName)
+
+	sub, err := js.PullSubscribe(prefix+">", "", nats.BindStream(StreamName), nats.DeliverLastPerSubject(), nats.AckExplicit(), nats.InactiveThreshold(time.Minute))
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	loaded := false
+	for {
+		fctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		msgs, err := sub.Fetch(256, nats.Context(fctx))
+		cancel()
+
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+
+		case errors.Is(err, context.DeadlineExceeded), errors.Is(err, nats.ErrTimeout):
+			if !loaded {
+				log.Infof("Loaded imported revocation bucket %s, %d values are revoked", BucketName, l.Count())
+				loaded = true
+			}
+			continue
+
+		case err != nil:
+			return err
+		}
+
+		for _, msg := range msgs {
+			err = msg.Ack()
+			if err != nil {
+				return err
+			}
+
+			key := strings.TrimPrefix(msg.Subject, prefix)
+
+			switch msg.Header.Get("KV-Operation") {
+			case "DEL", "PURGE":
+				l.removeBucket(key)
+				continue
+			}
+
+			r, err := parseEntry(msg.Data)
+			if err != nil {
+				log.Errorf("Invalid revocation %s: %s", key, err)
+				continue
+			}
+
+			l.addBucket(r)
+		}
+	}
+}
+
+func (l *List) watchBucket(ctx context.Context, bucket nats.KeyValue, log *logrus.Entry) error {
+	watch, err := bucket.WatchAll(nats.Context(ctx))
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+
+	for {
+		select {
+		case entry, ok := <-watch.Updates():
+			if !ok {
+				return fmt.Errorf("watch ended")
+			}
+
+			if entry == nil {
+				log.Infof("Loaded revocation bucket %s, %d values are revoked", BucketName, l.Count())
+				continue
+			}
+
+			if entry.Operation() != nats.KeyValuePut {
+				l.removeBucket(entry.Key())
+				continue
+			}
+
+			r, err := parseEntry(entry.Value())
+			if err != nil {
+				log.Errorf("Invalid revocation %s: %s", entry.Key(), err)
+				continue
+			}
+
+			l.addBucket(r)
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func parseEntry(data []byte) (*Revocation, error) {
+	r := &Revocation{}
+	err := json.Unmarshal(data, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package revocation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReadFile reads revocations from a JSON file, a missing file has no revocations
+func ReadFile(file string) ([]*Revocation, error) {
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var revs []*Revocation
+	err = json.Unmarshal(data, &revs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid revocation list %s: %s", file, err)
+	}
+
+	for _, r := range revs {
+		err = r.Validate()
+		if err != nil {
+			return nil, fmt.Errorf("invalid revocation list %s: %s", file, err)
+		}
+	}
+
+	sortRevocations(revs)
+
+	return revs, nil
+}
+
+// WriteFile atomically writes revocations to a JSON file
+func WriteFile(file string, revs []*Revocation) error {
+	for _, r := range revs {
+		err := r.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	sortRevocations(revs)
+
+	if revs == nil {
+		revs = []*Revocation{}
+	}
+
+	j, err := json.MarshalIndent(revs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(filepath.Dir(file), ".revocations-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = tf.Write(j)
+	tf.Close()
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tf.Name(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tf.Name(), file)
+}
+
+// LoadFile replaces all previously loaded file based revocations with those in file
+func (l *List) LoadFile(file string) error {
+	revs, err := ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	l.replaceFile(revs)
+
+	return nil
+}
+
+// WatchFile loads file and reloads it whenever it changes until ctx is canceled
+func (l *List) WatchFile(ctx context.Context, wg *sync.WaitGroup, file string, interval time.Duration, log *logrus.Entry) {
+	defer wg.Done()
+
+	var mtime time.Time
+
+	load := func() {
+		var current time.Time
+		stat, err := os.Stat(file)
+		if err == nil {
+			current = stat.ModTime()
+		}
+
+		if current.Equal(mtime) {
+			return
+		}
+
+		err = l.LoadFile(file)
+		if err != nil {
+			log.Errorf("Could not load revocation list: %s", err)
+			return
+		}
+
+		mtime = current
+		log.Infof("Loaded revocation list %s, %d values are revoked", file, l.Count())
+	}
+
+	load()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			load()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
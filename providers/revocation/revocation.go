@@ -0,0 +1,258 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package revocation maintains lists of revoked JWT tokens, callers and identities
+// that are distributed to brokers and servers using a file or a Choria Streams bucket
+package revocation
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/tokens"
+)
+
+// Kind is the kind of value being revoked
+type Kind string
+
+const (
+	// TokenID revokes a single token by its unique ID
+	TokenID Kind = "id"
+
+	// Caller revokes all client tokens for a caller like choria=bob
+	Caller Kind = "caller"
+
+	// Identity revokes all server tokens for a identity
+	Identity Kind = "identity"
+
+	// PublicKey revokes all tokens holding a specific public key
+	PublicKey Kind = "public_key"
+)
+
+// Kinds are all the valid kinds of revocation
+var Kinds = []Kind{TokenID, Caller, Identity, PublicKey}
+
+// Revocation is a single revoked value
+type Revocation struct {
+	Kind    Kind      `json:"kind"`
+	Value   string    `json:"value"`
+	Reason  string    `json:"reason,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// New creates a new revocation
+func New(kind Kind, value string, reason string) (*Revocation, error) {
+	r := &Revocation{
+		Kind:    kind,
+		Value:   value,
+		Reason:  reason,
+		Created: time.Now().UTC(),
+	}
+
+	err := r.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Validate checks the revocation is valid
+func (r *Revocation) Validate() error {
+	if r.Value == "" {
+		return fmt.Errorf("revoked value is required")
+	}
+
+	for _, k := range Kinds {
+		if r.Kind == k {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid revocation kind %q", r.Kind)
+}
+
+// Key is a unique key for the revoked value that is safe to use as a key in a Key-Value bucket
+func (r *Revocation) Key() string {
+	return key(r.Kind, r.Value)
+}
+
+// String is a human-readable description of the revocation
+func (r *Revocation) String() string {
+	switch r.Kind {
+	case TokenID:
+		return fmt.Sprintf("token id %s", r.Value)
+	case PublicKey:
+		return fmt.Sprintf("public key %s", r.Value)
+	default:
+		return fmt.Sprintf("%s %s", r.Kind, r.Value)
+	}
+}
+
+func key(kind Kind, value string) string {
+	return fmt.Sprintf("%s.%x", kind, sha256.Sum256([]byte(value)))
+}
+
+// List is a list of revocations sourced from a file and a Key-Value bucket
+type List struct {
+	file   map[string]*Revocation
+	bucket map[string]*Revocation
+	cb     func(*Revocation)
+	mu     sync.Mutex
+}
+
+// NewList creates a new empty revocation list
+func NewList() *List {
+	return &List{
+		file:   make(map[string]*Revocation),
+		bucket: make(map[string]*Revocation),
+	}
+}
+
+// OnRevoke sets a callback that will be called for every newly revoked value
+func (l *List) OnRevoke(cb func(*Revocation)) {
+	l.mu.Lock()
+	l.cb = cb
+	l.mu.Unlock()
+}
+
+// IsRevoked checks if a value is revoked
+func (l *List) IsRevoked(kind Kind, value string) (*Revocation, bool) {
+	if l == nil || value == "" {
+		return nil, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	k := key(kind, value)
+
+	r, ok := l.file[k]
+	if ok {
+		return r, true
+	}
+
+	r, ok = l.bucket[k]
+
+	return r, ok
+}
+
+// CheckClientClaims checks the token ID, caller and public key of a client token
+func (l *List) CheckClientClaims(claims *tokens.ClientIDClaims) (*Revocation, bool) {
+	if claims == nil {
+		return nil, false
+	}
+
+	return l.check(map[Kind]string{
+		TokenID:   claims.ID,
+		Caller:    claims.CallerID,
+		PublicKey: claims.PublicKey,
+	})
+}
+
+// CheckServerClaims checks the token ID, identity and public key of a server token
+func (l *List) CheckServerClaims(claims *tokens.ServerClaims) (*Revocation, bool) {
+	if claims == nil {
+		return nil, false
+	}
+
+	return l.check(map[Kind]string{
+		TokenID:   claims.ID,
+		Identity:  claims.ChoriaIdentity,
+		PublicKey: claims.PublicKey,
+	})
+}
+
+func (l *List) check(values map[Kind]string) (*Revocation, bool) {
+	for _, k := range Kinds {
+		r, ok := l.IsRevoked(k, values[k])
+		if ok {
+			return r, true
+		}
+	}
+
+	return nil, false
+}
+
+// Revocations are all the revocations in the list sorted by creation time
+func (l *List) Revocations() []*Revocation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	seen := make(map[string]*Revocation)
+	for k, r := range l.bucket {
+		seen[k] = r
+	}
+	for k, r := range l.file {
+		seen[k] = r
+	}
+
+	var res []*Revocation
+	for _, r := range seen {
+		res = append(res, r)
+	}
+
+	sortRevocations(res)
+
+	return res
+}
+
+// Count is the number of unique revoked values in the list
+func (l *List) Count() int {
+	return len(l.Revocations())
+}
+
+func (l *List) replaceFile(revs []*Revocation) {
+	current := make(map[string]*Revocation)
+	for _, r := range revs {
+		current[r.Key()] = r
+	}
+
+	l.mu.Lock()
+	previous := l.file
+	l.file = current
+	cb := l.cb
+	l.mu.Unlock()
+
+	if cb == nil {
+		return
+	}
+
+	for k, r := range current {
+		if _, ok := previous[k]; !ok {
+			cb(r)
+		}
+	}
+}
+
+func (l *List) addBucket(r *Revocation) {
+	l.mu.Lock()
+	_, known := l.bucket[r.Key()]
+	l.bucket[r.Key()] = r
+	cb := l.cb
+	l.mu.Unlock()
+
+	if !known && cb != nil {
+		cb(r)
+	}
+}
+
+func (l *List) removeBucket(k string) {
+	l.mu.Lock()
+	delete(l.bucket, k)
+	l.mu.Unlock()
+}
+
+func sortRevocations(revs []*Revocation) {
+	sort.Slice(revs, func(i, j int) bool {
+		if revs[i].Created.Equal(revs[j].Created) {
+			return revs[i].Key() < revs[j].Key()
+		}
+
+		return revs[i].Created.Before(revs[j].Created)
+	})
+}
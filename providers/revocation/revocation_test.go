@@ -0,0 +1,447 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package revocation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/choria-io/go-choria/tokens"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+)
+
+func TestRevocation(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Providers/Revocation")
+}
+
+var _ = Describe("Providers/Revocation", func() {
+	var (
+		log *logrus.Entry
+		td  string
+	)
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+		td = GinkgoT().TempDir()
+	})
+
+	Describe("New", func() {
+		It("Should validate revocations", func() {
+			_, err := New("other", "x", "")
+			Expect(err).To(MatchError(`invalid revocation kind "other"`))
+
+			_, err = New(Caller, "", "")
+			Expect(err).To(MatchError("revoked value is required"))
+
+			r, err := New(Caller, "choria=bob", "left the company")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(r.Key()).To(MatchRegexp("^caller\\.[a-f0-9]{64}$"))
+			Expect(r.String()).To(Equal("caller choria=bob"))
+			Expect(r.Created).To(BeTemporally("~", time.Now(), time.Second))
+		})
+	})
+
+	Describe("List", func() {
+		It("Should check client and server claims", func() {
+			list := NewList()
+			list.replaceFile([]*Revocation{
+				{Kind: TokenID, Value: "1234"},
+				{Kind: Caller, Value: "choria=bob"},
+				{Kind: Identity, Value: "node1.example.net"},
+				{Kind: PublicKey, Value: "abcd"},
+			})
+
+			client := func(id, caller, pk string) *tokens.ClientIDClaims {
+				return &tokens.ClientIDClaims{
+					CallerID:       caller,
+					PublicKey:      pk,
+					StandardClaims: tokens.StandardClaims{RegisteredClaims: jwt.RegisteredClaims{ID: id}},
+				}
+			}
+			srv := func(id, identity, pk string) *tokens.ServerClaims {
+				return &tokens.ServerClaims{
+					ChoriaIdentity: identity,
+					PublicKey:      pk,
+					StandardClaims: tokens.StandardClaims{RegisteredClaims: jwt.RegisteredClaims{ID: id}},
+				}
+			}
+
+			r, revoked := list.CheckClientClaims(client("1234", "choria=alice", "x"))
+			Expect(revoked).To(BeTrue())
+			Expect(r.Kind).To(Equal(TokenID))
+			_, revoked = list.CheckClientClaims(client("", "choria=bob", "x"))
+			Expect(revoked).To(BeTrue())
+			_, revoked = list.CheckClientClaims(client("", "choria=alice", "abcd"))
+			Expect(revoked).To(BeTrue())
+			_, revoked = list.CheckClientClaims(client("", "choria=alice", "x"))
+			Expect(revoked).To(BeFalse())
+			_, revoked = list.CheckClientClaims(client("", "node1.example.net", "x"))
+			Expect(revoked).To(BeFalse())
+
+			_, revoked = list.CheckServerClaims(srv("", "node1.example.net", "x"))
+			Expect(revoked).To(BeTrue())
+			_, revoked = list.CheckServerClaims(srv("1234", "node2.example.net", "x"))
+			Expect(revoked).To(BeTrue())
+			_, revoked = list.CheckServerClaims(srv("", "choria=bob", "x"))
+			Expect(revoked).To(BeFalse())
+		})
+
+		It("Should be safe to use when not configured", func() {
+			var list *List
+			_, revoked := list.IsRevoked(Caller, "choria=bob")
+			Expect(revoked).To(BeFalse())
+			_, revoked = list.CheckClientClaims(&tokens.ClientIDClaims{CallerID: "choria=bob"})
+			Expect(revoked).To(BeFalse())
+		})
+
+		It("Should only notify about new revocations", func() {
+			list := NewList()
+			var seen []string
+			list.OnRevoke(func(r *Revocation) { seen = append(seen, r.Value) })
+
+			list.replaceFile([]*Revocation{{Kind: Caller, Value: "choria=bob"}})
+			list.replaceFile([]*Revocation{{Kind: Caller, Value: "choria=bob"}, {Kind: Caller, Value: "choria=alice"}})
+			list.addBucket(&Revocation{Kind: Caller, Value: "choria=bob"})
+			list.addBucket(&Revocation{Kind: Caller, Value: "choria=bob"})
+			Expect(seen).To(Equal([]string{"choria=bob", "choria=alice", "choria=bob"}))
+			Expect(list.Count()).To(Equal(2))
+
+			list.replaceFile(nil)
+			list.removeBucket(key(Caller, "choria=bob"))
+			Expect(list.Count()).To(Equal(0))
+		})
+	})
+
+	Describe("Files", func() {
+		It("Should read and write files", func() {
+			file := filepath.Join(td, "revoked.json")
+
+			revs, err := ReadFile(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revs).To(BeEmpty())
+
+			r, _ := New(Caller, "choria=bob", "testing")
+			Expect(WriteFile(file, []*Revocation{r})).To(Succeed())
+
+			revs, err = ReadFile(file)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revs).To(HaveLen(1))
+			Expect(revs[0].Value).To(Equal("choria=bob"))
+			Expect(revs[0].Reason).To(Equal("testing"))
+
+			Expect(os.WriteFile(file, []byte(`[{"kind":"other","value":"x"}]`), 0600)).To(Succeed())
+			_, err = ReadFile(file)
+			Expect(err).To(MatchError(ContainSubstring(`invalid revocation kind "other"`)))
+		})
+
+		It("Should watch files for changes", func() {
+			file := filepath.Join(td, "revoked.json")
+			r, _ := New(Caller, "choria=bob", "")
+			Expect(WriteFile(file, []*Revocation{r})).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wg := &sync.WaitGroup{}
+
+			list := NewList()
+			wg.Add(1)
+			go list.WatchFile(ctx, wg, file, 10*time.Millisecond, log)
+
+			Eventually(func() bool {
+				_, revoked := list.IsRevoked(Caller, "choria=bob")
+				return revoked
+			}).Should(BeTrue())
+
+			// ensure the modification time changes
+			time.Sleep(20 * time.Millisecond)
+			r, _ = New(Identity, "node1.example.net", "")
+			Expect(WriteFile(file, []*Revocation{r})).To(Succeed())
+			Expect(os.Chtimes(file, time.Now().Add(time.Second), time.Now().Add(time.Second))).To(Succeed())
+
+			Eventually(func() bool {
+				_, revoked := list.IsRevoked(Identity, "node1.example.net")
+				return revoked
+			}).Should(BeTrue())
+			_, revoked := list.IsRevoked(Caller, "choria=bob")
+			Expect(revoked).To(BeFalse())
+
+			cancel()
+			wg.Wait()
+		})
+	})
+
+	Describe("Bucket", func() {
+		var (
+			srv *server.Server
+			nc  *nats.Conn
+		)
+
+		BeforeEach(func() {
+			srv, nc = startJSServer(GinkgoT())
+		})
+
+		AfterEach(func() {
+			nc.Close()
+			srv.Shutdown()
+			srv.WaitForShutdown()
+			os.RemoveAll(srv.StoreDir())
+		})
+
+		It("Should store, load and remove revocations", func() {
+			_, err := Bucket(nc, false, 1)
+			Expect(err).To(HaveOccurred())
+
+			bucket, err := Bucket(nc, true, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			revs, err := LoadBucket(bucket)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revs).To(BeEmpty())
+
+			r, _ := New(Caller, "choria=bob", "testing")
+			Expect(Store(bucket, r)).To(Succeed())
+
+			revs, err = LoadBucket(bucket)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revs).To(HaveLen(1))
+			Expect(revs[0].Value).To(Equal("choria=bob"))
+
+			Expect(Remove(bucket, Caller, "choria=bob")).To(Succeed())
+			Expect(Remove(bucket, Caller, "choria=bob")).To(MatchError("caller choria=bob is not revoked"))
+
+			revs, err = LoadBucket(bucket)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(revs).To(BeEmpty())
+		})
+
+		It("Should watch the bucket", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wg := &sync.WaitGroup{}
+
+			var mu sync.Mutex
+			var seen []string
+
+			list := NewList()
+			list.OnRevoke(func(r *Revocation) {
+				mu.Lock()
+				seen = append(seen, r.Value)
+				mu.Unlock()
+			})
+
+			wg.Add(1)
+			go list.WatchBucket(ctx, wg, nc, log)
+
+			bucket, err := Bucket(nc, true, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			r, _ := New(Caller, "choria=bob", "")
+			Expect(Store(bucket, r)).To(Succeed())
+
+			Eventually(func() bool {
+				_, revoked := list.IsRevoked(Caller, "choria=bob")
+				return revoked
+			}, 5*time.Second).Should(BeTrue())
+
+			mu.Lock()
+			Expect(seen).To(Equal([]string{"choria=bob"}))
+			mu.Unlock()
+
+			Expect(Remove(bucket, Caller, "choria=bob")).To(Succeed())
+			Eventually(list.Count).Should(Equal(0))
+
+			cancel()
+			wg.Wait()
+		})
+	})
+
+	Describe("Imported Bucket", func() {
+		It("Should watch the bucket using the imported API", func() {
+			srv, choria, acme := startAccountsServer(GinkgoT())
+			defer func() {
+				choria.Close()
+				acme.Close()
+				srv.Shutdown()
+				srv.WaitForShutdown()
+				os.RemoveAll(srv.StoreDir())
+			}()
+
+			bucket, err := Bucket(choria, true, 1)
+			Expect(err).ToNot(HaveOccurred())
+
+			r, _ := New(Caller, "choria=bob", "")
+			Expect(Store(bucket, r)).To(Succeed())
+
+			// a bucket in the organization account is not the one the broker enforces
+			local, err := Bucket(acme, true, 1)
+			Expect(err).ToNot(HaveOccurred())
+			r, _ = New(Identity, "local.example.net", "")
+			Expect(Store(local, r)).To(Succeed())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			wg := &sync.WaitGroup{}
+
+			list := NewList()
+			wg.Add(1)
+			go list.WatchImportedBucket(ctx, wg, acme, log)
+
+			Eventually(func() bool {
+				_, revoked := list.IsRevoked(Caller, "choria=bob")
+				return revoked
+			}, 5*time.Second).Should(BeTrue())
+
+			r, _ = New(Identity, "n1.example.net", "")
+			Expect(Store(bucket, r)).To(Succeed())
+			Eventually(func() bool {
+				_, revoked := list.IsRevoked(Identity, "n1.example.net")
+				return revoked
+			}, 15*time.Second).Should(BeTrue())
+
+			Expect(Remove(bucket, Caller, "choria=bob")).To(Succeed())
+			Eventually(list.Count, 15*time.Second).Should(Equal(1))
+
+			_, revoked := list.IsRevoked(Identity, "local.example.net")
+			Expect(revoked).To(BeFalse())
+
+			cancel()
+			wg.Wait()
+		})
+	})
+})
+
+// startAccountsServer starts a server with Choria Streams in the choria account and imports the revocation
+// bucket API into the acme account like the broker does for organizations
+func startAccountsServer(t GinkgoTInterface) (*server.Server, *nats.Conn, *nats.Conn) {
+	t.Helper()
+
+	d, err := os.MkdirTemp("", "jstest")
+	if err != nil {
+		t.Fatalf("temp dir could not be made: %s", err)
+	}
+
+	choriaAcct := server.NewAccount("choria")
+	acmeAcct := server.NewAccount("acme")
+
+	opts := &server.Options{
+		JetStream: true,
+		StoreDir:  d,
+		Port:      -1,
+		Host:      "localhost",
+		Accounts:  []*server.Account{choriaAcct, acmeAcct},
+		Users: []*server.User{
+			{Username: "choria", Password: "choria", Account: choriaAcct},
+			{Username: "acme", Password: "acme", Account: acmeAcct},
+		},
+	}
+
+	s, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal("server start failed: ", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Error("nats server did not start")
+	}
+
+	choriaAcct, err = s.LookupAccount("choria")
+	if err != nil {
+		t.Fatalf("choria account not found: %s", err)
+	}
+	acmeAcct, err = s.LookupAccount("acme")
+	if err != nil {
+		t.Fatalf("acme account not found: %s", err)
+	}
+
+	for _, acct := range []*server.Account{choriaAcct, acmeAcct} {
+		err = acct.EnableJetStream(map[string]server.JetStreamAccountLimits{"": {MaxMemory: -1, MaxStore: -1, MaxStreams: -1, MaxConsumers: -1}})
+		if err != nil {
+			t.Fatalf("could not enable jetstream: %s", err)
+		}
+	}
+
+	for _, api := range []string{"STREAM.INFO.KV_CHORIA_REVOCATIONS", "CONSUMER.CREATE.KV_CHORIA_REVOCATIONS", "CONSUMER.DELETE.KV_CHORIA_REVOCATIONS.*", "CONSUMER.MSG.NEXT.KV_CHORIA_REVOCATIONS.*"} {
+		rt := server.Singleton
+		if strings.HasPrefix(api, "CONSUMER.MSG.NEXT") {
+			rt = server.Streamed
+		}
+
+		err = choriaAcct.AddServiceExportWithResponse("$JS.API."+api, rt, []*server.Account{acmeAcct})
+		if err != nil {
+			t.Fatalf("export failed: %s", err)
+		}
+		err = acmeAcct.AddServiceImport(choriaAcct, ImportAPIPrefix+"."+api, "$JS.API."+api)
+		if err != nil {
+			t.Fatalf("import failed: %s", err)
+		}
+	}
+
+	err = choriaAcct.AddServiceExport("$JS.ACK.KV_CHORIA_REVOCATIONS.>", []*server.Account{acmeAcct})
+	if err == nil {
+		err = acmeAcct.AddServiceImport(choriaAcct, "$JS.ACK.KV_CHORIA_REVOCATIONS.>", "$JS.ACK.KV_CHORIA_REVOCATIONS.>")
+	}
+	if err != nil {
+		t.Fatalf("ack import failed: %s", err)
+	}
+
+	choria, err := nats.Connect(s.ClientURL(), nats.UserInfo("choria", "choria"))
+	if err != nil {
+		t.Fatalf("client start failed: %s", err)
+	}
+
+	acme, err := nats.Connect(s.ClientURL(), nats.UserInfo("acme", "acme"))
+	if err != nil {
+		t.Fatalf("client start failed: %s", err)
+	}
+
+	return s, choria, acme
+}
+
+func startJSServer(t GinkgoTInterface) (*server.Server, *nats.Conn) {
+	t.Helper()
+
+	d, err := os.MkdirTemp("", "jstest")
+	if err != nil {
+		t.Fatalf("temp dir could not be made: %s", err)
+	}
+
+	opts := &server.Options{
+		JetStream: true,
+		StoreDir:  d,
+		Port:      -1,
+		Host:      "localhost",
+	}
+
+	s, err := server.NewServer(opts)
+	if err != nil {
+		t.Fatal("server start failed: ", err)
+	}
+
+	go s.Start()
+	if !s.ReadyForConnections(10 * time.Second) {
+		t.Error("nats server did not start")
+	}
+
+	nc, err := nats.Connect(s.ClientURL(), nats.UseOldRequestStyle())
+	if err != nil {
+		t.Fatalf("client start failed: %s", err)
+	}
+
+	return s, nc
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/choria-io/go-choria/aagent/watchers/nagioswatcher"
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/providers/agent/mcorpc"
+)
+
+type HistoryRequest struct {
+	Checks []string `json:"checks"`
+}
+
+type HistoryResponse struct {
+	Checks []*CheckHistory `json:"checks"`
+}
+
+type CheckHistory struct {
+	Name    string                      `json:"name"`
+	History *nagioswatcher.CheckHistory `json:"history"`
+}
+
+func historyAction(_ context.Context, req *mcorpc.Request, reply *mcorpc.Reply, agent *mcorpc.Agent, _ inter.ConnectorInfo) {
+	resp := &HistoryResponse{Checks: []*CheckHistory{}}
+	reply.Data = resp
+
+	args := &HistoryRequest{}
+	if !mcorpc.ParseRequestData(args, req, reply) {
+		return
+	}
+
+	states, err := agent.ServerInfoSource.MachinesStatus()
+	if err != nil {
+		abort(fmt.Sprintf("Failed to retrieve states: %s", err), reply)
+		return
+	}
+
+	found := 0
+	for _, m := range states {
+		if !m.Scout {
+			continue
+		}
+
+		if len(args.Checks) > 0 && !stringInStrings(m.Name, args.Checks) {
+			continue
+		}
+
+		found++
+
+		h, ok := m.ScoutHistory.(*nagioswatcher.CheckHistory)
+		if !ok {
+			continue
+		}
+
+		resp.Checks = append(resp.Checks, &CheckHistory{Name: m.Name, History: h})
+	}
+
+	if len(args.Checks) > 0 && found != len(args.Checks) {
+		abort("Some checks are not known", reply)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/backoff"
+	"github.com/choria-io/go-choria/inter"
+	"github.com/choria-io/go-choria/scout/maintenance"
+	"github.com/choria-io/go-choria/server/agents"
+)
+
+const (
+	// maintenanceInterval is the longest time between evaluations, it ensures newly added checks are considered
+	maintenanceInterval = time.Minute
+
+	// maintenanceStateFile is the file in the machine store where windows applied by the scheduler are recorded
+	maintenanceStateFile = ".scout_maintenance.json"
+)
+
+// maintenanceRetry is how long to wait before watching the bucket again after repeated failures, saturating at 5 minutes
+var maintenanceRetry = backoff.Policy{
+	Millis: []int{10000, 20000, 40000, 80000, 160000, 300000},
+}
+
+// maintenanceScheduler places checks in and out of maintenance based on windows stored in Key-Value
+type maintenanceScheduler struct {
+	fw        inter.Framework
+	si        agents.ServerInfoSource
+	kv        nats.KeyValue
+	log       *logrus.Entry
+	stateFile string
+
+	// windows are the valid windows in the bucket by id, kept up to date by watching the bucket
+	windows map[string]*maintenance.Window
+
+	// applied are checks placed in maintenance by the scheduler and the window that did so,
+	// checks manually placed in maintenance are never resumed by the scheduler
+	applied map[string]string
+}
+
+func newMaintenanceScheduler(fw inter.Framework, si agents.ServerInfoSource, log *logrus.Entry) *maintenanceScheduler {
+	s := &maintenanceScheduler{
+		fw:      fw,
+		si:      si,
+		log:     log.WithField("scheduler", "maintenance"),
+		windows: make(map[string]*maintenance.Window),
+		applied: make(map[string]string),
+	}
+
+	if cfg := fw.Configuration(); cfg != nil && cfg.Choria.MachineSourceDir != "" {
+		s.stateFile = filepath.Join(cfg.Choria.MachineSourceDir, maintenanceStateFile)
+	}
+
+	return s
+}
+
+// Run watches the bucket and evaluates the windows whenever they change, a window starts or ends and at least every maintenanceInterval.
+//
+// Nodes without scout checks do not watch the bucket until checks are added
+func (s *maintenanceScheduler) Run(ctx context.Context) {
+	err := s.loadApplied()
+	if err != nil {
+		s.log.Warnf("Could not load previously applied maintenance windows: %s", err)
+	}
+
+	tries := 0
+	for {
+		if !s.hasChecks() {
+			err = backoff.Default.Sleep(ctx, maintenanceInterval)
+			if err != nil {
+				return
+			}
+			continue
+		}
+
+		started := time.Now()
+		err = s.watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			s.log.Debugf("Could not watch maintenance windows: %s", err)
+		}
+
+		// a watch that ran for a while failed for new reasons so retries start over
+		if time.Since(started) > maintenanceInterval {
+			tries = 0
+		}
+
+		err = maintenanceRetry.TrySleep(ctx, tries)
+		if err != nil {
+			return
+		}
+		tries++
+	}
+}
+
+// hasChecks determines if any scout checks are running on the node
+func (s *maintenanceScheduler) hasChecks() bool {
+	states, err := s.si.MachinesStatus()
+	if err != nil {
+		return false
+	}
+
+	for _, m := range states {
+		if m.Scout {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *maintenanceScheduler) watch(ctx context.Context) error {
+	kv, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	watch, err := kv.WatchAll(nats.Context(ctx))
+	if err != nil {
+		return err
+	}
+	defer watch.Stop()
+
+	timer := time.NewTimer(maintenanceInterval)
+	defer timer.Stop()
+
+	// the watcher sends all current values followed by a nil, we evaluate once all are received
+	initialized := false
+
+	for {
+		select {
+		case entry, ok := <-watch.Updates():
+			if !ok {
+				return fmt.Errorf("watch of bucket %s ended", maintenance.Bucket)
+			}
+
+			if entry == nil {
+				initialized = true
+			} else {
+				s.updateWindow(entry)
+			}
+
+			if !initialized {
+				continue
+			}
+
+		case <-timer.C:
+
+		case <-ctx.Done():
+			return nil
+		}
+
+		now := time.Now()
+
+		err = s.evaluate(now)
+		if err != nil {
+			s.log.Debugf("Could not evaluate maintenance windows: %s", err)
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(s.nextChange(now))
+	}
+}
+
+func (s *maintenanceScheduler) updateWindow(entry nats.KeyValueEntry) {
+	if entry.Operation() != nats.KeyValuePut {
+		delete(s.windows, entry.Key())
+		return
+	}
+
+	w, err := maintenance.Parse(entry.Value())
+	if err != nil {
+		s.log.Warnf("Skipping maintenance window %s: %s", entry.Key(), err)
+		delete(s.windows, entry.Key())
+		return
+	}
+
+	s.windows[entry.Key()] = w
+}
+
+// nextChange is the time until the next window starts or ends, capped at maintenanceInterval
+func (s *maintenanceScheduler) nextChange(now time.Time) time.Duration {
+	next := maintenanceInterval
+
+	for _, w := range s.windows {
+		start, end := w.Next(now)
+		if end.IsZero() {
+			continue
+		}
+
+		change := end
+		if start.After(now) {
+			change = start
+		}
+
+		if d := change.Sub(now); d < next {
+			next = d
+		}
+	}
+
+	if next < time.Second {
+		next = time.Second
+	}
+
+	return next
+}
+
+func (s *maintenanceScheduler) sortedWindows() []*maintenance.Window {
+	windows := make([]*maintenance.Window, 0, len(s.windows))
+	for _, w := range s.windows {
+		windows = append(windows, w)
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].ID < windows[j].ID
+	})
+
+	return windows
+}
+
+func (s *maintenanceScheduler) loadApplied() error {
+	if s.stateFile == "" {
+		return nil
+	}
+
+	j, err := os.ReadFile(s.stateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(j, &s.applied)
+}
+
+func (s *maintenanceScheduler) saveApplied() error {
+	if s.stateFile == "" {
+		return nil
+	}
+
+	j, err := json.Marshal(s.applied)
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(filepath.Dir(s.stateFile), maintenanceStateFile)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = tf.Write(j)
+	tf.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tf.Name(), s.stateFile)
+}
+
+func (s *maintenanceScheduler) bucket(ctx context.Context) (nats.KeyValue, error) {
+	if s.kv != nil {
+		return s.kv, nil
+	}
+
+	var conn inter.Connector
+	if c, ok := s.si.(interface{ Connector() inter.Connector }); ok {
+		conn = c.Connector()
+	}
+
+	kv, err := s.fw.KV(ctx, conn, maintenance.Bucket, false)
+	if err != nil {
+		return nil, err
+	}
+
+	s.kv = kv
+
+	return kv, nil
+}
+
+func (s *maintenanceScheduler) evaluate(now time.Time) error {
+	windows := s.sortedWindows()
+
+	states, err := s.si.MachinesStatus()
+	if err != nil {
+		return err
+	}
+
+	identity := s.si.Identity()
+	changed := false
+
+	for _, m := range states {
+		if !m.Scout {
+			continue
+		}
+
+		w := maintenance.ActiveWindow(windows, identity, m.Name, now)
+
+		switch {
+		case w != nil && m.State != maintenanceState:
+			if !stringInStrings(maintenanceTransition, m.AvailableTransitions) {
+				continue
+			}
+
+			s.log.Infof("Placing check %s in maintenance for window %s", m.Name, w.ID)
+			err = s.si.MachineTransition(m.Name, "", "", "", maintenanceTransition)
+			if err != nil {
+				s.log.Errorf("Could not place check %s in maintenance: %s", m.Name, err)
+				continue
+			}
+
+			s.applied[m.Name] = w.ID
+			changed = true
+
+		case w == nil && s.applied[m.Name] != "":
+			if m.State == maintenanceState && stringInStrings(resumeTransition, m.AvailableTransitions) {
+				s.log.Infof("Resuming check %s after maintenance window %s ended", m.Name, s.applied[m.Name])
+				err = s.si.MachineTransition(m.Name, "", "", "", resumeTransition)
+				if err != nil {
+					s.log.Errorf("Could not resume check %s: %s", m.Name, err)
+					continue
+				}
+			}
+
+			delete(s.applied, m.Name)
+			changed = true
+		}
+	}
+
+	if changed {
+		err = s.saveApplied()
+		if err != nil {
+			s.log.Errorf("Could not save applied maintenance windows: %s", err)
+		}
+	}
+
+	return nil
+}
@@ -5,6 +5,9 @@
 package agent
 
 import (
+	"context"
+
+	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/logger"
 	"github.com/choria-io/go-choria/plugin"
 	"github.com/choria-io/go-choria/providers/agent/mcorpc"
@@ -20,8 +23,21 @@ const (
 	forceTransition       = "FORCE_CHECK"
 	maintenanceTransition = "MAINTENANCE"
 	resumeTransition      = "RESUME"
+	maintenanceState      = "MAINTENANCE"
 )
 
+// scoutAgent starts the maintenance scheduler once the agent is registered in a server
+type scoutAgent struct {
+	*mcorpc.Agent
+
+	fw inter.Framework
+}
+
+// StartBackground implements agents.BackgroundAgent
+func (a *scoutAgent) StartBackground(ctx context.Context) {
+	go newMaintenanceScheduler(a.fw, a.ServerInfo(), a.Log).Run(ctx)
+}
+
 func New(mgr server.AgentManager) (agents.Agent, error) {
 	log = mgr.Logger()
 
@@ -30,6 +46,7 @@ func New(mgr server.AgentManager) (agents.Agent, error) {
 	agent.SetActivationChecker(activationCheck(mgr))
 
 	agent.MustRegisterAction("checks", checksAction)
+	agent.MustRegisterAction("history", historyAction)
 	agent.MustRegisterAction("trigger", triggerAction)
 	agent.MustRegisterAction("maintenance", maintenanceAction)
 	agent.MustRegisterAction("resume", resumeAction)
@@ -37,7 +54,7 @@ func New(mgr server.AgentManager) (agents.Agent, error) {
 
 	// TODO: info action showing machine info - facts and inventory like response
 
-	return agent, nil
+	return &scoutAgent{Agent: agent, fw: mgr.Choria()}, nil
 }
 
 func ChoriaPlugin() plugin.Pluggable {
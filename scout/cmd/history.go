@@ -0,0 +1,164 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scoutcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/client/scoutclient"
+	scoutagent "github.com/choria-io/go-choria/scout/agent/scout"
+)
+
+type HistoryCommand struct {
+	identity string
+	checks   []string
+	json     bool
+	fw       inter.Framework
+	verbose  bool
+	colorize bool
+	log      *logrus.Entry
+}
+
+func NewHistoryCommand(fw inter.Framework, id string, checks []string, jsonf bool, verbose bool, colorize bool, log *logrus.Entry) (*HistoryCommand, error) {
+	return &HistoryCommand{
+		identity: id,
+		checks:   checks,
+		json:     jsonf,
+		fw:       fw,
+		log:      log,
+		verbose:  verbose,
+		colorize: colorize,
+	}, nil
+}
+
+func (h *HistoryCommand) Run(ctx context.Context, wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	sc, err := scoutclient.New(h.fw, scoutclient.Logger(h.log), scoutclient.Progress())
+	if err != nil {
+		return err
+	}
+
+	var checks = make([]any, len(h.checks))
+	for i, c := range h.checks {
+		checks[i] = c
+	}
+
+	res, err := sc.OptionTargets([]string{h.identity}).History().Checks(checks).Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	if h.json {
+		return res.RenderResults(os.Stdout, scoutclient.JSONFormat, scoutclient.DisplayDDL, h.verbose, false, h.colorize, h.log)
+	}
+
+	var outputs []*scoutclient.HistoryOutput
+	res.EachOutput(func(o *scoutclient.HistoryOutput) {
+		outputs = append(outputs, o)
+	})
+
+	if len(outputs) != 1 {
+		return res.RenderResults(os.Stdout, scoutclient.JSONFormat, scoutclient.DisplayDDL, h.verbose, false, h.colorize, h.log)
+	}
+
+	if !outputs[0].ResultDetails().OK() {
+		return fmt.Errorf("loading history failed: %s", outputs[0].ResultDetails().StatusMessage())
+	}
+
+	history := scoutagent.HistoryResponse{}
+	err = outputs[0].ParseHistoryOutput(&history)
+	if err != nil {
+		return err
+	}
+
+	if len(history.Checks) == 1 {
+		h.renderCheck(history.Checks[0])
+	} else {
+		h.renderSummary(history.Checks)
+	}
+
+	fmt.Println()
+	return res.RenderResults(os.Stdout, scoutclient.TXTFooter, scoutclient.DisplayDDL, h.verbose, false, h.colorize, h.log)
+}
+
+func (h *HistoryCommand) renderSummary(checks []*scoutagent.CheckHistory) {
+	table := newMarkdownTable("Name", "State", "Since", "Changes", "Flapping")
+
+	for _, c := range checks {
+		if c.History == nil {
+			continue
+		}
+
+		since := "Never"
+		if !c.History.Since.IsZero() {
+			since = time.Since(c.History.Since).Round(time.Second).String()
+		}
+
+		flapping := "no"
+		if c.History.Flapping {
+			flapping = fmt.Sprintf("yes (%d changes)", c.History.FlapCount)
+		}
+
+		table.Append([]string{c.Name, c.History.State, since, fmt.Sprintf("%d", len(c.History.Transitions)), flapping})
+	}
+
+	table.Render()
+}
+
+func (h *HistoryCommand) renderCheck(check *scoutagent.CheckHistory) {
+	hist := check.History
+	if hist == nil {
+		fmt.Printf("No history for %s\n", check.Name)
+		return
+	}
+
+	fmt.Printf("Check %s is %s since %s", check.Name, hist.State, hist.Since.Local().Format(time.RFC1123))
+	if hist.Flapping {
+		fmt.Printf(", flapping with %d changes", hist.FlapCount)
+	}
+	fmt.Println()
+	fmt.Println()
+
+	var states []string
+	for s := range hist.StateDurations {
+		states = append(states, s)
+	}
+	sort.Strings(states)
+
+	if len(states) > 0 {
+		table := newMarkdownTable("State", "Time Spent")
+		for _, s := range states {
+			table.Append([]string{s, (time.Duration(hist.StateDurations[s]) * time.Second).Round(time.Second).String()})
+		}
+		table.Render()
+		fmt.Println()
+	}
+
+	if len(hist.Transitions) > 0 {
+		table := newMarkdownTable("Time", "From", "To", "Duration")
+		for _, t := range hist.Transitions {
+			table.Append([]string{t.Time.Local().Format(time.RFC1123), t.From, t.To, (time.Duration(t.Duration) * time.Second).Round(time.Second).String()})
+		}
+		table.Render()
+		fmt.Println()
+	}
+
+	if len(hist.Outputs) > 0 {
+		table := newMarkdownTable("Time", "Status", "Output")
+		for _, o := range hist.Outputs {
+			table.Append([]string{o.Time.Local().Format(time.RFC1123), o.Status, o.Output})
+		}
+		table.Render()
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -75,12 +76,20 @@ func (s *StatusCommand) Run(ctx context.Context, wg *sync.WaitGroup) (err error)
 		return err
 	}
 
-	table := newMarkdownTable("Name", "State", "Last Check", "History")
+	table := newMarkdownTable("Name", "State", "Last Check", "History", "Depends On")
 
 	for _, c := range checks.Checks {
 		last := "Never"
+		state := c.State
 		history := []string{}
+		deps := []string{}
 		if c.Status != nil {
+			deps = c.Status.Dependencies
+
+			if c.Status.Suppressed {
+				state = fmt.Sprintf("%s (suppressed)", state)
+			}
+
 			if c.Status.CheckTime != 0 {
 				last = time.Since(time.Unix(c.Status.CheckTime, 0)).Round(time.Second).String()
 			}
@@ -104,11 +113,96 @@ func (s *StatusCommand) Run(ctx context.Context, wg *sync.WaitGroup) (err error)
 			}
 		}
 
-		table.Append([]string{c.Name, c.State, last, strings.Join(history, " ")})
+		table.Append([]string{c.Name, state, last, strings.Join(history, " "), strings.Join(deps, ", ")})
 	}
 
 	table.Render()
 
+	graph := dependencyGraph(checks.Checks)
+	if graph != "" {
+		fmt.Println()
+		fmt.Println("Check Dependencies:")
+		fmt.Println()
+		fmt.Print(graph)
+	}
+
 	fmt.Println()
 	return res.RenderResults(os.Stdout, scoutclient.TXTFooter, scoutclient.DisplayDDL, s.verbose, false, s.colorize, s.log)
 }
+
+// dependencyGraph renders a tree of checks and the checks that depend on them, checks without
+// dependencies or dependents are not shown
+func dependencyGraph(checks []*scoutagent.CheckState) string {
+	states := map[string]*scoutagent.CheckState{}
+	children := map[string][]string{}
+	hasParent := map[string]bool{}
+
+	for _, c := range checks {
+		states[c.Name] = c
+		if c.Status == nil {
+			continue
+		}
+
+		for _, d := range c.Status.Dependencies {
+			children[d] = append(children[d], c.Name)
+			hasParent[c.Name] = true
+		}
+	}
+
+	if len(children) == 0 {
+		return ""
+	}
+
+	var roots []string
+	for parent := range children {
+		if !hasParent[parent] {
+			roots = append(roots, parent)
+		}
+	}
+	sort.Strings(roots)
+
+	label := func(name string) string {
+		c, ok := states[name]
+		if !ok {
+			return fmt.Sprintf("%s (unknown check)", name)
+		}
+
+		if c.Status != nil && c.Status.Suppressed {
+			return fmt.Sprintf("%s %s (suppressed)", name, c.State)
+		}
+
+		return fmt.Sprintf("%s %s", name, c.State)
+	}
+
+	var buf strings.Builder
+	var walk func(name string, prefix string, visited map[string]bool)
+	walk = func(name string, prefix string, visited map[string]bool) {
+		kids := children[name]
+		sort.Strings(kids)
+
+		for i, kid := range kids {
+			branch, next := "├── ", "│   "
+			if i == len(kids)-1 {
+				branch, next = "└── ", "    "
+			}
+
+			if visited[kid] {
+				fmt.Fprintf(&buf, "%s%s%s (cycle)\n", prefix, branch, kid)
+				continue
+			}
+
+			fmt.Fprintf(&buf, "%s%s%s\n", prefix, branch, label(kid))
+
+			visited[kid] = true
+			walk(kid, prefix+next, visited)
+			delete(visited, kid)
+		}
+	}
+
+	for _, root := range roots {
+		fmt.Fprintf(&buf, "%s\n", label(root))
+		walk(root, "", map[string]bool{root: true})
+	}
+
+	return buf.String()
+}
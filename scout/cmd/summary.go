@@ -0,0 +1,417 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package scoutcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/inter"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent/machine"
+	"github.com/choria-io/go-choria/aagent/watchers/nagioswatcher"
+	"github.com/choria-io/go-choria/client/discovery"
+	"github.com/choria-io/go-choria/client/scoutclient"
+	scoutagent "github.com/choria-io/go-choria/scout/agent/scout"
+)
+
+// FleetSummary is an aggregated view of scout checks across a fleet
+type FleetSummary struct {
+	Time        time.Time             `json:"time"`
+	Nodes       int                   `json:"nodes"`
+	FailedNodes []string              `json:"failed_nodes"`
+	Checks      []*CheckSummary       `json:"checks"`
+	WorstNodes  []*NodeSummary        `json:"worst_nodes"`
+	Maintenance []*MaintenanceSummary `json:"maintenance"`
+}
+
+// CheckSummary is the number of nodes in each state for a check
+type CheckSummary struct {
+	Name   string         `json:"name"`
+	States map[string]int `json:"states"`
+}
+
+// NodeSummary is the number of non OK checks on a node
+type NodeSummary struct {
+	Identity string `json:"identity"`
+	Critical int    `json:"critical"`
+	Warning  int    `json:"warning"`
+	Unknown  int    `json:"unknown"`
+	Checks   int    `json:"checks"`
+}
+
+// MaintenanceSummary lists the nodes where a check is in maintenance
+type MaintenanceSummary struct {
+	Name  string   `json:"name"`
+	Nodes []string `json:"nodes"`
+}
+
+type SummaryCommand struct {
+	sopt     *discovery.StandardOptions
+	fw       inter.Framework
+	json     bool
+	watch    bool
+	interval time.Duration
+	top      int
+	verbose  bool
+	colorize bool
+	log      *logrus.Entry
+
+	// states are check states by identity and check name
+	states  map[string]map[string]string
+	failed  []string
+	changed bool
+
+	sync.Mutex
+}
+
+func NewSummaryCommand(sopt *discovery.StandardOptions, fw inter.Framework, jsonf bool, watch bool, interval time.Duration, top int, verbose bool, colorize bool, log *logrus.Entry) (*SummaryCommand, error) {
+	return &SummaryCommand{
+		sopt:     sopt,
+		fw:       fw,
+		json:     jsonf,
+		watch:    watch,
+		interval: interval,
+		top:      top,
+		verbose:  verbose,
+		colorize: colorize,
+		log:      log,
+		states:   make(map[string]map[string]string),
+	}, nil
+}
+
+func (s *SummaryCommand) Run(ctx context.Context, wg *sync.WaitGroup) (err error) {
+	defer wg.Done()
+
+	res, err := s.loadStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !s.watch {
+		err = s.render(false)
+		if err != nil || s.json {
+			return err
+		}
+
+		fmt.Println()
+		return res.RenderResults(os.Stdout, scoutclient.TXTFooter, scoutclient.DisplayDDL, s.verbose, false, s.colorize, s.log)
+	}
+
+	if s.interval < time.Second {
+		return fmt.Errorf("interval should be at least 1 second")
+	}
+
+	nc, err := s.fw.NewConnector(ctx, s.fw.MiddlewareServers, s.fw.Certname(), s.log)
+	if err != nil {
+		return fmt.Errorf("cannot connect: %s", err)
+	}
+	defer nc.Close()
+
+	events := make(chan *nats.Msg, 1000)
+	for _, subj := range []string{"choria.machine.transition", "choria.machine.watcher.nagios.state"} {
+		_, err = nc.Nats().ChanSubscribe(subj, events)
+		if err != nil {
+			return fmt.Errorf("could not subscribe to %s: %s", subj, err)
+		}
+	}
+
+	err = s.render(true)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case m := <-events:
+			s.handleEvent(m)
+
+		case <-ticker.C:
+			s.Lock()
+			changed := s.changed
+			s.changed = false
+			s.Unlock()
+
+			if !changed {
+				continue
+			}
+
+			err = s.render(true)
+			if err != nil {
+				return err
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *SummaryCommand) loadStates(ctx context.Context) (*scoutclient.ChecksResult, error) {
+	sc, err := scoutClient(s.fw, s.sopt, s.log)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := sc.Checks().Do(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	res.EachOutput(func(o *scoutclient.ChecksOutput) {
+		if !o.ResultDetails().OK() {
+			s.failed = append(s.failed, o.ResultDetails().Sender())
+			return
+		}
+
+		checks := scoutagent.ChecksResponse{}
+		err := o.ParseChecksOutput(&checks)
+		if err != nil {
+			s.log.Errorf("Could not parse checks from %s: %s", o.ResultDetails().Sender(), err)
+			s.failed = append(s.failed, o.ResultDetails().Sender())
+			return
+		}
+
+		node := make(map[string]string)
+		for _, c := range checks.Checks {
+			node[c.Name] = c.State
+		}
+		s.states[o.ResultDetails().Sender()] = node
+	})
+
+	sort.Strings(s.failed)
+
+	return res, nil
+}
+
+// handleEvent updates the states of checks on nodes discovered at start from scout events
+func (s *SummaryCommand) handleEvent(m *nats.Msg) {
+	if m == nil {
+		return
+	}
+
+	var identity, check, state string
+
+	data, err := dataFromCloudEventJSON(m.Data)
+	if err != nil {
+		s.log.Debugf("could not parse cloud event: %s", err)
+		return
+	}
+
+	switch m.Subject {
+	case "choria.machine.transition":
+		t := &machine.TransitionNotification{}
+		err = json.Unmarshal(data, t)
+		if err != nil {
+			s.log.Debugf("could not parse transition: %s", err)
+			return
+		}
+		identity, check, state = t.Identity, t.Machine, t.ToState
+
+	default:
+		n := &nagioswatcher.StateNotification{}
+		err = json.Unmarshal(data, n)
+		if err != nil {
+			s.log.Debugf("could not parse state: %s", err)
+			return
+		}
+		identity, check, state = n.Identity, n.Machine, n.Status
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	node, ok := s.states[identity]
+	if !ok {
+		return
+	}
+
+	// transitions are published for all machines, only track known scout checks
+	current, ok := node[check]
+	if !ok || current == state {
+		return
+	}
+
+	node[check] = state
+	s.changed = true
+}
+
+// Summary aggregates the current states
+func (s *SummaryCommand) Summary() *FleetSummary {
+	s.Lock()
+	defer s.Unlock()
+
+	summary := &FleetSummary{
+		Time:        time.Now().UTC(),
+		Nodes:       len(s.states),
+		FailedNodes: append([]string{}, s.failed...),
+		Checks:      []*CheckSummary{},
+		WorstNodes:  []*NodeSummary{},
+		Maintenance: []*MaintenanceSummary{},
+	}
+
+	checks := map[string]*CheckSummary{}
+	maintenance := map[string]*MaintenanceSummary{}
+
+	for identity, node := range s.states {
+		ns := &NodeSummary{Identity: identity, Checks: len(node)}
+
+		for name, state := range node {
+			cs, ok := checks[name]
+			if !ok {
+				cs = &CheckSummary{Name: name, States: map[string]int{}}
+				checks[name] = cs
+			}
+			cs.States[state]++
+
+			switch state {
+			case "CRITICAL":
+				ns.Critical++
+			case "WARNING":
+				ns.Warning++
+			case "UNKNOWN":
+				ns.Unknown++
+			case "MAINTENANCE":
+				ms, ok := maintenance[name]
+				if !ok {
+					ms = &MaintenanceSummary{Name: name}
+					maintenance[name] = ms
+				}
+				ms.Nodes = append(ms.Nodes, identity)
+			}
+		}
+
+		if ns.Critical+ns.Warning+ns.Unknown > 0 {
+			summary.WorstNodes = append(summary.WorstNodes, ns)
+		}
+	}
+
+	for _, cs := range checks {
+		summary.Checks = append(summary.Checks, cs)
+	}
+	sort.Slice(summary.Checks, func(i, j int) bool { return summary.Checks[i].Name < summary.Checks[j].Name })
+
+	for _, ms := range maintenance {
+		sort.Strings(ms.Nodes)
+		summary.Maintenance = append(summary.Maintenance, ms)
+	}
+	sort.Slice(summary.Maintenance, func(i, j int) bool { return summary.Maintenance[i].Name < summary.Maintenance[j].Name })
+
+	sort.Slice(summary.WorstNodes, func(i, j int) bool {
+		a, b := summary.WorstNodes[i], summary.WorstNodes[j]
+		switch {
+		case a.Critical != b.Critical:
+			return a.Critical > b.Critical
+		case a.Warning != b.Warning:
+			return a.Warning > b.Warning
+		case a.Unknown != b.Unknown:
+			return a.Unknown > b.Unknown
+		default:
+			return a.Identity < b.Identity
+		}
+	})
+
+	if s.top > 0 && len(summary.WorstNodes) > s.top {
+		summary.WorstNodes = summary.WorstNodes[:s.top]
+	}
+
+	return summary
+}
+
+func (s *SummaryCommand) render(refresh bool) error {
+	summary := s.Summary()
+
+	if s.json {
+		// in watch mode every refresh is a single line to ease parsing by other tools
+		var j []byte
+		var err error
+		if refresh {
+			j, err = json.Marshal(summary)
+		} else {
+			j, err = json.MarshalIndent(summary, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(j))
+		return nil
+	}
+
+	if refresh {
+		fmt.Print("\033[2J\033[H")
+		fmt.Printf("Scout summary at %s, refreshing every %v\n\n", summary.Time.Local().Format(time.RFC1123), s.interval)
+	}
+
+	table := newMarkdownTable("Check", "OK", "Warning", "Critical", "Unknown", "Maintenance", "Other")
+	for _, c := range summary.Checks {
+		other := 0
+		for state, count := range c.States {
+			switch state {
+			case "OK", "WARNING", "CRITICAL", "UNKNOWN", "MAINTENANCE":
+			default:
+				other += count
+			}
+		}
+
+		table.Append([]string{
+			c.Name,
+			fmt.Sprintf("%d", c.States["OK"]),
+			fmt.Sprintf("%d", c.States["WARNING"]),
+			fmt.Sprintf("%d", c.States["CRITICAL"]),
+			fmt.Sprintf("%d", c.States["UNKNOWN"]),
+			fmt.Sprintf("%d", c.States["MAINTENANCE"]),
+			fmt.Sprintf("%d", other),
+		})
+	}
+	table.Render()
+
+	if len(summary.WorstNodes) > 0 {
+		fmt.Println()
+		fmt.Println("Worst Offending Nodes:")
+		fmt.Println()
+
+		table = newMarkdownTable("Identity", "Critical", "Warning", "Unknown", "Checks")
+		for _, n := range summary.WorstNodes {
+			table.Append([]string{n.Identity, fmt.Sprintf("%d", n.Critical), fmt.Sprintf("%d", n.Warning), fmt.Sprintf("%d", n.Unknown), fmt.Sprintf("%d", n.Checks)})
+		}
+		table.Render()
+	}
+
+	if len(summary.Maintenance) > 0 {
+		fmt.Println()
+		fmt.Println("Checks In Maintenance:")
+		fmt.Println()
+
+		table = newMarkdownTable("Check", "Nodes")
+		for _, m := range summary.Maintenance {
+			table.Append([]string{m.Name, strings.Join(m.Nodes, ", ")})
+		}
+		table.Render()
+	}
+
+	fmt.Println()
+	fmt.Printf("Nodes: %d", summary.Nodes)
+	if len(summary.FailedNodes) > 0 {
+		fmt.Printf(" Failed: %s", strings.Join(summary.FailedNodes, ", "))
+	}
+	fmt.Println()
+
+	return nil
+}
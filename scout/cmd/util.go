@@ -6,6 +6,7 @@ package scoutcmd
 
 import (
 	"github.com/choria-io/go-choria/inter"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/olekukonko/tablewriter"
 	"github.com/sirupsen/logrus"
 
@@ -36,3 +37,13 @@ func scoutClient(fw inter.Framework, opt *discovery.StandardOptions, log *logrus
 func newMarkdownTable(hdr ...string) *tablewriter.Table {
 	return util.NewMarkdownTable(hdr...)
 }
+
+func dataFromCloudEventJSON(j []byte) ([]byte, error) {
+	event := cloudevents.NewEvent("1.0")
+	err := event.UnmarshalJSON(j)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.Data(), nil
+}
@@ -18,7 +18,6 @@ import (
 
 	"github.com/awesome-gocui/gocui"
 	"github.com/choria-io/go-choria/inter"
-	cloudevents "github.com/cloudevents/sdk-go/v2"
 	"github.com/fatih/color"
 	"github.com/nats-io/jsm.go"
 	"github.com/nats-io/nats.go"
@@ -129,22 +128,12 @@ func (w *WatchCommand) Run(ctx context.Context, wg *sync.WaitGroup) (err error)
 	return nil
 }
 
-func (w *WatchCommand) dataFromCloudEventJSON(j []byte) ([]byte, error) {
-	event := cloudevents.NewEvent("1.0")
-	err := event.UnmarshalJSON(j)
-	if err != nil {
-		return nil, err
-	}
-
-	return event.Data(), nil
-}
-
 func (w *WatchCommand) handleTransition(m *nats.Msg, gui *gocui.Gui) {
 	if m == nil {
 		return
 	}
 
-	data, err := w.dataFromCloudEventJSON(m.Data)
+	data, err := dataFromCloudEventJSON(m.Data)
 	if err != nil {
 		w.log.Errorf("could not parse cloud event: %s", err)
 		return
@@ -199,7 +188,7 @@ func (w *WatchCommand) handleState(m *nats.Msg, gui *gocui.Gui) {
 		return
 	}
 
-	data, err := w.dataFromCloudEventJSON(m.Data)
+	data, err := dataFromCloudEventJSON(m.Data)
 	if err != nil {
 		w.log.Errorf("could not parse cloud event: %s", err)
 		return
@@ -0,0 +1,247 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package maintenance manages scheduled maintenance windows for Scout checks stored in a Key-Value bucket
+package maintenance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/robfig/cron"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+// Bucket is the Key-Value bucket maintenance windows are stored in
+const Bucket = "SCOUT_MAINTENANCE"
+
+var validID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// Window is a period where matching Scout checks are placed in maintenance
+type Window struct {
+	// ID is the unique identifier of the window, used as the key in the bucket
+	ID string `json:"id"`
+	// Description is a human friendly reason for the maintenance
+	Description string `json:"description,omitempty"`
+	// Identities are regular expressions matched against node identities, empty matches all nodes
+	Identities []string `json:"identities,omitempty"`
+	// Checks are regular expressions matched against check names, empty matches all checks
+	Checks []string `json:"checks,omitempty"`
+	// Start is when a once-off window starts
+	Start time.Time `json:"start,omitempty"`
+	// End is when a once-off window ends
+	End time.Time `json:"end,omitempty"`
+	// Schedule is a cron schedule for recurring windows
+	Schedule string `json:"schedule,omitempty"`
+	// Duration is how long each recurring window lasts
+	Duration string `json:"duration,omitempty"`
+	// Created is when the window was created
+	Created time.Time `json:"created"`
+	// Creator is who created the window
+	Creator string `json:"creator,omitempty"`
+
+	identities []*regexp.Regexp
+	checks     []*regexp.Regexp
+	schedule   cron.Schedule
+	duration   time.Duration
+}
+
+// Parse parses and validates a window stored in the bucket
+func Parse(data []byte) (*Window, error) {
+	w := &Window{}
+	err := json.Unmarshal(data, w)
+	if err != nil {
+		return nil, err
+	}
+
+	err = w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Validate checks the window is valid and compiles its matchers
+func (w *Window) Validate() error {
+	var err error
+
+	if !validID.MatchString(w.ID) {
+		return fmt.Errorf("id must match %s", validID.String())
+	}
+
+	switch {
+	case w.Schedule != "" && !w.Start.IsZero():
+		return fmt.Errorf("windows require either a start and end or a schedule and duration")
+
+	case w.Schedule != "":
+		w.schedule, err = cron.ParseStandard(w.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule: %s", err)
+		}
+
+		w.duration, err = iu.ParseDuration(w.Duration)
+		if err != nil {
+			return fmt.Errorf("invalid duration: %s", err)
+		}
+
+		if w.duration <= 0 {
+			return fmt.Errorf("duration must be positive")
+		}
+
+	case !w.Start.IsZero():
+		if !w.End.After(w.Start) {
+			return fmt.Errorf("end must be after start")
+		}
+
+	default:
+		return fmt.Errorf("windows require either a start and end or a schedule and duration")
+	}
+
+	w.identities = nil
+	for _, i := range w.Identities {
+		re, err := regexp.Compile(i)
+		if err != nil {
+			return fmt.Errorf("invalid identity matcher %q: %s", i, err)
+		}
+		w.identities = append(w.identities, re)
+	}
+
+	w.checks = nil
+	for _, c := range w.Checks {
+		re, err := regexp.Compile(c)
+		if err != nil {
+			return fmt.Errorf("invalid check matcher %q: %s", c, err)
+		}
+		w.checks = append(w.checks, re)
+	}
+
+	return nil
+}
+
+// Active determines if the window is in effect at a specific time
+func (w *Window) Active(now time.Time) bool {
+	if w.schedule == nil {
+		return !now.Before(w.Start) && now.Before(w.End)
+	}
+
+	// the first start after now-duration is the only one that could still be running
+	start := w.schedule.Next(now.Add(-w.duration))
+	return !start.After(now)
+}
+
+// Next is the next period the window is active in that ends after now, zero when the window expired
+func (w *Window) Next(now time.Time) (start time.Time, end time.Time) {
+	if w.schedule == nil {
+		if !now.Before(w.End) {
+			return time.Time{}, time.Time{}
+		}
+
+		return w.Start, w.End
+	}
+
+	start = w.schedule.Next(now.Add(-w.duration))
+	return start, start.Add(w.duration)
+}
+
+// Expired determines if a once-off window has ended
+func (w *Window) Expired(now time.Time) bool {
+	return w.schedule == nil && !now.Before(w.End)
+}
+
+// Matches determines if the window applies to a check on a node
+func (w *Window) Matches(identity string, check string) bool {
+	return matchAny(w.identities, identity) && matchAny(w.checks, check)
+}
+
+// ActiveWindow finds the first window that places a check in maintenance at a specific time
+func ActiveWindow(windows []*Window, identity string, check string, now time.Time) *Window {
+	for _, w := range windows {
+		if w.Active(now) && w.Matches(identity, check) {
+			return w
+		}
+	}
+
+	return nil
+}
+
+// Load retrieves all windows from the bucket sorted by id, invalid windows are returned as errors
+func Load(kv nats.KeyValue) ([]*Window, []error, error) {
+	keys, err := kv.Keys()
+	if err != nil {
+		if errors.Is(err, nats.ErrNoKeysFound) {
+			return []*Window{}, nil, nil
+		}
+
+		return nil, nil, err
+	}
+
+	var (
+		windows []*Window
+		errs    []error
+	)
+
+	for _, k := range keys {
+		entry, err := kv.Get(k)
+		if err != nil {
+			if errors.Is(err, nats.ErrKeyNotFound) {
+				continue
+			}
+
+			return nil, nil, err
+		}
+
+		w, err := Parse(entry.Value())
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid window %s: %s", k, err))
+			continue
+		}
+
+		windows = append(windows, w)
+	}
+
+	sort.Slice(windows, func(i, j int) bool { return windows[i].ID < windows[j].ID })
+
+	return windows, errs, nil
+}
+
+// Save stores a window in the bucket
+func Save(kv nats.KeyValue, w *Window) error {
+	err := w.Validate()
+	if err != nil {
+		return err
+	}
+
+	if w.Created.IsZero() {
+		w.Created = time.Now().UTC()
+	}
+
+	j, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+
+	_, err = kv.Put(w.ID, j)
+	return err
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	if len(res) == 0 {
+		return true
+	}
+
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
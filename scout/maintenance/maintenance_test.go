@@ -0,0 +1,109 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package maintenance
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func TestMaintenance(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scout/Maintenance")
+}
+
+var _ = Describe("Scout/Maintenance", func() {
+	var now time.Time
+
+	BeforeEach(func() {
+		now = time.Date(2022, 9, 4, 2, 30, 0, 0, time.Local) // a sunday
+	})
+
+	Describe("Validate", func() {
+		It("Should validate windows", func() {
+			Expect((&Window{ID: "x y"}).Validate()).To(MatchError("id must match ^[a-zA-Z0-9_-]+$"))
+			Expect((&Window{ID: "x"}).Validate()).To(MatchError("windows require either a start and end or a schedule and duration"))
+			Expect((&Window{ID: "x", Start: now, End: now}).Validate()).To(MatchError("end must be after start"))
+			Expect((&Window{ID: "x", Start: now, Schedule: "@daily"}).Validate()).To(MatchError("windows require either a start and end or a schedule and duration"))
+			Expect((&Window{ID: "x", Schedule: "foo", Duration: "1h"}).Validate()).To(MatchError(ContainSubstring("invalid schedule")))
+			Expect((&Window{ID: "x", Schedule: "@daily", Duration: "0s"}).Validate()).To(MatchError("duration must be positive"))
+			Expect((&Window{ID: "x", Schedule: "@daily", Duration: "1h", Checks: []string{"("}}).Validate()).To(MatchError(ContainSubstring("invalid check matcher")))
+			Expect((&Window{ID: "x", Schedule: "@daily", Duration: "1h"}).Validate()).To(Succeed())
+			Expect((&Window{ID: "x", Start: now, End: now.Add(time.Hour)}).Validate()).To(Succeed())
+		})
+
+		It("Should parse stored windows", func() {
+			j, err := json.Marshal(&Window{ID: "x", Schedule: "0 2 * * 0", Duration: "1h"})
+			Expect(err).ToNot(HaveOccurred())
+
+			w, err := Parse(j)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(w.Active(now)).To(BeTrue())
+		})
+	})
+
+	Describe("Active", func() {
+		It("Should support once-off windows", func() {
+			w := &Window{ID: "x", Start: now, End: now.Add(time.Hour)}
+			Expect(w.Validate()).To(Succeed())
+
+			Expect(w.Active(now.Add(-time.Second))).To(BeFalse())
+			Expect(w.Active(now)).To(BeTrue())
+			Expect(w.Active(now.Add(59 * time.Minute))).To(BeTrue())
+			Expect(w.Active(now.Add(time.Hour))).To(BeFalse())
+			Expect(w.Expired(now.Add(time.Hour))).To(BeTrue())
+
+			start, end := w.Next(now.Add(-time.Hour))
+			Expect(start).To(Equal(now))
+			Expect(end).To(Equal(now.Add(time.Hour)))
+
+			start, _ = w.Next(now.Add(2 * time.Hour))
+			Expect(start.IsZero()).To(BeTrue())
+		})
+
+		It("Should support recurring windows", func() {
+			// every sunday at 02:00 for an hour
+			w := &Window{ID: "x", Schedule: "0 2 * * 0", Duration: "1h"}
+			Expect(w.Validate()).To(Succeed())
+
+			Expect(w.Active(now)).To(BeTrue())
+			Expect(w.Active(now.Add(-31 * time.Minute))).To(BeFalse())
+			Expect(w.Active(now.Add(30 * time.Minute))).To(BeFalse())
+			Expect(w.Active(now.Add(7 * 24 * time.Hour))).To(BeTrue())
+			Expect(w.Active(now.Add(24 * time.Hour))).To(BeFalse())
+			Expect(w.Expired(now.Add(24 * time.Hour))).To(BeFalse())
+
+			start, end := w.Next(now)
+			Expect(start).To(Equal(now.Add(-30 * time.Minute)))
+			Expect(end).To(Equal(now.Add(30 * time.Minute)))
+
+			start, _ = w.Next(now.Add(time.Hour))
+			Expect(start).To(Equal(now.Add(7*24*time.Hour - 30*time.Minute)))
+		})
+	})
+
+	Describe("ActiveWindow", func() {
+		It("Should match identities and checks", func() {
+			windows := []*Window{
+				{ID: "expired", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+				{ID: "web", Start: now, End: now.Add(time.Hour), Identities: []string{"^web"}, Checks: []string{"^http", "^disk"}},
+			}
+			for _, w := range windows {
+				Expect(w.Validate()).To(Succeed())
+			}
+
+			Expect(ActiveWindow(windows, "web1", "http_check", now).ID).To(Equal("web"))
+			Expect(ActiveWindow(windows, "web1", "disk_root", now).ID).To(Equal("web"))
+			Expect(ActiveWindow(windows, "web1", "load", now)).To(BeNil())
+			Expect(ActiveWindow(windows, "db1", "http_check", now)).To(BeNil())
+			Expect(ActiveWindow(windows, "web1", "http_check", now.Add(2*time.Hour))).To(BeNil())
+			Expect(ActiveWindow(windows, "db1", "load", now.Add(-90*time.Minute)).ID).To(Equal("expired"))
+		})
+	})
+})
@@ -0,0 +1,304 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+// Config configures the notification service
+type Config struct {
+	// Stream is the stream holding scout events, defaults to CHORIA_MACHINE
+	Stream string `json:"stream"`
+	// Consumer is the name of the durable consumer to create, defaults to SCOUT_NOTIFY
+	Consumer string `json:"consumer"`
+	// DedupeWindow is how long to wait before notifying again about a check that stays in the same state, defaults to 1h
+	DedupeWindow string `json:"dedupe_window"`
+	// Retries is how many times delivery to a sink is attempted, defaults to 5
+	Retries int `json:"retries"`
+	// StateFile is where the last notified state of each check is saved, without it notifications are sent again after restarts
+	StateFile string `json:"state_file"`
+	// Routes are evaluated in order, the first matching route delivers the notification unless it has Continue set
+	Routes []*Route `json:"routes"`
+	// Sinks are the notification destinations by name
+	Sinks map[string]*SinkConfig `json:"sinks"`
+
+	dedupeWindow time.Duration
+}
+
+// Route matches scout events and delivers them to sinks
+type Route struct {
+	// Name is a descriptive name for the route
+	Name string `json:"name"`
+	// Checks are regular expressions matched against the check name, empty matches all
+	Checks []string `json:"checks"`
+	// Identities are regular expressions matched against the node identity, empty matches all
+	Identities []string `json:"identities"`
+	// Tags must all match the annotations on the check
+	Tags map[string]string `json:"tags"`
+	// States are the check states to notify about, empty means all
+	States []string `json:"states"`
+	// Window restricts the route to certain times
+	Window *TimeWindow `json:"window"`
+	// Sinks are the names of sinks to deliver to
+	Sinks []string `json:"sinks"`
+	// Continue evaluating further routes after this one matched
+	Continue bool `json:"continue"`
+
+	checks     []*regexp.Regexp
+	identities []*regexp.Regexp
+}
+
+// TimeWindow is a period of time on certain days of the week
+type TimeWindow struct {
+	// Days are the days this window is active like mon, tue, empty means every day
+	Days []string `json:"days"`
+	// Start is the time of day the window starts in 15:04 format
+	Start string `json:"start"`
+	// End is the time of day the window ends in 15:04 format, windows ending before they start span midnight
+	End string `json:"end"`
+	// Timezone is the location the times are in, defaults to the local time zone
+	Timezone string `json:"timezone"`
+
+	start    time.Duration
+	end      time.Duration
+	location *time.Location
+}
+
+// SinkConfig configures a notification destination
+type SinkConfig struct {
+	// Type is one of webhook, smtp or stdout
+	Type string `json:"type"`
+
+	// URL is the address webhooks are POSTed to
+	URL string `json:"url"`
+	// Headers are additional HTTP headers sent to webhooks
+	Headers map[string]string `json:"headers"`
+	// Timeout is the timeout for a single delivery attempt, defaults to 10s
+	Timeout string `json:"timeout"`
+
+	// Server is the SMTP server in host:port format
+	Server string `json:"server"`
+	// Username is the optional SMTP username
+	Username string `json:"username"`
+	// Password is the optional SMTP password
+	Password string `json:"password"`
+	// From is the sender address for emails
+	From string `json:"from"`
+	// To are the recipients of emails
+	To []string `json:"to"`
+}
+
+var weekDays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// LoadConfig reads and validates a YAML or JSON configuration file
+func LoadConfig(file string) (*Config, error) {
+	cfgb, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	err = yaml.Unmarshal(cfgb, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration %s: %s", file, err)
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration and sets defaults
+func (c *Config) Validate() error {
+	var err error
+
+	if c.Stream == "" {
+		c.Stream = "CHORIA_MACHINE"
+	}
+
+	if c.Consumer == "" {
+		c.Consumer = "SCOUT_NOTIFY"
+	}
+
+	if c.Retries <= 0 {
+		c.Retries = 5
+	}
+
+	c.dedupeWindow = time.Hour
+	if c.DedupeWindow != "" {
+		c.dedupeWindow, err = iu.ParseDuration(c.DedupeWindow)
+		if err != nil {
+			return fmt.Errorf("invalid dedupe_window: %s", err)
+		}
+	}
+
+	if len(c.Sinks) == 0 {
+		return fmt.Errorf("no sinks configured")
+	}
+
+	for name, sink := range c.Sinks {
+		err = sink.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid sink %s: %s", name, err)
+		}
+	}
+
+	if len(c.Routes) == 0 {
+		return fmt.Errorf("no routes configured")
+	}
+
+	for i, route := range c.Routes {
+		if route.Name == "" {
+			route.Name = fmt.Sprintf("route %d", i+1)
+		}
+
+		err = route.Validate()
+		if err != nil {
+			return fmt.Errorf("invalid route %s: %s", route.Name, err)
+		}
+
+		for _, s := range route.Sinks {
+			if _, ok := c.Sinks[s]; !ok {
+				return fmt.Errorf("invalid route %s: unknown sink %s", route.Name, s)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the route and compiles its matchers
+func (r *Route) Validate() error {
+	if len(r.Sinks) == 0 {
+		return fmt.Errorf("no sinks specified")
+	}
+
+	r.checks = nil
+	for _, c := range r.Checks {
+		re, err := regexp.Compile(c)
+		if err != nil {
+			return fmt.Errorf("invalid check matcher %q: %s", c, err)
+		}
+		r.checks = append(r.checks, re)
+	}
+
+	r.identities = nil
+	for _, i := range r.Identities {
+		re, err := regexp.Compile(i)
+		if err != nil {
+			return fmt.Errorf("invalid identity matcher %q: %s", i, err)
+		}
+		r.identities = append(r.identities, re)
+	}
+
+	for i, s := range r.States {
+		r.States[i] = strings.ToUpper(s)
+	}
+
+	if r.Window != nil {
+		return r.Window.Validate()
+	}
+
+	return nil
+}
+
+// Validate checks the time window and parses its times
+func (w *TimeWindow) Validate() error {
+	var err error
+
+	for _, d := range w.Days {
+		if _, ok := weekDays[strings.ToLower(d)]; !ok {
+			return fmt.Errorf("invalid day %q", d)
+		}
+	}
+
+	w.start, err = parseTimeOfDay(w.Start)
+	if err != nil {
+		return fmt.Errorf("invalid window start: %s", err)
+	}
+
+	w.end, err = parseTimeOfDay(w.End)
+	if err != nil {
+		return fmt.Errorf("invalid window end: %s", err)
+	}
+
+	w.location = time.Local
+	if w.Timezone != "" {
+		w.location, err = time.LoadLocation(w.Timezone)
+		if err != nil {
+			return fmt.Errorf("invalid timezone: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks the sink configuration
+func (s *SinkConfig) Validate() error {
+	switch s.Type {
+	case "webhook":
+		if s.URL == "" {
+			return fmt.Errorf("url is required")
+		}
+
+	case "smtp":
+		if s.Server == "" {
+			return fmt.Errorf("server is required")
+		}
+		if s.From == "" {
+			return fmt.Errorf("from is required")
+		}
+		if len(s.To) == 0 {
+			return fmt.Errorf("to is required")
+		}
+
+	case "stdout":
+
+	default:
+		return fmt.Errorf("unknown sink type %q", s.Type)
+	}
+
+	if s.Timeout != "" {
+		_, err := iu.ParseDuration(s.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout: %s", err)
+		}
+	}
+
+	return nil
+}
+
+func parseTimeOfDay(t string) (time.Duration, error) {
+	if t == "" {
+		return 0, nil
+	}
+
+	parsed, err := time.Parse("15:04", t)
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
@@ -0,0 +1,359 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/nats-io/jsm.go"
+	"github.com/nats-io/nats.go"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent/watchers/nagioswatcher"
+	"github.com/choria-io/go-choria/backoff"
+)
+
+// StateSubject is the subject scout check states are published on
+const StateSubject = "choria.machine.watcher.nagios.state"
+
+// Notification is the message delivered to sinks
+type Notification struct {
+	Identity       string            `json:"identity"`
+	Check          string            `json:"check"`
+	Status         string            `json:"status"`
+	PreviousStatus string            `json:"previous_status,omitempty"`
+	Output         string            `json:"output"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+	Time           time.Time         `json:"time"`
+	Route          string            `json:"route"`
+}
+
+// String is a human friendly representation of the notification
+func (n *Notification) String() string {
+	if n.PreviousStatus != "" && n.PreviousStatus != n.Status {
+		return fmt.Sprintf("%s %s %s %s => %s", n.Time.Format(time.RFC3339), n.Identity, n.Check, n.PreviousStatus, n.Status)
+	}
+
+	return fmt.Sprintf("%s %s %s is %s", n.Time.Format(time.RFC3339), n.Identity, n.Check, n.Status)
+}
+
+type notified struct {
+	status string
+	time   time.Time
+}
+
+// notifiedState is the persisted form of the last notified state of a check
+type notifiedState struct {
+	Identity string    `json:"identity"`
+	Check    string    `json:"check"`
+	Status   string    `json:"status"`
+	Time     time.Time `json:"time"`
+}
+
+// Service consumes scout events and routes notifications to sinks
+type Service struct {
+	cfg    *Config
+	sinks  map[string]Sink
+	policy backoff.Policy
+	log    *logrus.Entry
+
+	// notified is the last state notified for each identity and check
+	notified map[string]*notified
+	mu       sync.Mutex
+}
+
+// New creates a new notification service, stdout sinks write to out or os.Stdout when nil
+func New(cfg *Config, out io.Writer, log *logrus.Entry) (*Service, error) {
+	err := cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	if out == nil {
+		out = os.Stdout
+	}
+
+	s := &Service{
+		cfg:      cfg,
+		sinks:    make(map[string]Sink),
+		policy:   backoff.FiveSec,
+		log:      log,
+		notified: make(map[string]*notified),
+	}
+
+	for name, sc := range cfg.Sinks {
+		s.sinks[name], err = newSink(sc, out)
+		if err != nil {
+			return nil, fmt.Errorf("could not create sink %s: %s", name, err)
+		}
+	}
+
+	if cfg.StateFile != "" {
+		err = s.loadState()
+		if err != nil {
+			return nil, fmt.Errorf("could not load state from %s: %s", cfg.StateFile, err)
+		}
+	}
+
+	return s, nil
+}
+
+// Run consumes events from the configured stream using a durable consumer until ctx is done
+func (s *Service) Run(ctx context.Context, nc *nats.Conn) error {
+	mgr, err := jsm.New(nc)
+	if err != nil {
+		return err
+	}
+
+	cons, err := mgr.LoadOrNewConsumer(s.cfg.Stream, s.cfg.Consumer,
+		jsm.DurableName(s.cfg.Consumer),
+		jsm.FilterStreamBySubject(StateSubject),
+		jsm.StartWithNextReceived(),
+		jsm.AcknowledgeExplicit(),
+		jsm.AckWait(time.Duration(s.cfg.Retries+1)*time.Minute),
+	)
+	if err != nil {
+		return fmt.Errorf("could not load consumer %s on stream %s: %s", s.cfg.Consumer, s.cfg.Stream, err)
+	}
+
+	s.log.Infof("Consuming scout events from %s using consumer %s", s.cfg.Stream, s.cfg.Consumer)
+
+	for {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		tctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		msg, err := cons.NextMsgContext(tctx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != context.DeadlineExceeded {
+				s.log.Warnf("Could not fetch the next event: %s", err)
+				backoff.Default.TrySleep(ctx, 1)
+			}
+
+			continue
+		}
+
+		err = s.ProcessEvent(ctx, msg.Data, time.Now())
+		if err != nil {
+			s.log.Errorf("Could not process event: %s", err)
+		}
+
+		// failed deliveries were already retried by the sinks, redelivery would only notify the sinks that succeeded again
+		msg.Ack()
+	}
+}
+
+// ProcessEvent routes a scout state CloudEvent to sinks
+func (s *Service) ProcessEvent(ctx context.Context, data []byte, now time.Time) error {
+	event := cloudevents.NewEvent("1.0")
+	err := event.UnmarshalJSON(data)
+	if err != nil {
+		return fmt.Errorf("invalid cloud event: %s", err)
+	}
+
+	state := &nagioswatcher.StateNotification{}
+	err = json.Unmarshal(event.Data(), state)
+	if err != nil {
+		return fmt.Errorf("invalid scout state: %s", err)
+	}
+
+	return s.Process(ctx, state, now)
+}
+
+// Process routes a scout state to sinks, deduplicating repeated states
+func (s *Service) Process(ctx context.Context, state *nagioswatcher.StateNotification, now time.Time) error {
+	// checks suppressed by their dependencies do not notify
+	if state.Suppressed {
+		return nil
+	}
+
+	n := &Notification{
+		Identity:    state.Identity,
+		Check:       state.Machine,
+		Status:      state.Status,
+		Output:      state.Output,
+		Annotations: state.Annotations,
+		Time:        now.UTC(),
+	}
+
+	previous, notify := s.shouldNotify(n, now)
+	if !notify {
+		s.log.Debugf("Not notifying about duplicate %s", n)
+		return nil
+	}
+	n.PreviousStatus = previous
+
+	var errs []error
+	delivered := false
+	for _, route := range s.cfg.Routes {
+		if !route.Matches(n, now) {
+			continue
+		}
+
+		rn := *n
+		rn.Route = route.Name
+
+		for _, name := range route.Sinks {
+			err := s.deliver(ctx, name, &rn)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			delivered = true
+		}
+
+		if !route.Continue {
+			break
+		}
+	}
+
+	// failed deliveries are not recorded so the next event for the check tries again
+	if delivered || len(errs) == 0 {
+		s.recordNotified(n, now)
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d deliveries failed, first error: %s", len(errs), errs[0])
+	}
+
+	return nil
+}
+
+func (s *Service) deliver(ctx context.Context, name string, n *Notification) error {
+	sink, ok := s.sinks[name]
+	if !ok {
+		return fmt.Errorf("unknown sink %s", name)
+	}
+
+	var err error
+	for try := 1; try <= s.cfg.Retries; try++ {
+		err = sink.Deliver(ctx, n)
+		if err == nil {
+			s.log.Infof("Delivered %s via %s", n, name)
+			return nil
+		}
+
+		s.log.Warnf("Delivery attempt %d of %s via %s failed: %s", try, n, name, err)
+
+		if try < s.cfg.Retries {
+			if serr := s.policy.TrySleep(ctx, try); serr != nil {
+				return serr
+			}
+		}
+	}
+
+	return fmt.Errorf("delivery via %s failed after %d attempts: %s", name, s.cfg.Retries, err)
+}
+
+func notifyKey(n *Notification) string {
+	return n.Identity + "\x00" + n.Check
+}
+
+// shouldNotify determines if a notification is needed and returns the previously notified status, recoveries
+// are only sent for checks that previously notified a problem
+func (s *Service) shouldNotify(n *Notification, now time.Time) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.notified[notifyKey(n)]
+	if !ok {
+		return "", n.Status != "OK"
+	}
+
+	if prev.status != n.Status {
+		return prev.status, true
+	}
+
+	if n.Status == "OK" {
+		return prev.status, false
+	}
+
+	return prev.status, now.Sub(prev.time) >= s.cfg.dedupeWindow
+}
+
+func (s *Service) recordNotified(n *Notification, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notified[notifyKey(n)] = &notified{status: n.Status, time: now}
+
+	if s.cfg.StateFile == "" {
+		return
+	}
+
+	err := s.saveState()
+	if err != nil {
+		s.log.Errorf("Could not save state to %s: %s", s.cfg.StateFile, err)
+	}
+}
+
+func (s *Service) loadState() error {
+	j, err := os.ReadFile(s.cfg.StateFile)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var states []notifiedState
+	err = json.Unmarshal(j, &states)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, st := range states {
+		s.notified[st.Identity+"\x00"+st.Check] = &notified{status: st.Status, time: st.Time}
+	}
+
+	return nil
+}
+
+// lock should be held by caller
+func (s *Service) saveState() error {
+	states := make([]notifiedState, 0, len(s.notified))
+	for k, v := range s.notified {
+		identity, check, _ := strings.Cut(k, "\x00")
+		states = append(states, notifiedState{Identity: identity, Check: check, Status: v.status, Time: v.time})
+	}
+
+	j, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+
+	tf, err := os.CreateTemp(filepath.Dir(s.cfg.StateFile), "")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tf.Name())
+
+	_, err = tf.Write(j)
+	tf.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tf.Name(), s.cfg.StateFile)
+}
@@ -0,0 +1,392 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/sirupsen/logrus"
+
+	"github.com/choria-io/go-choria/aagent/watchers/event"
+	"github.com/choria-io/go-choria/aagent/watchers/nagioswatcher"
+	"github.com/choria-io/go-choria/backoff"
+)
+
+func TestNotify(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Scout/Notify")
+}
+
+// fakeSMTP is a minimal SMTP server that records the messages it receives
+type fakeSMTP struct {
+	l        net.Listener
+	messages []string
+	mu       sync.Mutex
+}
+
+func newFakeSMTP() *fakeSMTP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).ToNot(HaveOccurred())
+
+	s := &fakeSMTP{l: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	fmt.Fprintf(conn, "220 localhost ESMTP\r\n")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			fmt.Fprintf(conn, "250 localhost\r\n")
+		case strings.HasPrefix(cmd, "DATA"):
+			fmt.Fprintf(conn, "354 go ahead\r\n")
+			var msg bytes.Buffer
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				msg.WriteString(l)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mu.Unlock()
+			fmt.Fprintf(conn, "250 queued\r\n")
+		case strings.HasPrefix(cmd, "QUIT"):
+			fmt.Fprintf(conn, "221 bye\r\n")
+			return
+		default:
+			fmt.Fprintf(conn, "250 ok\r\n")
+		}
+	}
+}
+
+func (s *fakeSMTP) Messages() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.messages...)
+}
+
+var _ = Describe("Scout/Notify", func() {
+	var (
+		log   *logrus.Entry
+		out   *bytes.Buffer
+		now   time.Time
+		state func(id string, check string, status string) *nagioswatcher.StateNotification
+	)
+
+	BeforeEach(func() {
+		logger := logrus.New()
+		logger.SetOutput(GinkgoWriter)
+		log = logrus.NewEntry(logger)
+		out = bytes.NewBuffer(nil)
+		now = time.Date(2022, 9, 5, 10, 0, 0, 0, time.UTC) // a monday
+
+		state = func(id string, check string, status string) *nagioswatcher.StateNotification {
+			return &nagioswatcher.StateNotification{
+				Event:       event.Event{Identity: id, Machine: check},
+				Status:      status,
+				Output:      status + ": output",
+				Annotations: map[string]string{"team": "web"},
+			}
+		}
+	})
+
+	newService := func(cfg *Config) *Service {
+		svc, err := New(cfg, out, log)
+		Expect(err).ToNot(HaveOccurred())
+		svc.policy = backoff.Policy{Millis: []int{1}}
+		return svc
+	}
+
+	Describe("Config", func() {
+		It("Should validate configurations", func() {
+			cfg := &Config{}
+			Expect(cfg.Validate()).To(MatchError("no sinks configured"))
+
+			cfg.Sinks = map[string]*SinkConfig{"x": {Type: "webhook"}}
+			Expect(cfg.Validate()).To(MatchError("invalid sink x: url is required"))
+
+			cfg.Sinks = map[string]*SinkConfig{"x": {Type: "stdout"}}
+			Expect(cfg.Validate()).To(MatchError("no routes configured"))
+
+			cfg.Routes = []*Route{{Sinks: []string{"y"}}}
+			Expect(cfg.Validate()).To(MatchError("invalid route route 1: unknown sink y"))
+
+			cfg.Routes = []*Route{{Sinks: []string{"x"}, Window: &TimeWindow{Days: []string{"funday"}}}}
+			Expect(cfg.Validate()).To(MatchError(`invalid route route 1: invalid day "funday"`))
+
+			cfg.Routes = []*Route{{Sinks: []string{"x"}}}
+			Expect(cfg.Validate()).To(Succeed())
+			Expect(cfg.Stream).To(Equal("CHORIA_MACHINE"))
+			Expect(cfg.Consumer).To(Equal("SCOUT_NOTIFY"))
+			Expect(cfg.dedupeWindow).To(Equal(time.Hour))
+		})
+	})
+
+	Describe("Routes", func() {
+		It("Should match checks, identities, tags and states", func() {
+			r := &Route{Sinks: []string{"x"}, Checks: []string{"^http"}, Identities: []string{`\.prod$`}, Tags: map[string]string{"team": "web"}, States: []string{"critical"}}
+			Expect(r.Validate()).To(Succeed())
+
+			n := &Notification{Identity: "web1.prod", Check: "http_check", Status: "CRITICAL", Annotations: map[string]string{"team": "web"}}
+			Expect(r.Matches(n, now)).To(BeTrue())
+
+			n.Status = "WARNING"
+			Expect(r.Matches(n, now)).To(BeFalse())
+			n.Status = "CRITICAL"
+
+			n.Identity = "web1.dev"
+			Expect(r.Matches(n, now)).To(BeFalse())
+			n.Identity = "web1.prod"
+
+			n.Annotations = map[string]string{"team": "db"}
+			Expect(r.Matches(n, now)).To(BeFalse())
+		})
+
+		It("Should support time windows", func() {
+			w := &TimeWindow{Days: []string{"mon"}, Start: "09:00", End: "17:00", Timezone: "UTC"}
+			Expect(w.Validate()).To(Succeed())
+			Expect(w.Contains(now)).To(BeTrue())
+			Expect(w.Contains(now.Add(8 * time.Hour))).To(BeFalse())
+			Expect(w.Contains(now.Add(24 * time.Hour))).To(BeFalse())
+
+			night := &TimeWindow{Days: []string{"mon"}, Start: "22:00", End: "06:00", Timezone: "UTC"}
+			Expect(night.Validate()).To(Succeed())
+			Expect(night.Contains(now.Add(13 * time.Hour))).To(BeTrue())  // monday 23:00
+			Expect(night.Contains(now.Add(18 * time.Hour))).To(BeTrue())  // tuesday 04:00
+			Expect(night.Contains(now.Add(42 * time.Hour))).To(BeFalse()) // wednesday 04:00
+			Expect(night.Contains(now)).To(BeFalse())
+		})
+	})
+
+	Describe("Process", func() {
+		It("Should deduplicate notifications and send recoveries", func() {
+			svc := newService(&Config{
+				DedupeWindow: "10m",
+				Sinks:        map[string]*SinkConfig{"console": {Type: "stdout"}},
+				Routes:       []*Route{{Sinks: []string{"console"}}},
+			})
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "OK"), now)).To(Succeed())
+			Expect(out.String()).To(BeEmpty())
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)).To(Succeed())
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now.Add(time.Minute))).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(1))
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now.Add(11*time.Minute))).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(2))
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "OK"), now.Add(12*time.Minute))).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("n1 disk CRITICAL => OK"))
+			Expect(strings.Count(out.String(), "\n")).To(Equal(3))
+
+			suppressed := state("n1", "http", "UNKNOWN")
+			suppressed.Suppressed = true
+			Expect(svc.Process(context.Background(), suppressed, now)).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(3))
+		})
+
+		It("Should deliver to webhooks with retries", func() {
+			var (
+				received []*Notification
+				calls    int
+				mu       sync.Mutex
+			)
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				calls++
+				if calls == 1 {
+					w.WriteHeader(500)
+					return
+				}
+
+				Expect(r.Header.Get("X-Token")).To(Equal("secret"))
+				body, _ := io.ReadAll(r.Body)
+				n := &Notification{}
+				Expect(json.Unmarshal(body, n)).To(Succeed())
+				received = append(received, n)
+			}))
+			defer srv.Close()
+
+			svc := newService(&Config{
+				Sinks:  map[string]*SinkConfig{"hook": {Type: "webhook", URL: srv.URL, Headers: map[string]string{"X-Token": "secret"}}},
+				Routes: []*Route{{Name: "pager", Sinks: []string{"hook"}}},
+			})
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "WARNING"), now)).To(Succeed())
+
+			mu.Lock()
+			defer mu.Unlock()
+			Expect(calls).To(Equal(2))
+			Expect(received).To(HaveLen(1))
+			Expect(received[0].Identity).To(Equal("n1"))
+			Expect(received[0].Check).To(Equal("disk"))
+			Expect(received[0].Status).To(Equal("WARNING"))
+			Expect(received[0].Route).To(Equal("pager"))
+			Expect(received[0].Annotations).To(Equal(map[string]string{"team": "web"}))
+		})
+
+		It("Should fail after exhausting retries and try again later", func() {
+			calls := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(503)
+			}))
+			defer srv.Close()
+
+			svc := newService(&Config{
+				Retries: 2,
+				Sinks:   map[string]*SinkConfig{"hook": {Type: "webhook", URL: srv.URL}},
+				Routes:  []*Route{{Sinks: []string{"hook"}}},
+			})
+
+			err := svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)
+			Expect(err).To(MatchError(ContainSubstring("delivery via hook failed after 2 attempts")))
+			Expect(calls).To(Equal(2))
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)).ToNot(Succeed())
+			Expect(calls).To(Equal(4))
+		})
+
+		It("Should deliver via SMTP", func() {
+			smtpd := newFakeSMTP()
+			defer smtpd.l.Close()
+
+			svc := newService(&Config{
+				Sinks:  map[string]*SinkConfig{"mail": {Type: "smtp", Server: smtpd.l.Addr().String(), From: "scout@example.net", To: []string{"ops@example.net"}}},
+				Routes: []*Route{{Sinks: []string{"mail"}}},
+			})
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)).To(Succeed())
+
+			msgs := smtpd.Messages()
+			Expect(msgs).To(HaveLen(1))
+			Expect(msgs[0]).To(ContainSubstring("Subject: [scout] n1 disk is CRITICAL"))
+			Expect(msgs[0]).To(ContainSubstring("CRITICAL: output"))
+		})
+
+		It("Should not allow headers to be injected into mail", func() {
+			smtpd := newFakeSMTP()
+			defer smtpd.l.Close()
+
+			svc := newService(&Config{
+				Sinks:  map[string]*SinkConfig{"mail": {Type: "smtp", Server: smtpd.l.Addr().String(), From: "scout@example.net", To: []string{"ops@example.net"}}},
+				Routes: []*Route{{Sinks: []string{"mail"}}},
+			})
+
+			Expect(svc.Process(context.Background(), state("n1\r\nBcc: evil@example.net", "disk\nX-Injected: yes", "CRITICAL"), now)).To(Succeed())
+
+			msgs := smtpd.Messages()
+			Expect(msgs).To(HaveLen(1))
+
+			headers, _, _ := strings.Cut(msgs[0], "\r\n\r\n")
+			Expect(headers).To(ContainSubstring("Subject: [scout] n1 Bcc: evil@example.net disk X-Injected: yes is CRITICAL\r\n"))
+			Expect(headers).ToNot(ContainSubstring("\r\nBcc:"))
+			Expect(headers).ToNot(ContainSubstring("\nX-Injected:"))
+		})
+
+		It("Should give up on SMTP servers that do not respond", func() {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+			defer l.Close()
+
+			go func() {
+				for {
+					conn, err := l.Accept()
+					if err != nil {
+						return
+					}
+					defer conn.Close()
+				}
+			}()
+
+			sink, err := newSink(&SinkConfig{Type: "smtp", Server: l.Addr().String(), From: "scout@example.net", To: []string{"ops@example.net"}, Timeout: "200ms"}, out)
+			Expect(err).ToNot(HaveOccurred())
+
+			start := time.Now()
+			err = sink.Deliver(context.Background(), &Notification{Identity: "n1", Check: "disk", Status: "CRITICAL"})
+			Expect(err).To(MatchError(ContainSubstring("context deadline exceeded")))
+			Expect(time.Since(start)).To(BeNumerically("<", 2*time.Second))
+		})
+
+		It("Should persist the deduplication state", func() {
+			cfg := &Config{
+				DedupeWindow: "10m",
+				StateFile:    filepath.Join(GinkgoT().TempDir(), "state.json"),
+				Sinks:        map[string]*SinkConfig{"console": {Type: "stdout"}},
+				Routes:       []*Route{{Sinks: []string{"console"}}},
+			}
+
+			svc := newService(cfg)
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(1))
+
+			svc = newService(cfg)
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now.Add(time.Minute))).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(1))
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "OK"), now.Add(2*time.Minute))).To(Succeed())
+			Expect(out.String()).To(ContainSubstring("n1 disk CRITICAL => OK"))
+		})
+
+		It("Should stop at the first matching route unless continue is set", func() {
+			svc := newService(&Config{
+				Sinks: map[string]*SinkConfig{"console": {Type: "stdout"}},
+				Routes: []*Route{
+					{Name: "first", Checks: []string{"disk"}, Sinks: []string{"console"}, Continue: true},
+					{Name: "second", Sinks: []string{"console"}},
+					{Name: "third", Sinks: []string{"console"}},
+				},
+			})
+
+			Expect(svc.Process(context.Background(), state("n1", "disk", "CRITICAL"), now)).To(Succeed())
+			Expect(strings.Count(out.String(), "\n")).To(Equal(2))
+		})
+	})
+})
@@ -0,0 +1,105 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Matches determines if the route applies to a notification at a specific time
+func (r *Route) Matches(n *Notification, now time.Time) bool {
+	if !matchAny(r.checks, n.Check) {
+		return false
+	}
+
+	if !matchAny(r.identities, n.Identity) {
+		return false
+	}
+
+	for k, v := range r.Tags {
+		if n.Annotations[k] != v {
+			return false
+		}
+	}
+
+	if len(r.States) > 0 {
+		found := false
+		for _, s := range r.States {
+			if s == n.Status {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if r.Window != nil && !r.Window.Contains(now) {
+		return false
+	}
+
+	return true
+}
+
+// Contains determines if t falls within the window
+func (w *TimeWindow) Contains(t time.Time) bool {
+	loc := w.location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+
+	offset := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	day := t.Weekday()
+
+	switch {
+	case w.start == w.end:
+		// a window without times covers the whole day
+		return w.onDay(day)
+
+	case w.start < w.end:
+		return w.onDay(day) && offset >= w.start && offset < w.end
+
+	default:
+		// windows spanning midnight belong to the day they started on
+		if offset >= w.start {
+			return w.onDay(day)
+		}
+
+		return offset < w.end && w.onDay((day+6)%7)
+	}
+}
+
+func (w *TimeWindow) onDay(d time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+
+	for _, day := range w.Days {
+		if weekDays[strings.ToLower(day)] == d {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	if len(res) == 0 {
+		return true
+	}
+
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,220 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+
+	iu "github.com/choria-io/go-choria/internal/util"
+)
+
+// Sink delivers notifications to a destination
+type Sink interface {
+	Deliver(ctx context.Context, n *Notification) error
+}
+
+type webhookSink struct {
+	cfg     *SinkConfig
+	timeout time.Duration
+	client  *http.Client
+}
+
+type smtpSink struct {
+	cfg     *SinkConfig
+	timeout time.Duration
+}
+
+type writerSink struct {
+	out io.Writer
+}
+
+func newSink(cfg *SinkConfig, stdout io.Writer) (Sink, error) {
+	timeout := 10 * time.Second
+	if cfg.Timeout != "" {
+		var err error
+		timeout, err = iu.ParseDuration(cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	switch cfg.Type {
+	case "webhook":
+		return &webhookSink{cfg: cfg, timeout: timeout, client: &http.Client{}}, nil
+	case "smtp":
+		return &smtpSink{cfg: cfg, timeout: timeout}, nil
+	case "stdout":
+		return &writerSink{out: stdout}, nil
+	default:
+		return nil, fmt.Errorf("unknown sink type %q", cfg.Type)
+	}
+}
+
+func (s *webhookSink) Deliver(ctx context.Context, n *Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(tctx, http.MethodPost, s.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (s *smtpSink) Deliver(ctx context.Context, n *Notification) error {
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, "From: %s\r\n", s.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mailSubject(n))
+	fmt.Fprintf(&msg, "Date: %s\r\n", n.Time.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	fmt.Fprintf(&msg, "%s\r\n", n.String())
+	if n.Output != "" {
+		fmt.Fprintf(&msg, "\r\n%s\r\n", n.Output)
+	}
+
+	host, _, err := net.SplitHostPort(s.cfg.Server)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+
+	tctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err = s.send(tctx, host, auth, msg.Bytes())
+	if err != nil && tctx.Err() != nil {
+		return fmt.Errorf("sending mail via %s failed: %s", s.cfg.Server, tctx.Err())
+	}
+
+	return err
+}
+
+// headerSafe removes line breaks that would let nodes publishing events inject headers into mail
+var headerSafe = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// mailSubject is the encoded subject for a notification
+func mailSubject(n *Notification) string {
+	subject := fmt.Sprintf("[scout] %s %s is %s", n.Identity, n.Check, n.Status)
+
+	return mime.QEncoding.Encode("utf-8", headerSafe.Replace(subject))
+}
+
+// send delivers the message over a connection that is closed when ctx is done so no work outlives the delivery
+func (s *smtpSink) send(ctx context.Context, host string, auth smtp.Auth, msg []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.cfg.Server)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		err = c.StartTLS(&tls.Config{ServerName: host})
+		if err != nil {
+			return err
+		}
+	}
+
+	if auth != nil {
+		err = c.Auth(auth)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = c.Mail(s.cfg.From)
+	if err != nil {
+		return err
+	}
+
+	for _, to := range s.cfg.To {
+		err = c.Rcpt(to)
+		if err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(msg)
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+func (s *writerSink) Deliver(_ context.Context, n *Notification) error {
+	_, err := fmt.Fprintln(s.out, n.String())
+	return err
+}
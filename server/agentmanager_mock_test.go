@@ -291,6 +291,62 @@ func (mr *MockServerInfoSourceMockRecorder) LastProcessedMessage() *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastProcessedMessage", reflect.TypeOf((*MockServerInfoSource)(nil).LastProcessedMessage))
 }
 
+// MachineDataPut mocks base method.
+func (m *MockServerInfoSource) MachineDataPut(name, version, path, id, key string, value any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineDataPut", name, version, path, id, key, value)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineDataPut indicates an expected call of MachineDataPut.
+func (mr *MockServerInfoSourceMockRecorder) MachineDataPut(name, version, path, id, key, value any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineDataPut", reflect.TypeOf((*MockServerInfoSource)(nil).MachineDataPut), name, version, path, id, key, value)
+}
+
+// MachinePause mocks base method.
+func (m *MockServerInfoSource) MachinePause(name, version, path, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachinePause", name, version, path, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachinePause indicates an expected call of MachinePause.
+func (mr *MockServerInfoSourceMockRecorder) MachinePause(name, version, path, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachinePause", reflect.TypeOf((*MockServerInfoSource)(nil).MachinePause), name, version, path, id)
+}
+
+// MachineReload mocks base method.
+func (m *MockServerInfoSource) MachineReload(name, version, path, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineReload", name, version, path, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineReload indicates an expected call of MachineReload.
+func (mr *MockServerInfoSourceMockRecorder) MachineReload(name, version, path, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineReload", reflect.TypeOf((*MockServerInfoSource)(nil).MachineReload), name, version, path, id)
+}
+
+// MachineResume mocks base method.
+func (m *MockServerInfoSource) MachineResume(name, version, path, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MachineResume", name, version, path, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MachineResume indicates an expected call of MachineResume.
+func (mr *MockServerInfoSourceMockRecorder) MachineResume(name, version, path, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MachineResume", reflect.TypeOf((*MockServerInfoSource)(nil).MachineResume), name, version, path, id)
+}
+
 // MachineTransition mocks base method.
 func (m *MockServerInfoSource) MachineTransition(name, version, path, id, transition string) error {
 	m.ctrl.T.Helper()
@@ -33,6 +33,12 @@ type Agent interface {
 	ShouldActivate() bool
 }
 
+// BackgroundAgent is an agent that runs background tasks for as long as the server is running,
+// the context is cancelled when the server shuts down
+type BackgroundAgent interface {
+	StartBackground(ctx context.Context)
+}
+
 // ServerInfoSource provides data about a running server instance
 type ServerInfoSource interface {
 	AgentMetadata(string) (Metadata, bool)
@@ -45,6 +51,10 @@ type ServerInfoSource interface {
 	Identity() string
 	KnownAgents() []string
 	LastProcessedMessage() time.Time
+	MachineDataPut(name string, version string, path string, id string, key string, value any) error
+	MachinePause(name string, version string, path string, id string) error
+	MachineReload(name string, version string, path string, id string) error
+	MachineResume(name string, version string, path string, id string) error
 	MachineTransition(name string, version string, path string, id string, transition string) error
 	MachinesStatus() ([]aagent.MachineState, error)
 	NewEvent(t lifecycle.Type, opts ...lifecycle.Option) error
@@ -171,6 +181,10 @@ func (a *Manager) RegisterAgent(ctx context.Context, name string, agent Agent, c
 
 	a.agents[name] = agent
 
+	if ba, ok := agent.(BackgroundAgent); ok {
+		ba.StartBackground(ctx)
+	}
+
 	return nil
 }
 
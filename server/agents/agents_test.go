@@ -26,6 +26,15 @@ import (
 	. "github.com/onsi/gomega"
 )
 
+type backgroundAgent struct {
+	*MockAgent
+	ctx context.Context
+}
+
+func (a *backgroundAgent) StartBackground(ctx context.Context) {
+	a.ctx = ctx
+}
+
 func Test(t *testing.T) {
 	os.Setenv("MCOLLECTIVE_CERTNAME", "rip.mcollective")
 	RegisterFailHandler(Fail)
@@ -162,6 +171,19 @@ var _ = Describe("Server/Agents", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("should start background agents with the registration context", func() {
+			conn.EXPECT().AgentBroadcastTarget("cone", "stub").Return("cone.stub")
+			conn.EXPECT().AgentBroadcastTarget("ctwo", "stub").Return("ctwo.stub")
+			conn.EXPECT().QueueSubscribe(gomock.Any(), "cone.stub", "cone.stub", "", gomock.Any()).Return(nil).Times(1)
+			conn.EXPECT().QueueSubscribe(gomock.Any(), "ctwo.stub", "ctwo.stub", "", gomock.Any()).Return(nil).Times(1)
+			agent.EXPECT().ShouldActivate().Return(true).AnyTimes()
+
+			ba := &backgroundAgent{MockAgent: agent}
+			err := mgr.RegisterAgent(ctx, "stub", ba, conn)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ba.ctx).To(Equal(ctx))
+		})
+
 		It("should support service agents", func() {
 			agent.Metadata().Service = true
 			conn.EXPECT().ServiceBroadcastTarget("cone", "stub").Return("cone.stub")
@@ -119,6 +119,42 @@ func (srv *Instance) MachineTransition(name string, version string, path string,
 	return srv.machines.Transition(name, version, path, id, transition)
 }
 
+// MachinePause pauses a specific running machine instance
+func (srv *Instance) MachinePause(name string, version string, path string, id string) error {
+	if srv.machines == nil {
+		return fmt.Errorf("autonomous agent host not initialized")
+	}
+
+	return srv.machines.Pause(name, version, path, id)
+}
+
+// MachineResume resumes a specific paused machine instance
+func (srv *Instance) MachineResume(name string, version string, path string, id string) error {
+	if srv.machines == nil {
+		return fmt.Errorf("autonomous agent host not initialized")
+	}
+
+	return srv.machines.Resume(name, version, path, id)
+}
+
+// MachineReload reloads a specific running machine instance from its source directory
+func (srv *Instance) MachineReload(name string, version string, path string, id string) error {
+	if srv.machines == nil {
+		return fmt.Errorf("autonomous agent host not initialized")
+	}
+
+	return srv.machines.Reload(name, version, path, id)
+}
+
+// MachineDataPut stores a data item in a specific running machine instance
+func (srv *Instance) MachineDataPut(name string, version string, path string, id string, key string, value any) error {
+	if srv.machines == nil {
+		return fmt.Errorf("autonomous agent host not initialized")
+	}
+
+	return srv.machines.DataPut(name, version, path, id, key, value)
+}
+
 // LastProcessedMessage is the time that the last message was processed in local time
 func (srv *Instance) LastProcessedMessage() time.Time {
 	return srv.lastMsgProcessed
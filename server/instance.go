@@ -14,6 +14,7 @@ import (
 	"github.com/choria-io/go-choria/config"
 	"github.com/choria-io/go-choria/inter"
 	"github.com/choria-io/go-choria/providers/data"
+	"github.com/choria-io/go-choria/providers/revocation"
 	"github.com/choria-io/go-choria/server/agents"
 	"github.com/choria-io/go-choria/server/discovery"
 	"github.com/choria-io/go-choria/server/registration"
@@ -37,6 +38,7 @@ type Instance struct {
 	lifecycleComponent string
 	machines           *aagent.AAgent
 	data               *data.Manager
+	revocations        *revocation.List
 
 	requests chan inter.ConnectorMessage
 
@@ -124,6 +126,8 @@ func (srv *Instance) RunServiceHost(ctx context.Context, wg *sync.WaitGroup) err
 	wg.Add(1)
 	go srv.WriteServerStatus(sctx, wg)
 
+	srv.setupRevocations(sctx, wg)
+
 	srv.agents = agents.NewServices(srv.requests, srv.fw, srv.connector, srv, srv.log)
 
 	err = srv.setupAdditionalAgentProviders(sctx)
@@ -191,6 +195,8 @@ func (srv *Instance) Run(ctx context.Context, wg *sync.WaitGroup) error {
 	wg.Add(1)
 	go srv.WriteServerStatus(sctx, wg)
 
+	srv.setupRevocations(sctx, wg)
+
 	srv.agents = agents.New(srv.requests, srv.fw, srv.connector, srv, srv.log)
 	srv.registration = registration.New(srv.fw, srv, srv.connector, srv.log)
 
@@ -10,6 +10,7 @@ import (
 	"sync"
 
 	"github.com/choria-io/go-choria/aagent"
+	"github.com/choria-io/go-choria/aagent/watchers/nagioswatcher"
 	"github.com/choria-io/go-choria/internal/util"
 )
 
@@ -32,6 +33,17 @@ func (srv *Instance) StartMachine(ctx context.Context, wg *sync.WaitGroup) (err
 		}
 	}
 
+	if srv.cfg.Choria.ScoutPrometheus {
+		if srv.cfg.Choria.StatsPort == 0 {
+			srv.log.Warnf("Scout Prometheus metrics enabled but plugin.choria.stats_port is not set, metrics will not be exposed")
+		}
+
+		err = nagioswatcher.RegisterPrometheus()
+		if err != nil {
+			srv.log.Errorf("Could not register Scout Prometheus metrics: %s", err)
+		}
+	}
+
 	srv.machines, err = aagent.New(srv.cfg.Choria.MachineSourceDir, srv)
 	if err != nil {
 		return err
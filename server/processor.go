@@ -41,6 +41,12 @@ func (srv *Instance) handleRawMessage(ctx context.Context, wg *sync.WaitGroup, r
 		return
 	}
 
+	if r, revoked := srv.revokedRequest(req); revoked {
+		revokedCtr.WithLabelValues(srv.cfg.Identity).Inc()
+		srv.log.Errorf("Discarding request %s from %s sent by %s using revoked %s", req.RequestID(), req.CallerID(), req.SenderID(), r)
+		return
+	}
+
 	protocol.CopyFederationData(transport, req)
 
 	if !srv.discovery.ShouldProcess(req) {
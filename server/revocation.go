@@ -0,0 +1,83 @@
+// Copyright (c) 2022, R.I. Pienaar and the Choria Project contributors
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/choria-io/go-choria/protocol"
+	"github.com/choria-io/go-choria/providers/revocation"
+	"github.com/choria-io/go-choria/tokens"
+)
+
+// setupRevocations loads the revocation list and keeps it updated when revocations are configured
+func (srv *Instance) setupRevocations(ctx context.Context, wg *sync.WaitGroup) {
+	cfg := srv.cfg.Choria
+	if cfg.RevocationFile == "" && !cfg.RevocationKV {
+		return
+	}
+
+	list := revocation.NewList()
+	log := srv.log.WithField("component", "revocation")
+
+	if cfg.RevocationFile != "" {
+		err := list.LoadFile(cfg.RevocationFile)
+		if err != nil {
+			log.Errorf("Could not load revocation list %s: %s", cfg.RevocationFile, err)
+		}
+
+		wg.Add(1)
+		go list.WatchFile(ctx, wg, cfg.RevocationFile, 10*time.Second, log)
+	}
+
+	if cfg.RevocationKV {
+		wg.Add(1)
+		if org := srv.organization(); org != "" && org != "choria" {
+			log.Infof("Watching the revocation bucket imported into the %s organization", org)
+			go list.WatchImportedBucket(ctx, wg, srv.connector.Nats(), log)
+		} else {
+			go list.WatchBucket(ctx, wg, srv.connector.Nats(), log)
+		}
+	}
+
+	srv.mu.Lock()
+	srv.revocations = list
+	srv.mu.Unlock()
+}
+
+// organization is the organization from the server token, servers in other organizations than choria are hosted
+// in their own accounts when the broker isolates organizations
+func (srv *Instance) organization() string {
+	token, err := srv.fw.SignerToken()
+	if err != nil || token == "" {
+		return ""
+	}
+
+	claims, err := tokens.ParseServerTokenUnverified(token)
+	if err != nil {
+		return ""
+	}
+
+	return claims.OrganizationUnit
+}
+
+// revokedRequest checks if the caller or sender of a request has been revoked.
+//
+// Requests do not carry the tokens they were made with so servers only enforce caller and identity revocations,
+// token ID and public key revocations are enforced by the broker when connecting and by disconnecting revoked
+// connections
+func (srv *Instance) revokedRequest(req protocol.Request) (*revocation.Revocation, bool) {
+	srv.mu.Lock()
+	list := srv.revocations
+	srv.mu.Unlock()
+
+	if r, revoked := list.IsRevoked(revocation.Caller, req.CallerID()); revoked {
+		return r, true
+	}
+
+	return list.IsRevoked(revocation.Identity, req.SenderID())
+}
@@ -43,6 +43,11 @@ var (
 		Name: "choria_server_ttlexpired",
 		Help: "Number of messages received that were too old and dropped",
 	}, []string{"identity"})
+
+	revokedCtr = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "choria_server_revoked",
+		Help: "Number of messages received from revoked callers or senders that were dropped",
+	}, []string{"identity"})
 )
 
 func init() {
@@ -52,5 +57,6 @@ func init() {
 	prometheus.MustRegister(filteredCtr)
 	prometheus.MustRegister(repliesCtr)
 	prometheus.MustRegister(ttlExpiredCtr)
+	prometheus.MustRegister(revokedCtr)
 	prometheus.MustRegister(totalCtr)
 }
@@ -10,6 +10,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
@@ -19,7 +21,8 @@ type ClientPermissions struct {
 	// StreamsAdmin enables full access to Choria Streams for all APIs
 	StreamsAdmin bool `json:"streams_admin,omitempty"`
 
-	// StreamsUser enables user level access to Choria Streams, no stream admin features
+	// StreamsUser enables user level access to Choria Streams, no stream admin features. This includes reading and
+	// deleting messages in all streams and so all Key-Value buckets, KV grants do not limit streams users
 	StreamsUser bool `json:"streams_user,omitempty"`
 
 	// EventsViewer allows viewing lifecycle and auto agent events
@@ -39,6 +42,80 @@ type ClientPermissions struct {
 
 	// ExtendedServiceLifetime allows a token to have a longer than common life time, suitable for services users
 	ExtendedServiceLifetime bool `json:"service,omitempty"`
+
+	// KV grants access to specific Key-Value buckets or keys, does not require Streams permissions and only isolates
+	// users without StreamsUser or StreamsAdmin access
+	KV []*KVPermission `json:"kv,omitempty"`
+}
+
+// KVPermission grants access to keys in a Key-Value bucket
+type KVPermission struct {
+	// Bucket is the name of the bucket
+	Bucket string `json:"bucket"`
+
+	// Prefix limits access to keys below the prefix, users grants access to users and users.>, empty for the entire bucket.
+	// Reading keys by prefix uses direct get and so requires the bucket to have AllowDirect enabled. Only read access
+	// can be limited by prefix as writers can roll up, and so purge, all keys in a bucket
+	Prefix string `json:"prefix,omitempty"`
+
+	// Read allows reading values, watching, listing keys and viewing history requires read access to the entire bucket
+	Read bool `json:"read,omitempty"`
+
+	// Write allows storing, deleting and purging values
+	Write bool `json:"write,omitempty"`
+}
+
+var (
+	validBucketName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+	validKeyPrefix  = regexp.MustCompile(`^[-/_=a-zA-Z0-9*>]+$`)
+)
+
+// Validate checks that the bucket and prefix are valid
+func (p *KVPermission) Validate() error {
+	if !validBucketName.MatchString(p.Bucket) {
+		return fmt.Errorf("invalid bucket name %q", p.Bucket)
+	}
+
+	if p.Prefix == "" {
+		return nil
+	}
+
+	if p.Write {
+		return fmt.Errorf("write access to bucket %s can not be limited to a key prefix", p.Bucket)
+	}
+
+	parts := strings.Split(p.Prefix, ".")
+	for i, t := range parts {
+		if !validKeyPrefix.MatchString(t) {
+			return fmt.Errorf("invalid key prefix %q", p.Prefix)
+		}
+
+		if strings.ContainsAny(t, "*>") && len(t) > 1 || t == ">" && i != len(parts)-1 {
+			return fmt.Errorf("invalid key prefix %q", p.Prefix)
+		}
+	}
+
+	return nil
+}
+
+func (p *KVPermission) String() string {
+	access := []string{}
+	if p.Read {
+		access = append(access, "read")
+	}
+	if p.Write {
+		access = append(access, "write")
+	}
+	if len(access) == 0 {
+		access = append(access, "none")
+	}
+
+	keys := "all keys"
+	if p.Prefix != "" {
+		keys = fmt.Sprintf("keys under %s", p.Prefix)
+	}
+
+	return fmt.Sprintf("%s access to %s in bucket %s", strings.Join(access, " and "), keys, p.Bucket)
 }
 
 // ClientIDClaims represents a user and all AAA Authenticators should create a JWT using this format
@@ -51,7 +128,7 @@ type ClientIDClaims struct {
 	// AllowedAgents is a list of agent names or agent.action names this user can perform
 	AllowedAgents []string `json:"agents,omitempty"`
 
-	// OrganizationUnit is currently unused but will indicate the server account a user should belong to, set to 'choria' now
+	// OrganizationUnit is the organization a user belongs to, brokers hosting multiple organizations isolate them in separate accounts
 	OrganizationUnit string `json:"ou,omitempty"`
 
 	// UserProperties is a list of arbitrary properties that can be set for a user, OPA Policies in the token can access these
@@ -98,6 +175,10 @@ func NewClientIDClaims(callerID string, allowedAgents []string, org string, prop
 		org = "choria"
 	}
 
+	if !IsValidOrganization(org) {
+		return nil, fmt.Errorf("invalid organization %q", org)
+	}
+
 	return &ClientIDClaims{
 		CallerID:         callerID,
 		AllowedAgents:    allowedAgents,
@@ -65,6 +65,12 @@ var _ = Describe("ClientIDClaims", func() {
 			Expect(claims).To(BeNil())
 		})
 
+		It("Should require a valid organization", func() {
+			claims, err := NewClientIDClaims("up=ginkgo", []string{"rpcutil"}, "acme.corp", nil, "", "", time.Hour, perms, pubK)
+			Expect(err).To(MatchError(`invalid organization "acme.corp"`))
+			Expect(claims).To(BeNil())
+		})
+
 		It("Should create correct claims", func() {
 			claims, err := NewClientIDClaims("up=ginkgo", []string{"rpcutil"}, "choria", map[string]string{"group": "admins"}, "// opa policy", "Ginkgo", time.Hour, perms, pubK)
 			Expect(err).ToNot(HaveOccurred())
@@ -76,6 +82,7 @@ var _ = Describe("ClientIDClaims", func() {
 			Expect(claims.UserProperties).To(Equal(map[string]string{"group": "admins"}))
 			Expect(claims.OPAPolicy).To(Equal("// opa policy"))
 			Expect(claims.PublicKey).To(Equal(hex.EncodeToString(pubK)))
+			Expect(claims.ID).To(HaveLen(36))
 			Expect(claims.IssuedAt.Time).To(BeTemporally("~", time.Now(), time.Second))
 			Expect(claims.ExpiresAt.Time).To(BeTemporally("~", time.Now().Add(time.Hour), time.Second))
 			Expect(claims.Permissions).To(Equal(perms))
@@ -145,4 +152,26 @@ var _ = Describe("ClientIDClaims", func() {
 			Expect(t).To(BeNil())
 		})
 	})
+
+	Describe("KVPermission", func() {
+		It("Should validate the bucket and prefix", func() {
+			Expect((&KVPermission{Bucket: "CONFIG"}).Validate()).To(Succeed())
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users"}).Validate()).To(Succeed())
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users.*.age"}).Validate()).To(Succeed())
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users.>"}).Validate()).To(Succeed())
+
+			Expect((&KVPermission{Bucket: ""}).Validate()).To(MatchError(`invalid bucket name ""`))
+			Expect((&KVPermission{Bucket: "CONFIG.>"}).Validate()).To(MatchError(`invalid bucket name "CONFIG.>"`))
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users."}).Validate()).To(MatchError(`invalid key prefix "users."`))
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users.>.age"}).Validate()).To(MatchError(`invalid key prefix "users.>.age"`))
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users.b*"}).Validate()).To(MatchError(`invalid key prefix "users.b*"`))
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users", Write: true}).Validate()).To(MatchError("write access to bucket CONFIG can not be limited to a key prefix"))
+			Expect((&KVPermission{Bucket: "CONFIG", Write: true}).Validate()).To(Succeed())
+		})
+
+		It("Should describe the grant", func() {
+			Expect((&KVPermission{Bucket: "CONFIG", Read: true, Write: true}).String()).To(Equal("read and write access to all keys in bucket CONFIG"))
+			Expect((&KVPermission{Bucket: "CONFIG", Prefix: "users", Read: true}).String()).To(Equal("read access to keys under users in bucket CONFIG"))
+		})
+	})
 })
@@ -43,7 +43,7 @@ type ServerClaims struct {
 	// Permissions are additional abilities the server will have
 	Permissions *ServerPermissions `json:"permissions,omitempty"`
 
-	// OrganizationUnit is currently unused but will indicate the server account a node should belong to, set to 'choria' now
+	// OrganizationUnit is the organization a node belongs to, brokers hosting multiple organizations isolate them in separate accounts
 	OrganizationUnit string `json:"ou,omitempty"`
 
 	// AdditionalPublishSubjects are additional subjects the server can publish to facilitate for example custom registration paths
@@ -117,6 +117,10 @@ func NewServerClaims(identity string, collectives []string, org string, perms *S
 		org = "choria"
 	}
 
+	if !IsValidOrganization(org) {
+		return nil, fmt.Errorf("invalid organization %q", org)
+	}
+
 	if issuer == "" {
 		issuer = "choria"
 	}
@@ -44,6 +44,11 @@ var _ = Describe("ServerClaims", func() {
 			Expect(err).To(MatchError("public key is required"))
 		})
 
+		It("Should require a valid organization", func() {
+			_, err := NewServerClaims("ginkgo.example.net", []string{"choria"}, "acme.corp", nil, nil, pubK, "", 0)
+			Expect(err).To(MatchError(`invalid organization "acme.corp"`))
+		})
+
 		It("Should create a valid token", func() {
 			perms := &ServerPermissions{Submission: true}
 			claims, err := NewServerClaims("ginkgo.example.net", []string{"choria"}, "ginkgo_org", perms, []string{"choria.registration"}, pubK, "ginkgo issuer", 365*24*time.Hour)
@@ -55,6 +60,7 @@ var _ = Describe("ServerClaims", func() {
 			Expect(claims.PublicKey).To(Equal(hex.EncodeToString(pubK)))
 			Expect(claims.OrganizationUnit).To(Equal("ginkgo_org"))
 			Expect(claims.Issuer).To(Equal("ginkgo issuer"))
+			Expect(claims.ID).To(HaveLen(36))
 			Expect(claims.AdditionalPublishSubjects).To(Equal([]string{"choria.registration"}))
 			Expect(claims.IssuedAt.Time).To(BeTemporally("~", time.Now(), time.Second))
 			Expect(claims.ExpiresAt.Time).To(BeTemporally("~", time.Now().Add(365*24*time.Hour), time.Second))
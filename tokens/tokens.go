@@ -11,8 +11,10 @@ import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"regexp"
 	"time"
 
+	"github.com/gofrs/uuid"
 	"github.com/golang-jwt/jwt/v4"
 )
 
@@ -44,6 +46,13 @@ const (
 // MapClaims are free form map claims
 type MapClaims jwt.MapClaims
 
+var validOrganization = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// IsValidOrganization determines if org is a valid organization name
+func IsValidOrganization(org string) bool {
+	return validOrganization.MatchString(org)
+}
+
 // ParseToken parses token into claims and verify the token is valid using the pk
 func ParseToken(token string, claims jwt.Claims, pk any) error {
 	if pk == nil {
@@ -167,10 +176,16 @@ func SaveAndSignTokenWithKeyFile(claims jwt.Claims, pkFile string, outFile strin
 }
 
 func newStandardClaims(issuer string, purpose Purpose, validity time.Duration, setSubject bool) (*StandardClaims, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
 	now := jwt.NewNumericDate(time.Now().UTC())
 	claims := &StandardClaims{
 		Purpose: purpose,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id.String(),
 			Issuer:    issuer,
 			IssuedAt:  now,
 			NotBefore: now,